@@ -2,22 +2,22 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+package c2go
 
 import "github.com/hajimehoshi/cingo/cc"
 
 // fixArrays rewrites uses of the untyped "Array" container defined in cmd/gc
 // to use native Go slices.
 // It has nothing to do with standard C arrays.
-func fixArray(fn *cc.Decl, x *cc.Expr) {
+func (cfg *Config) fixArray(fn *cc.Decl, x *cc.Expr) {
 	// arraynew(n, sizeof(T)) becomes Go make([]T, 0, n).
 	if isCall(x, "arraynew") {
 		if len(x.List) != 2 {
-			fprintf(x.Span, "wrong number of arguments to arraynew")
+			cfg.warnf(x.Span, "array", "wrong number of arguments to arraynew")
 			return
 		}
 		if x.List[1].Op != cc.SizeofType {
-			fprintf(x.Span, "second argument to arraynew must be sizeof(T)")
+			cfg.warnf(x.Span, "array", "second argument to arraynew must be sizeof(T)")
 			return
 		}
 		x.Left.Text = "make"
@@ -46,11 +46,11 @@ func fixArray(fn *cc.Decl, x *cc.Expr) {
 	// other place too. In cmd/gc this does not happen.
 	if isCall(x, "arrayadd") {
 		if len(x.List) != 2 {
-			fprintf(x.Span, "wrong number of arguments to arrayadd")
+			cfg.warnf(x.Span, "array", "wrong number of arguments to arrayadd")
 			return
 		}
 		if x.List[1].Op != cc.Addr {
-			fprintf(x.Span, "second argument to arrayadd must be &x, have %v", x.List[1])
+			cfg.warnf(x.Span, "array", "second argument to arrayadd must be &x, have %v", x.List[1])
 			return
 		}
 		append := copyExpr(x)
@@ -0,0 +1,296 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// A bitGroup is a run of consecutive bit-fields in a struct,
+// packed into a single unsigned integer field.
+type bitGroup struct {
+	field  string
+	bits   int64 // size of the field in bits
+	decls  []*cc.Decl
+	offset map[*cc.Decl]int64
+}
+
+// rewriteBitFields translates uses of C bit-fields.
+//
+// Consecutive bit-fields in a struct are packed into one unsigned
+// integer field, and each bit-field a gets a getter a() and a setter setA(v).
+// A read x.a becomes x.a(), and an assignment x.a = v becomes x.setA(v).
+//
+// It runs after the declarations have their final names,
+// so that setter names follow the exported or renamed field names.
+func rewriteBitFields(cfg *Config, prog *cc.Prog) {
+	types := make(map[*cc.Type]bool)
+	cc.Preorder(prog, func(x cc.Syntax) {
+		if t, ok := x.(*cc.Type); ok && t.Kind == cc.Struct && hasBitFields(t) {
+			types[t] = true
+		}
+	})
+	if len(types) == 0 {
+		return
+	}
+	nameTypes(cfg, prog, types)
+
+	// Assignments to bit-fields can only be rewritten
+	// when they are statements, since setters have no value.
+	stmt := make(map[*cc.Expr]bool)
+	var markStmt func(x *cc.Expr)
+	markStmt = func(x *cc.Expr) {
+		if x == nil {
+			return
+		}
+		stmt[x] = true
+		if x.Op == cc.Comma {
+			for _, y := range x.List {
+				markStmt(y)
+			}
+		}
+	}
+	cc.Preorder(prog, func(x cc.Syntax) {
+		if s, ok := x.(*cc.Stmt); ok {
+			switch s.Op {
+			case cc.StmtExpr:
+				markStmt(s.Expr)
+			case cc.For:
+				markStmt(s.Pre)
+				markStmt(s.Post)
+			}
+		}
+	})
+
+	var reads, writes []*cc.Expr
+	target := make(map[*cc.Expr]bool)
+	cc.Preorder(prog, func(x cc.Syntax) {
+		switch x := x.(type) {
+		case *cc.Init:
+			t := x.XType.Def()
+			if t != nil && types[t] && len(x.Braced) > 0 {
+				cfg.warnf(x.Span, "bitfield", "cannot translate initializer of struct %s with bit-fields", t.Tag)
+			}
+
+		case *cc.Expr:
+			if isBitField(x) && !target[x] {
+				reads = append(reads, x)
+				return
+			}
+			if x.Left == nil || !isBitField(x.Left) {
+				return
+			}
+			if _, ok := bitFieldOp(x.Op); !ok && x.Op != cc.Eq {
+				return
+			}
+			target[x.Left] = true
+			if !stmt[x] {
+				cfg.warnf(x.Span, "bitfield", "cannot translate use of bit-field assignment %v as a value", x)
+				return
+			}
+			writes = append(writes, x)
+		}
+	})
+
+	// x.a becomes x.a().
+	for _, x := range reads {
+		*x = *bitFieldGet(x)
+	}
+
+	// x.a = v becomes x.setA(v), and x.a += v becomes x.setA(x.a() + v).
+	setters := make(map[*cc.Decl]*cc.Decl)
+	for _, x := range writes {
+		d := x.Left.XDecl
+		if setters[d] == nil {
+			setters[d] = &cc.Decl{Name: bitFieldSetter(d.Name)}
+		}
+		v := x.Right
+		if op, ok := bitFieldOp(x.Op); ok {
+			if v == nil {
+				v = &cc.Expr{Op: cc.Number, Text: "1", XType: d.Type}
+			}
+			v = &cc.Expr{
+				SyntaxInfo: x.SyntaxInfo,
+				Op:         op,
+				Left:       bitFieldGet(x.Left),
+				Right:      v,
+				XType:      d.Type,
+			}
+		}
+		*x = cc.Expr{
+			SyntaxInfo: x.SyntaxInfo,
+			Op:         cc.Call,
+			Left: &cc.Expr{
+				SyntaxInfo: x.Left.SyntaxInfo,
+				Op:         x.Left.Op,
+				Left:       x.Left.Left,
+				Text:       x.Left.Text,
+				XDecl:      setters[d],
+			},
+			List: []*cc.Expr{v},
+		}
+	}
+}
+
+// bitFieldOp returns the binary operator applied by
+// the bit-field assignment operator op.
+func bitFieldOp(op cc.ExprOp) (cc.ExprOp, bool) {
+	switch op {
+	case cc.AddEq, cc.PostInc, cc.PreInc:
+		return cc.Add, true
+	case cc.SubEq, cc.PostDec, cc.PreDec:
+		return cc.Sub, true
+	case cc.MulEq:
+		return cc.Mul, true
+	case cc.DivEq:
+		return cc.Div, true
+	case cc.ModEq:
+		return cc.Mod, true
+	case cc.AndEq:
+		return cc.And, true
+	case cc.OrEq:
+		return cc.Or, true
+	case cc.XorEq:
+		return cc.Xor, true
+	case cc.LshEq:
+		return cc.Lsh, true
+	case cc.RshEq:
+		return cc.Rsh, true
+	}
+	return 0, false
+}
+
+// bitFieldGet returns a call of the getter for the bit-field x.
+func bitFieldGet(x *cc.Expr) *cc.Expr {
+	return &cc.Expr{
+		SyntaxInfo: x.SyntaxInfo,
+		Op:         cc.Call,
+		Left: &cc.Expr{
+			SyntaxInfo: x.SyntaxInfo,
+			Op:         x.Op,
+			Left:       x.Left,
+			Text:       x.Text,
+			XDecl:      x.XDecl,
+		},
+		XType: x.XDecl.Type,
+	}
+}
+
+func bitFieldSetter(name string) string {
+	r, _ := utf8.DecodeRuneInString(name)
+	if unicode.IsUpper(r) {
+		return "Set" + name
+	}
+	return "set" + exportName(name)
+}
+
+func isBitField(x *cc.Expr) bool {
+	return (x.Op == cc.Dot || x.Op == cc.Arrow) && x.XDecl != nil && x.XDecl.Bits != nil
+}
+
+func hasBitFields(t *cc.Type) bool {
+	for _, d := range t.Decls {
+		if d.Bits != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// bitGroups returns the bit-field groups of struct t,
+// evaluating the widths under the data model m.
+// A group ends at a zero-width bit-field or when it would exceed 64 bits.
+func bitGroups(t *cc.Type, m *cc.Model) []*bitGroup {
+	var groups []*bitGroup
+	var g *bitGroup
+	for _, d := range t.Decls {
+		if d.Bits == nil {
+			g = nil
+			continue
+		}
+		n, _ := cc.ConstInt(d.Bits, m)
+		if n == 0 {
+			g = nil
+			continue
+		}
+		if g != nil && g.bits+n > 64 {
+			g = nil
+		}
+		if g == nil {
+			g = &bitGroup{offset: make(map[*cc.Decl]int64)}
+			groups = append(groups, g)
+		}
+		g.offset[d] = g.bits
+		g.bits += n
+		g.decls = append(g.decls, d)
+	}
+
+	for i, g := range groups {
+		g.field = fmt.Sprint("bits", i)
+		for unionMember(t, g.field) != nil {
+			g.field += "_"
+		}
+		switch {
+		case g.bits <= 8:
+			g.bits = 8
+		case g.bits <= 16:
+			g.bits = 16
+		case g.bits <= 32:
+			g.bits = 32
+		default:
+			g.bits = 64
+		}
+	}
+	return groups
+}
+
+func (p *Printer) printBitFieldMethods(t *cc.Type) {
+	for _, g := range bitGroups(t, p.model) {
+		for _, d := range g.decls {
+			if d.Name == "" {
+				continue
+			}
+			n, _ := cc.ConstInt(d.Bits, p.model)
+			off := g.offset[d]
+			mask := uint64(1)<<uint(n) - 1
+			word := fmt.Sprint("uint", g.bits)
+
+			p.Print(Newline, Newline, "func (x *", t.Tag, ") ", d.Name, "() ", d.Type, " {", Indent, Newline)
+			if isSignedInt(d.Type) {
+				p.Print("return ", d.Type, fmt.Sprintf("(int%d(x.%s<<%d)>>%d)", g.bits, g.field, g.bits-off-n, g.bits-n))
+			} else if off == 0 {
+				p.Print("return ", d.Type, fmt.Sprintf("(x.%s&%#x)", g.field, mask))
+			} else {
+				p.Print("return ", d.Type, fmt.Sprintf("(x.%s>>%d&%#x)", g.field, off, mask))
+			}
+			p.Print(Unindent, Newline, "}")
+
+			p.Print(Newline, Newline, "func (x *", t.Tag, ") ", bitFieldSetter(d.Name), "(v ", d.Type, ") {", Indent, Newline)
+			if off == 0 {
+				p.Print(fmt.Sprintf("x.%s = x.%s&^%#x | %s(v)&%#x", g.field, g.field, mask, word, mask))
+			} else {
+				p.Print(fmt.Sprintf("x.%s = x.%s&^%#x | %s(v)<<%d&%#x", g.field, g.field, mask<<uint(off), word, off, mask<<uint(off)))
+			}
+			p.Print(Unindent, Newline, "}")
+		}
+	}
+}
+
+func isSignedInt(t *cc.Type) bool {
+	if t == nil {
+		return false
+	}
+	switch t.Kind {
+	case Int8, Int16, Int, Int32, Rune, Int64:
+		return true
+	case cc.TypedefType:
+		return isSignedInt(t.Base)
+	}
+	return false
+}
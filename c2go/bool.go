@@ -0,0 +1,487 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go
+
+import (
+	"sort"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// inferBools decides which C integer declarations become Go bools.
+//
+// An integer variable, field, parameter or function result becomes a bool
+// if it is only ever assigned 0, 1 or the result of a comparison or
+// logical operator, and its value is only ever used as a condition.
+// As with slices, the decision spreads across assignments, initializers,
+// call arguments and return values, and a use outside a condition,
+// such as arithmetic, taking the address or passing it to a function
+// that is not translated, keeps the whole class an integer.
+// A class assigned only 0 and 1 must also be tested somewhere,
+// so that counters that happen to stay small are left alone.
+// The "bool" config directive forces a declaration to be a bool.
+//
+// Each inferred declaration is reported at the info level.
+// Like inferSlices, it must run before the C types are translated.
+func inferBools(cfg *Config, prog *cc.Prog) {
+	b := &boolInference{
+		cfg:     cfg,
+		parent:  make(map[*cc.Decl]*cc.Decl),
+		class:   make(map[*cc.Decl]*boolClass),
+		params:  make(map[string][]*cc.Decl),
+		results: make(map[string]*cc.Decl),
+		defined: make(map[string]bool),
+	}
+
+	// Prototypes and definitions of a function share parameters.
+	var funcs []string
+	for _, d := range prog.Decls {
+		if d.Type == nil || d.Type.Kind != cc.Func {
+			continue
+		}
+		if d.Body != nil {
+			b.defined[d.Name] = true
+		}
+		if old, ok := b.params[d.Name]; ok {
+			for i, p := range d.Type.Decls {
+				if i < len(old) {
+					b.union(old[i], p)
+				}
+			}
+			continue
+		}
+		b.params[d.Name] = d.Type.Decls
+		b.result(d.Name, d.Type).Span = d.Span
+		funcs = append(funcs, d.Name)
+	}
+
+	for _, d := range prog.Decls {
+		if d.Type != nil && d.Type.Kind == cc.Func {
+			if d.Body != nil {
+				b.stmt(d, d.Body)
+			}
+			continue
+		}
+		b.decl(d)
+	}
+
+	// Functions defined elsewhere or called through pointers
+	// keep their C signatures.
+	for _, name := range funcs {
+		if !b.defined[name] || name == "main" {
+			b.escape(name)
+		}
+	}
+
+	cfg.bools = make(map[*cc.Decl]bool)
+	cfg.boolResults = make(map[string]bool)
+	var inferred []*cc.Decl
+	for d := range b.parent {
+		c := b.class[b.find(d)]
+		if c.disq || !(c.compare || c.literal && c.cond) {
+			continue
+		}
+		if d.Name == "return" {
+			cfg.boolResults[d.CurFn.Name] = true
+		} else {
+			cfg.bools[d] = true
+		}
+		if !cfg.bool[declKey(d)] {
+			inferred = append(inferred, d)
+			cfg.suggest(c.why.span, "bool "+declKey(d), "%s", c.why.why)
+		}
+	}
+	sort.Slice(inferred, func(i, j int) bool {
+		return declKey(inferred[i]) < declKey(inferred[j])
+	})
+	for _, d := range inferred {
+		cfg.infof(d.Span, "bool", "inferred bool: %s", declKey(d))
+	}
+}
+
+type boolInference struct {
+	cfg     *Config
+	parent  map[*cc.Decl]*cc.Decl // union-find forest of decls exchanging values
+	class   map[*cc.Decl]*boolClass
+	params  map[string][]*cc.Decl // parameters of each function
+	results map[string]*cc.Decl   // pseudo-decls of function results
+	defined map[string]bool       // functions with bodies
+}
+
+// A boolClass is what is known about a class of decls exchanging values.
+type boolClass struct {
+	disq    bool // used or assigned as an integer
+	compare bool // assigned the result of a comparison or logical operator
+	literal bool // assigned 0 or 1
+	cond    bool // used as a condition
+	why     evidence
+}
+
+// A boolUse is the context in which an expression is evaluated.
+type boolUse int
+
+const (
+	useValue boolUse = iota // as an integer
+	useCond                 // as a condition
+	useNone                 // for its side effects, or stored into a decl
+)
+
+// isBoolCandidate reports whether d is an integer declaration
+// that could be translated as a Go bool.
+func (b *boolInference) isBoolCandidate(d *cc.Decl) bool {
+	if d == nil || d.Type == nil || d.Bits != nil {
+		return false
+	}
+	if t := d.OuterType; t != nil && t.Kind == cc.Union {
+		return false
+	}
+	if d.Type.Kind == cc.TypedefType && b.cfg.typeMap[d.Type.Name] != "" {
+		return false
+	}
+	t := d.Type.Def()
+	return t != nil && cc.Char <= t.Kind && t.Kind <= cc.Ulonglong
+}
+
+func (b *boolInference) find(d *cc.Decl) *cc.Decl {
+	p, ok := b.parent[d]
+	if !ok {
+		b.parent[d] = d
+		c := new(boolClass)
+		if b.cfg.bool[declKey(d)] {
+			c.compare = true
+			c.why = evidence{d.Span, declKey(d) + " configured as bool"}
+		}
+		b.class[d] = c
+		return d
+	}
+	if p == d {
+		return d
+	}
+	r := b.find(p)
+	b.parent[d] = r
+	return r
+}
+
+func (b *boolInference) union(d1, d2 *cc.Decl) {
+	if !b.isBoolCandidate(d1) || !b.isBoolCandidate(d2) {
+		b.disqualify(d1)
+		b.disqualify(d2)
+		return
+	}
+	r1, r2 := b.find(d1), b.find(d2)
+	if r1 == r2 {
+		return
+	}
+	b.parent[r2] = r1
+	c1, c2 := b.class[r1], b.class[r2]
+	c1.disq = c1.disq || c2.disq
+	c1.compare = c1.compare || c2.compare
+	c1.literal = c1.literal || c2.literal
+	c1.cond = c1.cond || c2.cond
+	if c1.why.why == "" {
+		c1.why = c2.why
+	}
+	delete(b.class, r2)
+}
+
+// get returns the class of d, or nil if d cannot be a bool.
+func (b *boolInference) get(d *cc.Decl) *boolClass {
+	if !b.isBoolCandidate(d) {
+		return nil
+	}
+	return b.class[b.find(d)]
+}
+
+func (b *boolInference) disqualify(d *cc.Decl) {
+	if c := b.get(d); c != nil {
+		c.disq = true
+	}
+}
+
+// escape records that the function name is used other than by calling it.
+func (b *boolInference) escape(name string) {
+	if d := b.results[name]; d != nil {
+		b.disqualify(d)
+	}
+	for _, p := range b.params[name] {
+		b.disqualify(p)
+	}
+}
+
+// result returns the pseudo-decl standing for the result
+// of the function name of type t, as toGoType sees it.
+func (b *boolInference) result(name string, t *cc.Type) *cc.Decl {
+	d := b.results[name]
+	if d == nil {
+		d = &cc.Decl{Name: "return", Type: t.Base, CurFn: &cc.Decl{Name: name}}
+		b.results[name] = d
+	}
+	return d
+}
+
+// decl records the initialization of d.
+func (b *boolInference) decl(d *cc.Decl) {
+	if d.Init == nil {
+		return
+	}
+	if d.Init.Expr != nil {
+		b.flow(d, d.Init.Expr)
+		return
+	}
+	b.init(d.Type, d.Init)
+}
+
+// init records the braced initializer x of type t.
+// Fields initialized by position or name are not tracked:
+// they keep their integer types.
+func (b *boolInference) init(t *cc.Type, x *cc.Init) {
+	if x.Expr != nil {
+		b.expr(x.Expr, useValue)
+		return
+	}
+	t = t.Def()
+	for i, y := range x.Braced {
+		var elem *cc.Type
+		if t != nil {
+			switch t.Kind {
+			case cc.Array:
+				elem = t.Base
+			case cc.Struct, cc.Union:
+				for _, f := range t.Decls {
+					b.disqualify(f)
+				}
+				if i < len(t.Decls) {
+					elem = t.Decls[i].Type
+				}
+				for _, p := range y.Prefix {
+					if p.XDecl != nil {
+						elem = p.XDecl.Type
+					}
+				}
+			}
+		}
+		b.init(elem, y)
+	}
+}
+
+func (b *boolInference) stmt(fn *cc.Decl, x *cc.Stmt) {
+	if x == nil {
+		return
+	}
+	switch x.Op {
+	case cc.StmtDecl:
+		b.decl(x.Decl)
+	case cc.StmtExpr:
+		b.expr(x.Expr, useNone)
+	case cc.If, cc.For, cc.While, cc.Do:
+		b.expr(x.Pre, useNone)
+		b.expr(x.Expr, useCond)
+		b.expr(x.Post, useNone)
+	case cc.Return:
+		if x.Expr != nil {
+			b.flow(b.result(fn.Name, fn.Type), x.Expr)
+		}
+	default:
+		b.expr(x.Pre, useValue)
+		b.expr(x.Expr, useValue)
+		b.expr(x.Post, useValue)
+	}
+	b.stmt(fn, x.Body)
+	b.stmt(fn, x.Else)
+	for _, s := range x.Block {
+		b.stmt(fn, s)
+	}
+}
+
+// expr records the evaluation of x in the context use.
+func (b *boolInference) expr(x *cc.Expr, use boolUse) {
+	if x == nil {
+		return
+	}
+	switch x.Op {
+	case cc.Paren:
+		b.expr(x.Left, use)
+
+	case cc.Name, cc.Dot, cc.Arrow:
+		if x.Op != cc.Name {
+			b.expr(x.Left, useValue)
+		}
+		d := x.XDecl
+		if d == nil {
+			return
+		}
+		if f := d.Type.Def(); x.Op == cc.Name && f != nil && f.Kind == cc.Func {
+			b.escape(d.Name)
+			return
+		}
+		b.used(d, use)
+
+	case cc.Call:
+		name := ""
+		if x.Left.Op == cc.Name && x.Left.XDecl != nil {
+			if f := x.Left.XDecl.Type.Def(); f != nil && f.Kind == cc.Func {
+				name = x.Left.Text
+			}
+		}
+		if name == "" {
+			b.expr(x.Left, useValue)
+			for _, arg := range x.List {
+				b.expr(arg, useValue)
+			}
+			return
+		}
+		b.used(b.result(name, x.Left.XDecl.Type.Def()), use)
+		params := b.params[name]
+		for i, arg := range x.List {
+			if i < len(params) && params[i].Name != "..." {
+				b.flow(params[i], arg)
+			} else {
+				b.expr(arg, useValue)
+			}
+		}
+
+	case cc.Not, cc.AndAnd, cc.OrOr:
+		b.expr(x.Left, useCond)
+		b.expr(x.Right, useCond)
+
+	case cc.Cond:
+		b.expr(x.List[0], useCond)
+		b.expr(x.List[1], use)
+		b.expr(x.List[2], use)
+
+	case cc.Comma:
+		for i, y := range x.List {
+			if i == len(x.List)-1 {
+				b.expr(y, use)
+			} else {
+				b.expr(y, useNone)
+			}
+		}
+
+	case cc.Eq:
+		if d := b.root(x.Left); d != nil {
+			if x.Left.Op != cc.Name {
+				b.expr(x.Left.Left, useValue)
+			}
+			b.flow(d, x.Right)
+			if use == useValue {
+				b.disqualify(d)
+			}
+			return
+		}
+		b.expr(x.Left, useNone)
+		b.expr(x.Right, useValue)
+
+	case cc.EqEq, cc.NotEq:
+		// Comparing two bools is fine, as long as both are.
+		if l, r := b.root(x.Left), b.root(x.Right); l != nil && r != nil {
+			b.union(l, r)
+			b.expr(x.Left, useNone)
+			b.expr(x.Right, useNone)
+			return
+		}
+		b.expr(x.Left, useValue)
+		b.expr(x.Right, useValue)
+
+	case cc.CastInit:
+		b.init(x.Type, x.Init)
+
+	case cc.SizeofExpr:
+		// Not evaluated.
+
+	default:
+		b.expr(x.Left, useValue)
+		b.expr(x.Right, useValue)
+		for _, y := range x.List {
+			b.expr(y, useValue)
+		}
+	}
+}
+
+// used records a use of the value of d in the context use.
+func (b *boolInference) used(d *cc.Decl, use boolUse) {
+	switch use {
+	case useValue:
+		b.disqualify(d)
+	case useCond:
+		if c := b.get(d); c != nil {
+			c.cond = true
+		}
+	}
+}
+
+// flow records that the value of x is stored in d.
+func (b *boolInference) flow(d *cc.Decl, x *cc.Expr) {
+	x = unparen(x)
+	c := b.get(d)
+	if c == nil {
+		b.expr(x, useValue)
+		return
+	}
+	switch x.Op {
+	case cc.Number:
+		if x.Text == "0" || x.Text == "1" {
+			c.literal = true
+			if c.why.why == "" {
+				c.why = evidence{x.Span, declKey(d) + " assigned " + x.Text}
+			}
+			return
+		}
+	case cc.EqEq, cc.NotEq, cc.Lt, cc.LtEq, cc.Gt, cc.GtEq, cc.AndAnd, cc.OrOr, cc.Not:
+		if !c.compare {
+			c.why = evidence{x.Span, declKey(d) + " assigned a condition"}
+		}
+		c.compare = true
+		b.expr(x, useCond)
+		return
+	case cc.Cond:
+		b.expr(x.List[0], useCond)
+		b.flow(d, x.List[1])
+		b.flow(d, x.List[2])
+		return
+	case cc.Cast:
+		b.flow(d, x.Left)
+		return
+	case cc.Name, cc.Dot, cc.Arrow, cc.Call:
+		if r := b.root(x); r != nil {
+			b.union(d, r)
+			b.expr(x, useNone)
+			return
+		}
+	}
+	b.disqualify(d)
+	b.expr(x, useValue)
+}
+
+// root returns the decl holding the value of x, if it could be a bool.
+func (b *boolInference) root(x *cc.Expr) *cc.Decl {
+	x = unparen(x)
+	switch x.Op {
+	case cc.Name, cc.Dot, cc.Arrow:
+		if b.isBoolCandidate(x.XDecl) {
+			return x.XDecl
+		}
+	case cc.Call:
+		if x.Left.Op == cc.Name && x.Left.XDecl != nil {
+			if f := x.Left.XDecl.Type.Def(); f != nil && f.Kind == cc.Func {
+				if d := b.result(x.Left.Text, f); b.isBoolCandidate(d) {
+					return d
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isBool reports whether the integer decl d becomes a bool.
+func (cfg *Config) isBool(d *cc.Decl) bool {
+	if cfg.bool[declKey(d)] {
+		return true
+	}
+	if d.Name == "return" && d.CurFn != nil {
+		return cfg.boolResults[d.CurFn.Name]
+	}
+	return cfg.bools[d]
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go_test
+
+import (
+	"strings"
+	"testing"
+)
+
+var boolTests = []struct {
+	name     string
+	cfg      string
+	src      string
+	want     []string // lines of the output
+	inferred []string // inferred bool reports, as printed
+}{
+	{
+		name: "param",
+		src: `
+int n;
+void set(int on) { if(on) n++; }
+void run(int x) { set(1); set(x < 0); }
+`,
+		want:     []string{"func set(on bool) {", "\tset(true)", "\tset(x < 0)"},
+		inferred: []string{"x.c:3: info: inferred bool: set.on"},
+	},
+	{
+		name: "arithmetic",
+		src: `
+int f(int a) {
+	int t;
+	t = 0;
+	if(a > 0)
+		t = 1;
+	if(t)
+		return t + 1;
+	return 0;
+}
+`,
+		want: []string{"\tvar t int"},
+	},
+	{
+		name: "address",
+		src: `
+void get(int *p) { *p = 1; }
+int f(void) {
+	int t;
+	t = 0;
+	get(&t);
+	if(t)
+		return 1;
+	return 0;
+}
+`,
+		want: []string{"\tvar t int"},
+	},
+	{
+		// A flag set to 0 and 1 but never tested is a counter.
+		name: "untested",
+		src: `
+int seen;
+void mark(void) { seen = 1; }
+void clear(void) { seen = 0; }
+`,
+		want: []string{"var seen int"},
+	},
+	{
+		name: "configured",
+		cfg:  "bool seen\n",
+		src:  "int seen;\nvoid mark(void) { seen = 1; }\n",
+		want: []string{"var seen bool", "\tseen = true"},
+	},
+}
+
+func TestBools(t *testing.T) {
+	for _, tt := range boolTests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, diags := translate(t, tt.cfg, map[string]string{"x.c": tt.src})
+			lines := strings.Split(string(out["x.go"]), "\n")
+			for _, want := range tt.want {
+				if !contains(lines, want) {
+					t.Errorf("missing line %q in:\n%s", want, out["x.go"])
+				}
+			}
+			var got []string
+			for _, d := range diags {
+				if strings.Contains(d.Message, "inferred bool") {
+					got = append(got, d.String())
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.inferred, "\n") {
+				t.Errorf("inferred:\n%q\nwant:\n%q", got, tt.inferred)
+			}
+		})
+	}
+}
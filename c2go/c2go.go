@@ -0,0 +1,159 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package c2go translates C programs to Go.
+//
+// A translation is configured by a Config, usually parsed from a
+// config file by ParseConfig, and keeps all its state to itself:
+// Translate may be called concurrently, even with the same Config,
+// and returns the Go files instead of writing them.
+package c2go
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// An Input is a C file to translate.
+// Inputs named *.h are not translated themselves
+// but only made available to #include.
+type Input struct {
+	Name string // file name, used for diagnostics and to place the output
+	Data []byte
+}
+
+// passes lists the passes translating a C program into Go, in order.
+var passes = []struct {
+	name string
+	run  func(*Config, *cc.Prog)
+}{
+	{"sizes", computeSizes},
+	{"types", func(cfg *Config, prog *cc.Prog) { rewriteTypes(cfg, prog) }},
+	{"syntax", rewriteSyntax},
+	{"len", rewriteLen},
+	{"gotypes", fixGoTypes},
+	{"fold", foldSizes},
+	{"bool", simplifyBool},
+	{"unions", rewriteUnions},
+	{"vtables", rewriteVtables},
+	{"methods", rewriteMethods},
+	{"rename", renameDecls},
+	{"export", exportDecls},
+	{"bitfields", rewriteBitFields},
+	{"output", writeGoFiles},
+	{"verify", verifyGoFiles},
+}
+
+// Translate translates the C files in inputs to Go as configured by cfg.
+// It returns the Go files, keyed by slash-separated paths relative to
+// the root of the module, including the module's go.mod file
+// and any source maps requested by SetLines, along with the
+// diagnostics reported by the config and every pass.
+// If the C files cannot be parsed, the returned map is nil.
+//
+// Files included by the inputs are looked up among the inputs first
+// and then on disk.
+func Translate(cfg *Config, inputs []Input) (map[string][]byte, []*Diagnostic) {
+	cfg = cfg.clone()
+	prog := cfg.read(inputs)
+	if prog == nil {
+		return nil, cfg.diags
+	}
+	for _, pass := range passes {
+		cfg.startPass(pass.name)
+		pass.run(cfg, prog)
+	}
+
+	cfg.startPass("diffs")
+	for _, d := range cfg.diffs {
+		if d.used == 0 {
+			cfg.warnf(d.span, "unused-diff", "unused diff")
+		}
+	}
+	return cfg.output, cfg.diags
+}
+
+// Suggest analyzes the C files in inputs as Translate would
+// and returns a draft config file for them, listing the evidence
+// for each line, along with the diagnostics reported by the analyses.
+func Suggest(cfg *Config, inputs []Input) ([]byte, []*Diagnostic) {
+	cfg = cfg.clone()
+	prog := cfg.read(inputs)
+	if prog == nil {
+		return nil, cfg.diags
+	}
+	for _, pass := range passes {
+		cfg.startPass(pass.name)
+		pass.run(cfg, prog)
+		if pass.name == "types" {
+			// The analyses are done.
+			break
+		}
+	}
+
+	cfg.startPass("suggest")
+	var files []string
+	for _, in := range inputs {
+		if !strings.HasSuffix(in.Name, ".h") {
+			files = append(files, in.Name)
+		}
+	}
+	var buf bytes.Buffer
+	writeSuggestions(&buf, cfg, prog, files)
+	return buf.Bytes(), cfg.diags
+}
+
+// read parses and type-checks inputs, reporting any errors.
+func (cfg *Config) read(inputs []Input) *cc.Prog {
+	files := make(map[string][]byte)
+	var names []string
+	var r []io.Reader
+	for _, in := range inputs {
+		files[filepath.Clean(in.Name)] = in.Data
+		if strings.HasSuffix(in.Name, ".h") {
+			continue
+		}
+		names = append(names, in.Name)
+		r = append(r, bytes.NewReader(in.Data))
+	}
+	opts := cfg.opts
+	readFile := opts.ReadFile
+	opts.ReadFile = func(name string) ([]byte, error) {
+		if data, ok := files[filepath.Clean(name)]; ok {
+			// cc appends to the data it reads, which must not
+			// write into inputs that other translations share.
+			return data[:len(data):len(data)], nil
+		}
+		if readFile != nil {
+			return readFile(name)
+		}
+		return ioutil.ReadFile(name)
+	}
+
+	cfg.startPass("parse")
+	prog, err := opts.ReadMany(names, r)
+	if err != nil {
+		list, ok := err.(cc.ErrorList)
+		if !ok {
+			cfg.errorf(cc.Span{}, "c", "%v", err)
+			return nil
+		}
+		for _, e := range list {
+			cfg.errorf(e.Span, "c", "%s", e.Msg)
+		}
+		return nil
+	}
+	cfg.startPass("macros")
+	for _, m := range prog.Macros {
+		if m.Expanded != "" {
+			cfg.warnf(m.Span, "macro", "cannot translate macro %s (%s); expanding it", m.Name, m.Expanded)
+		}
+	}
+	return prog
+}
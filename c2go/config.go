@@ -0,0 +1,430 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go
+
+import (
+	"bytes"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+type Config struct {
+	pkgRules []pkgRule
+	exports  []string
+	replace  map[string]string
+	delete   map[string]bool
+	diffs    []diff
+	slice    map[string]bool
+	len      map[string]string
+	cap      map[string]string
+	typeMap  map[string]string
+	bool     map[string]bool
+	ptr      map[string]bool
+	rename   map[string]string
+	union    map[string]string
+	model    *cc.Model
+	module   string
+	imports  map[string]string
+	iface    map[string]cc.Span // interface directive by struct name
+	methods  []methodRule
+	stringer map[string]bool
+	autoLen  bool // apply inferred slice groups
+	lines    bool // write //line directives and source maps
+	opts     cc.Options
+
+	// derived during analysis
+	topDecls     []*cc.Decl
+	sizes        map[*cc.Expr]int64 // foldable sizeof and offsetof values
+	allocSets    map[*cc.Stmt]bool  // assignments of the capacity an allocation gives a slice
+	stringers    map[*cc.Type]bool  // enums to give String methods
+	slices       map[*cc.Decl]bool  // pointer decls inferred to be slices
+	sliceResults map[string]bool    // functions inferred to return slices
+	bools        map[*cc.Decl]bool  // integer decls inferred to be bools
+	boolResults  map[string]bool    // functions inferred to return bools
+	suggestions  []suggestion       // config lines proposed by the analyses
+
+	// translation state
+	pass       string        // pass currently running
+	diags      []*Diagnostic // diagnostics reported by all passes
+	tmpGen     int           // number of temporaries generated
+	numRewrite int           // number of runs of rewriteSyntax
+	output     map[string][]byte
+	srcMaps    map[string]srcMap // C syntax translated by each Go file in output
+}
+
+// SetModel sets the C data model assumed by the translation,
+// overriding any model directive in the config file.
+func (cfg *Config) SetModel(m *cc.Model) {
+	cfg.model = m
+}
+
+// AddInclude adds dir to the directories searched for included files.
+func (cfg *Config) AddInclude(dir string) {
+	cfg.opts.AddInclude(dir)
+}
+
+// Define defines the macro name as value, as with -D.
+func (cfg *Config) Define(name, value string) {
+	cfg.opts.Define(name, value)
+}
+
+// Undefine undefines the macro name, as with -U.
+func (cfg *Config) Undefine(name string) {
+	cfg.opts.Undefine(name)
+}
+
+// Module returns the module path set by the module directive.
+func (cfg *Config) Module() string {
+	return cfg.module
+}
+
+// SetModule sets the path of the module the Go files belong to.
+func (cfg *Config) SetModule(module string) {
+	cfg.module = module
+}
+
+// SetLines sets whether the Go files give the C position of their
+// statements and declarations in //line directives, and are each
+// accompanied by a JSON source map, named after the file plus .map,
+// listing the Go lines translating each C statement and declaration.
+func (cfg *Config) SetLines(on bool) {
+	cfg.lines = on
+}
+
+// clone returns a copy of cfg for a single translation.
+// The translation records its analyses, diagnostics and output
+// in the copy, leaving cfg free for use by other translations.
+func (cfg *Config) clone() *Config {
+	c := &Config{
+		pkgRules: cfg.pkgRules,
+		exports:  cfg.exports,
+		replace:  cfg.replace,
+		delete:   cfg.delete,
+		diffs:    append([]diff(nil), cfg.diffs...),
+		slice:    make(map[string]bool),
+		len:      make(map[string]string),
+		cap:      make(map[string]string),
+		typeMap:  cfg.typeMap,
+		bool:     cfg.bool,
+		ptr:      cfg.ptr,
+		rename:   cfg.rename,
+		union:    cfg.union,
+		model:    cfg.model,
+		module:   cfg.module,
+		imports:  cfg.imports,
+		iface:    cfg.iface,
+		methods:  cfg.methods,
+		stringer: cfg.stringer,
+		autoLen:  cfg.autoLen,
+		lines:    cfg.lines,
+		opts:     cfg.opts,
+		output:   make(map[string][]byte),
+		srcMaps:  make(map[string]srcMap),
+	}
+	// Inferred slice groups are added to these.
+	for k, v := range cfg.slice {
+		c.slice[k] = v
+	}
+	for k, v := range cfg.len {
+		c.len[k] = v
+	}
+	for k, v := range cfg.cap {
+		c.cap[k] = v
+	}
+	c.opts.Model = c.dataModel()
+	return c
+}
+
+type pkgRule struct {
+	pattern string
+	pkg     string
+}
+
+type diff struct {
+	span   cc.Span
+	before []byte
+	after  []byte
+	used   int
+}
+
+func (cfg *Config) filePackage(file string) (pkg string) {
+	file = filepath.ToSlash(strings.TrimPrefix(file, runtime.GOROOT()+string(filepath.Separator)))
+	pkg = "main"
+	for _, rule := range cfg.pkgRules {
+		if matched, _ := path.Match(rule.pattern, file); matched {
+			pkg = rule.pkg
+		}
+	}
+	return pkg
+}
+
+// ParseConfig parses the config file named file with contents data.
+// It returns the config along with the problems found in the file.
+func ParseConfig(file string, data []byte) (*Config, []*Diagnostic) {
+	cfg := new(Config)
+	cfg.startPass("config")
+	cfg.parse(file, data)
+	return cfg, cfg.diags
+}
+
+func (cfg *Config) parse(file string, data []byte) {
+	lineno := 0
+	warn := func(format string, args ...interface{}) {
+		cfg.warnf(cc.Span{Start: cc.Pos{File: file, Line: lineno}}, "config", format, args...)
+	}
+	lines := strings.Split(string(data), "\n")
+	cfg.replace = make(map[string]string)
+	cfg.delete = make(map[string]bool)
+	cfg.slice = make(map[string]bool)
+	cfg.len = make(map[string]string)
+	cfg.cap = make(map[string]string)
+	cfg.typeMap = make(map[string]string)
+	cfg.bool = make(map[string]bool)
+	cfg.ptr = make(map[string]bool)
+	cfg.rename = make(map[string]string)
+	cfg.union = make(map[string]string)
+	cfg.imports = make(map[string]string)
+	cfg.iface = make(map[string]cc.Span)
+	cfg.stringer = make(map[string]bool)
+
+	for len(lines) > 0 {
+		line := lines[0]
+		lines = lines[1:]
+		lineno++
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+		switch f[0] {
+		case "package":
+			pkg := f[len(f)-1]
+			for i := 1; i < len(f)-1; i++ {
+				if _, err := path.Match(f[i], ""); err != nil {
+					warn("invalid pattern %s: %v", f[i], err)
+					continue
+				}
+				cfg.pkgRules = append(cfg.pkgRules, pkgRule{f[i], pkg})
+			}
+
+		case "export":
+			cfg.exports = append(cfg.exports, f[1:]...)
+
+		case "delete":
+			for _, name := range f[1:] {
+				cfg.delete[name] = true
+			}
+
+		case "bool":
+			for _, name := range f[1:] {
+				cfg.bool[name] = true
+			}
+
+		case "ptr":
+			for _, name := range f[1:] {
+				cfg.ptr[name] = true
+			}
+
+		case "string":
+			if len(f) >= 3 {
+				cfg.len[f[2]] = f[1]
+			}
+
+		case "slice":
+			if len(f) >= 2 {
+				cfg.slice[f[1]] = true
+			}
+			if len(f) >= 3 {
+				cfg.len[f[2]] = f[1]
+			}
+			if len(f) >= 4 {
+				cfg.cap[f[3]] = f[1]
+			}
+			if len(f) >= 5 {
+				warn("extra arguments for slice")
+			}
+
+		case "autoslice":
+			cfg.autoLen = true
+
+		case "lines":
+			cfg.lines = true
+
+		case "func", "type":
+			if len(f) < 2 {
+				warn("short func/type declaration")
+			}
+			var buf bytes.Buffer
+			buf.WriteString(line + "\n")
+			if strings.HasSuffix(line, "{") {
+				for {
+					lineno++
+					if len(lines) == 0 {
+						cfg.errorf(cc.Span{Start: cc.Pos{File: file, Line: lineno}}, "config", "unexpected EOF reading func/type body")
+						return
+					}
+					line = lines[0]
+					lines = lines[1:]
+					buf.WriteString(line + "\n")
+					if line == "}" {
+						break
+					}
+				}
+			}
+			name := f[1]
+			if i := strings.Index(name, "("); i >= 0 {
+				name = name[:i]
+			}
+			cfg.replace[name] = buf.String()
+
+		case "diff":
+			if line != "diff {" {
+				warn("invalid diff opening")
+				break
+			}
+
+			var old, new bytes.Buffer
+			span := cc.Span{Start: cc.Pos{File: file, Line: lineno}}
+			for {
+				lineno++
+				if len(lines) == 0 {
+					cfg.errorf(cc.Span{Start: cc.Pos{File: file, Line: lineno}}, "config", "unexpected EOF reading diff")
+					return
+				}
+				line = lines[0]
+				lines = lines[1:]
+				if line == "}" {
+					break
+				}
+				switch {
+				case strings.HasPrefix(line, "+"):
+					line = strings.TrimPrefix(line[1:], " ")
+					new.WriteString(line + "\n")
+
+				case strings.HasPrefix(line, "-"):
+					line = strings.TrimPrefix(line[1:], " ")
+					old.WriteString(line + "\n")
+
+				default:
+					line = strings.TrimPrefix(strings.TrimPrefix(line, " "), " ")
+					old.WriteString(line + "\n")
+					new.WriteString(line + "\n")
+				}
+			}
+			cfg.diffs = append(cfg.diffs, diff{
+				span:   span,
+				before: old.Bytes(),
+				after:  new.Bytes(),
+			})
+
+		case "typemap":
+			if len(f) != 3 {
+				warn("invalid typemap directive")
+				continue
+			}
+			cfg.typeMap[f[1]] = f[2]
+
+		case "rename":
+			if len(f) != 3 {
+				warn("invalid rename directive")
+				continue
+			}
+			cfg.rename[f[1]] = f[2]
+
+		case "union":
+			if len(f) != 3 {
+				warn("invalid union directive")
+				continue
+			}
+			cfg.union[f[1]] = f[2]
+
+		case "model":
+			if len(f) < 2 {
+				warn("invalid model directive")
+				continue
+			}
+			m, err := cc.ParseModel(strings.Join(f[1:], ","))
+			if err != nil {
+				warn("%v", err)
+				continue
+			}
+			cfg.model = m
+
+		case "import":
+			// import [name] path imports path as name,
+			// for rewrites referring to either of them.
+			switch len(f) {
+			case 2:
+				cfg.imports[path.Base(f[1])] = f[1]
+			case 3:
+				cfg.imports[f[1]] = f[2]
+			default:
+				warn("invalid import directive")
+			}
+
+		case "interface":
+			for _, name := range f[1:] {
+				cfg.iface[name] = cc.Span{Start: cc.Pos{File: file, Line: lineno}}
+			}
+
+		case "stringer":
+			// stringer Enum... gives the named enums String methods.
+			for _, name := range f[1:] {
+				cfg.stringer[name] = true
+			}
+
+		case "method":
+			// method Type [pattern]
+			if len(f) < 2 || len(f) > 3 {
+				warn("invalid method directive")
+				continue
+			}
+			r := methodRule{typ: f[1], pattern: strings.ToLower(f[1]) + "_*"}
+			if len(f) == 3 {
+				r.pattern = f[2]
+			}
+			if strings.Count(r.pattern, "*") != 1 {
+				warn("method pattern %s must contain one *", r.pattern)
+				continue
+			}
+			cfg.methods = append(cfg.methods, r)
+
+		case "module":
+			if len(f) != 2 {
+				warn("invalid module directive")
+				continue
+			}
+			cfg.module = f[1]
+
+		default:
+			warn("unknown verb %s", f[0])
+		}
+	}
+}
+
+func declKey(d *cc.Decl) string {
+	key := d.Name
+	if t := d.OuterType; t != nil {
+		name := t.Name
+		if name == "" {
+			name = t.Tag
+		}
+		if name == "" {
+			name = t.String()
+		}
+		key = name + "." + key
+	}
+	if d.CurFn != nil {
+		key = declKey(d.CurFn) + "." + key
+	}
+	return key
+}
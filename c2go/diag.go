@@ -0,0 +1,130 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// A Severity is the seriousness of a diagnostic.
+type Severity int
+
+const (
+	// Info diagnostics describe C constructs left untranslated
+	// that are usually harmless, shown only with -v.
+	Info Severity = iota
+	// Warning diagnostics describe translations that may need attention.
+	Warning
+	// Error diagnostics describe output that is known to be wrong.
+	Error
+)
+
+var severityNames = []string{
+	Info:    "info",
+	Warning: "warning",
+	Error:   "error",
+}
+
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// A Diagnostic is a problem found while translating.
+type Diagnostic struct {
+	Span     cc.Span
+	Severity Severity
+	Pass     string // name of the pass reporting the problem
+	Code     string // short identifier of the kind of problem
+	Message  string
+}
+
+func (d *Diagnostic) String() string {
+	if d.Severity == Warning {
+		return fmt.Sprintf("%s:%d: %s", d.Span.Start.File, d.Span.Start.Line, d.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", d.Span.Start.File, d.Span.Start.Line, d.Severity, d.Message)
+}
+
+func (d *Diagnostic) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		File     string `json:"file"`
+		Line     int    `json:"line"`
+		EndFile  string `json:"endFile,omitempty"`
+		EndLine  int    `json:"endLine,omitempty"`
+		Severity string `json:"severity"`
+		Pass     string `json:"pass"`
+		Code     string `json:"code"`
+		Message  string `json:"message"`
+	}{
+		File:     d.Span.Start.File,
+		Line:     d.Span.Start.Line,
+		EndFile:  d.Span.End.File,
+		EndLine:  d.Span.End.Line,
+		Severity: d.Severity.String(),
+		Pass:     d.Pass,
+		Code:     d.Code,
+		Message:  d.Message,
+	})
+}
+
+// startPass records that diagnostics reported from now on
+// come from the named pass.
+func (cfg *Config) startPass(name string) {
+	cfg.pass = name
+}
+
+func (cfg *Config) report(span cc.Span, sev Severity, code, format string, args ...interface{}) {
+	if cfg == nil {
+		// Printing outside a translation, for debugging.
+		return
+	}
+	cfg.diags = append(cfg.diags, &Diagnostic{
+		Span:     span,
+		Severity: sev,
+		Pass:     cfg.pass,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// errorf reports that the output for span is wrong.
+func (cfg *Config) errorf(span cc.Span, code, format string, args ...interface{}) {
+	cfg.report(span, Error, code, format, args...)
+}
+
+// warnf reports a problem at span that may need attention.
+func (cfg *Config) warnf(span cc.Span, code, format string, args ...interface{}) {
+	cfg.report(span, Warning, code, format, args...)
+}
+
+// infof reports a construct at span that was left untranslated.
+func (cfg *Config) infof(span cc.Span, code, format string, args ...interface{}) {
+	cfg.report(span, Info, code, format, args...)
+}
+
+// WriteDiagnostics writes the diagnostics in list at least as severe as min
+// to w, as text or as one JSON object per line.
+// It reports whether any of them is at least as severe as fail.
+func WriteDiagnostics(w io.Writer, list []*Diagnostic, min, fail Severity, asJSON bool) (failed bool) {
+	enc := json.NewEncoder(w)
+	for _, d := range list {
+		if d.Severity >= fail {
+			failed = true
+		}
+		if d.Severity < min {
+			continue
+		}
+		if asJSON {
+			enc.Encode(d)
+			continue
+		}
+		fmt.Fprintln(w, d)
+	}
+	return failed
+}
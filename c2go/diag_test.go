@@ -0,0 +1,126 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/hajimehoshi/cingo/c2go"
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+var testDiags = []*Diagnostic{
+	{
+		Span:     cc.Span{Start: cc.Pos{File: "x.c", Line: 3}},
+		Severity: Info,
+		Pass:     "macros",
+		Code:     "macro",
+		Message:  "left as is",
+	},
+	{
+		Span:     cc.Span{Start: cc.Pos{File: "x.c", Line: 5}, End: cc.Pos{File: "x.c", Line: 7}},
+		Severity: Warning,
+		Pass:     "unions",
+		Code:     "union",
+		Message:  "union U holds pointers",
+	},
+	{
+		Span:     cc.Span{Start: cc.Pos{File: "y.c", Line: 1}},
+		Severity: Error,
+		Pass:     "verify",
+		Code:     "type",
+		Message:  "undefined: f",
+	},
+}
+
+// The thresholds are those the command sets from its flags:
+// min is Info with -v and Warning otherwise,
+// fail is Warning with -Werror and Error otherwise.
+var writeDiagTests = []struct {
+	name      string
+	list      []*Diagnostic
+	min, fail Severity
+	json      bool
+	out       string
+	failed    bool
+}{
+	{
+		name:   "default",
+		list:   testDiags,
+		min:    Warning,
+		fail:   Error,
+		out:    "x.c:5: union U holds pointers\ny.c:1: error: undefined: f\n",
+		failed: true,
+	},
+	{
+		name:   "verbose",
+		list:   testDiags,
+		min:    Info,
+		fail:   Error,
+		out:    "x.c:3: info: left as is\nx.c:5: union U holds pointers\ny.c:1: error: undefined: f\n",
+		failed: true,
+	},
+	{
+		name:   "warnings",
+		list:   testDiags[:2],
+		min:    Warning,
+		fail:   Error,
+		out:    "x.c:5: union U holds pointers\n",
+		failed: false,
+	},
+	{
+		name:   "Werror",
+		list:   testDiags[:2],
+		min:    Warning,
+		fail:   Warning,
+		out:    "x.c:5: union U holds pointers\n",
+		failed: true,
+	},
+	{
+		name:   "Werror info",
+		list:   testDiags[:1],
+		min:    Warning,
+		fail:   Warning,
+		out:    "",
+		failed: false,
+	},
+	{
+		name: "json",
+		list: testDiags,
+		min:  Warning,
+		fail: Error,
+		json: true,
+		out: `{"file":"x.c","line":5,"endFile":"x.c","endLine":7,"severity":"warning","pass":"unions","code":"union","message":"union U holds pointers"}
+{"file":"y.c","line":1,"severity":"error","pass":"verify","code":"type","message":"undefined: f"}
+`,
+		failed: true,
+	},
+	{
+		name: "json verbose",
+		list: testDiags[:1],
+		min:  Info,
+		fail: Warning,
+		json: true,
+		out: `{"file":"x.c","line":3,"severity":"info","pass":"macros","code":"macro","message":"left as is"}
+`,
+		failed: false,
+	},
+}
+
+func TestWriteDiagnostics(t *testing.T) {
+	for _, tt := range writeDiagTests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			failed := WriteDiagnostics(&buf, tt.list, tt.min, tt.fail, tt.json)
+			if got := buf.String(); got != tt.out {
+				t.Errorf("output:\n%s\nwant:\n%s", got, tt.out)
+			}
+			if failed != tt.failed {
+				t.Errorf("failed = %v, want %v", failed, tt.failed)
+			}
+		})
+	}
+}
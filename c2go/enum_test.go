@@ -0,0 +1,64 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go_test
+
+import (
+	"strings"
+	"testing"
+)
+
+var enumTests = []struct {
+	name string
+	cfg  string
+	src  string
+	want string // part of the output
+}{
+	{
+		name: "offset",
+		src:  "enum Level { Low = 1, Mid, High };\nenum Level f(void) { return Mid; }\n",
+		want: "type Level int\n\nconst (\n\tLow Level = iota + 1\n\tMid\n\tHigh\n)\n",
+	},
+	{
+		name: "negative",
+		src:  "enum Sign { Neg = -1, Zero, Pos };\nenum Sign f(void) { return Zero; }\n",
+		want: "type Sign int\n\nconst (\n\tNeg Sign = -1 + iota\n\tZero\n\tPos\n)\n",
+	},
+	{
+		name: "char",
+		src:  "enum Char { A = 'a', B, C = 'z' };\nenum Char f(void) { return B; }\n",
+		want: "const (\n\tA Char = iota + 97\n\tB\n\tC Char = 'z'\n)\n",
+	},
+	{
+		// Values not following on from the previous one
+		// keep their C expressions.
+		name: "explicit",
+		src:  "enum Flag { F1 = 1<<0, F2 = 1<<1, F3 = 1<<2 };\nenum Flag f(void) { return F2; }\n",
+		want: "const (\n\tF1 Flag = 1 << 0\n\tF2 Flag = 1 << 1\n\tF3 Flag = 1 << 2\n)\n",
+	},
+	{
+		// An alias of an earlier value gets no case of its own.
+		name: "alias",
+		cfg:  "stringer Alias\n",
+		src:  "enum Alias { First, Second, Last = Second };\nenum Alias f(void) { return Last; }\n",
+		want: "\tswitch x {\n\tcase First:\n\t\treturn \"First\"\n\tcase Second:\n\t\treturn \"Second\"\n\t}\n",
+	},
+	{
+		// Unnamed enums stay untyped constants.
+		name: "unnamed",
+		src:  "enum { One = 1, Two };\nint f(void) { return Two; }\n",
+		want: "const (\n\tOne = iota + 1\n\tTwo\n)\n",
+	},
+}
+
+func TestEnums(t *testing.T) {
+	for _, tt := range enumTests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _ := translate(t, tt.cfg, map[string]string{"x.c": tt.src})
+			if !strings.Contains(string(out["x.go"]), tt.want) {
+				t.Errorf("output:\n%s\nwant it to contain:\n%s", out["x.go"], tt.want)
+			}
+		})
+	}
+}
@@ -2,7 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+package c2go
 
 import (
 	"strings"
@@ -23,11 +23,11 @@ func exportDecls(cfg *Config, prog *cc.Prog) {
 		cc.Preorder(d, func(x cc.Syntax) {
 			switch x := x.(type) {
 			case *cc.Expr:
-				if x.Op == cc.Name && x.XDecl != nil && x.XDecl.GoPackage != "" && x.XDecl.GoPackage != pkg {
+				if (x.Op == cc.Name || x.Op == cc.Dot && x.XDecl != nil && x.XDecl.Method) && x.XDecl != nil && x.XDecl.GoPackage != "" && x.XDecl.GoPackage != pkg {
 					exportDecl(x.XDecl)
 				}
 			case *cc.Type:
-				if x.Kind == cc.TypedefType && x.TypeDecl != nil && x.TypeDecl.GoPackage != "" && x.TypeDecl.GoPackage != pkg {
+				if (x.Kind == cc.TypedefType || isNamedEnum(x)) && x.TypeDecl != nil && x.TypeDecl.GoPackage != "" && x.TypeDecl.GoPackage != pkg {
 					exportDecl(x.TypeDecl)
 					x.Name = x.TypeDecl.Name
 				}
@@ -51,7 +51,7 @@ func shouldExport(cfg *Config, name string) bool {
 
 func exportDecl(d *cc.Decl) {
 	d.Name = exportName(d.Name)
-	if d.Storage&cc.Typedef != 0 && d.Type.Kind == cc.Struct {
+	if d.Storage&cc.Typedef != 0 && (d.Type.Kind == cc.Struct || d.Type.Kind == Interface) {
 		for _, dd := range d.Type.Decls {
 			exportDecl(dd)
 			// type became type_, became Type_. Drop underscore now that it's not needed.
@@ -77,7 +77,7 @@ func renameDecl(cfg *Config, d *cc.Decl) {
 	if cfg.rename[key] != "" {
 		d.Name = cfg.rename[key]
 	}
-	if d.Storage&cc.Typedef != 0 && d.Type.Kind == cc.Struct {
+	if d.Storage&cc.Typedef != 0 && (d.Type.Kind == cc.Struct || d.Type.Kind == Interface) {
 		for _, dd := range d.Type.Decls {
 			renameDecl(cfg, dd)
 			if dd.Name == "U" {
@@ -0,0 +1,280 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go_test
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/hajimehoshi/cingo/c2go"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// Each directory in testdata/golden is a test case.
+// Its C files, and the headers they include, are translated
+// as configured by its c2go.cfg file, if any,
+// and the Go files produced must match those in its want directory.
+func TestGolden(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join("testdata", "golden", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range dirs {
+		dir := dir
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			testGolden(t, dir)
+		})
+	}
+}
+
+func testGolden(t *testing.T, dir string) {
+	cfg := new(Config)
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "c2go.cfg")); err == nil {
+		var diags []*Diagnostic
+		cfg, diags = ParseConfig("c2go.cfg", data)
+		for _, d := range diags {
+			t.Errorf("%v", d)
+		}
+	}
+	inputs, err := readInputs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, diags := Translate(cfg, inputs)
+	for _, d := range diags {
+		if d.Severity == Error {
+			t.Errorf("%v", d)
+		}
+	}
+	if out == nil {
+		return
+	}
+
+	for name, data := range out {
+		if !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		if src, err := format.Source(data); err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if !bytes.Equal(src, data) {
+			t.Errorf("%s is not gofmt'd", name)
+		}
+	}
+	if err := typeCheck(out); err != nil {
+		t.Error(err)
+	}
+
+	want := filepath.Join(dir, "want")
+	if *update {
+		if err := os.RemoveAll(want); err != nil {
+			t.Fatal(err)
+		}
+		for name, data := range out {
+			file := filepath.Join(want, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(file, data, 0666); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return
+	}
+
+	golden, err := readTree(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range out {
+		g, ok := golden[name]
+		if !ok {
+			t.Errorf("unexpected output %s", name)
+			continue
+		}
+		if !bytes.Equal(g, data) {
+			t.Errorf("%s differs from golden file\nhave:\n%s\nwant:\n%s", name, data, g)
+		}
+	}
+	for name := range golden {
+		if _, ok := out[name]; !ok {
+			t.Errorf("missing output %s", name)
+		}
+	}
+}
+
+// readInputs returns the C files and headers in the tree rooted at dir,
+// named relative to dir, in lexical order.
+func readInputs(dir string) ([]Input, error) {
+	files, err := readTree(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range files {
+		if strings.HasPrefix(name, "want/") {
+			continue
+		}
+		if strings.HasSuffix(name, ".c") || strings.HasSuffix(name, ".h") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var inputs []Input
+	for _, name := range names {
+		inputs = append(inputs, Input{Name: name, Data: files[name]})
+	}
+	return inputs, nil
+}
+
+// readTree returns the files in the tree rooted at dir,
+// keyed by slash-separated paths relative to dir.
+func readTree(dir string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	return files, err
+}
+
+// typeCheck type-checks the packages of the module in files,
+// importing the standard library from source.
+func typeCheck(files map[string][]byte) error {
+	module := strings.TrimPrefix(strings.SplitN(string(files["go.mod"]), "\n", 2)[0], "module ")
+	fset := token.NewFileSet()
+	parsed := make(map[string][]*ast.File)
+	for name, data := range files {
+		if !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, data, 0)
+		if err != nil {
+			return err
+		}
+		pkg := path.Join(module, path.Dir(name))
+		parsed[pkg] = append(parsed[pkg], f)
+	}
+
+	std := importer.ForCompiler(fset, "source", nil)
+	checked := make(map[string]*types.Package)
+	var imp importerFunc
+	check := func(pkg string) (*types.Package, error) {
+		if p := checked[pkg]; p != nil {
+			return p, nil
+		}
+		var list []error
+		conf := types.Config{
+			Importer: imp,
+			Error:    func(err error) { list = append(list, err) },
+		}
+		p, _ := conf.Check(pkg, fset, parsed[pkg], nil)
+		if len(list) > 0 {
+			return nil, fmt.Errorf("type-checking %s: %v", pkg, list)
+		}
+		checked[pkg] = p
+		return p, nil
+	}
+	imp = func(pkg string) (*types.Package, error) {
+		if parsed[pkg] != nil {
+			return check(pkg)
+		}
+		return std.Import(pkg)
+	}
+
+	var pkgs []string
+	for pkg := range parsed {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		if _, err := check(pkg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// TestTranslateConcurrent translates the golden cases in parallel,
+// sharing a Config between the translations of each case,
+// and checks that each translation gives the same output.
+// Run it with -race.
+func TestTranslateConcurrent(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join("testdata", "golden", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type job struct {
+		dir    string
+		cfg    *Config
+		inputs []Input
+	}
+	var jobs []job
+	for _, dir := range dirs {
+		cfg := new(Config)
+		if data, err := ioutil.ReadFile(filepath.Join(dir, "c2go.cfg")); err == nil {
+			cfg, _ = ParseConfig("c2go.cfg", data)
+		}
+		inputs, err := readInputs(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, job{dir, cfg, inputs})
+	}
+
+	const n = 8
+	var wg sync.WaitGroup
+	outs := make([][]map[string][]byte, len(jobs))
+	for i, j := range jobs {
+		outs[i] = make([]map[string][]byte, n)
+		for k := 0; k < n; k++ {
+			wg.Add(1)
+			go func(i, k int, j job) {
+				defer wg.Done()
+				outs[i][k], _ = Translate(j.cfg, j.inputs)
+			}(i, k, j)
+		}
+	}
+	wg.Wait()
+
+	for i, j := range jobs {
+		for k := 1; k < n; k++ {
+			if !reflect.DeepEqual(outs[i][k], outs[i][0]) {
+				t.Errorf("%s: translation %d differs from translation 0", j.dir, k)
+			}
+		}
+	}
+}
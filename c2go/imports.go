@@ -0,0 +1,177 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go
+
+import (
+	"bytes"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+)
+
+// stdImports maps the names of the standard packages that rewrites
+// refer to, as in fmt.Sprintf or sort.Sort, to their import paths.
+var stdImports = map[string]string{
+	"bufio":   "bufio",
+	"bytes":   "bytes",
+	"errors":  "errors",
+	"fmt":     "fmt",
+	"io":      "io",
+	"log":     "log",
+	"math":    "math",
+	"bits":    "math/bits",
+	"os":      "os",
+	"sort":    "sort",
+	"strconv": "strconv",
+	"strings": "strings",
+	"time":    "time",
+	"unicode": "unicode",
+	"utf8":    "unicode/utf8",
+	"unsafe":  "unsafe",
+}
+
+// addImport records that the printed code uses package path.
+func (p *Printer) addImport(path string) {
+	p.addNamedImport("", path)
+}
+
+// addNamedImport records that the printed code refers to package path
+// as name. An empty name stands for the name of the package itself.
+func (p *Printer) addNamedImport(name, imp string) {
+	if p.imports == nil {
+		p.imports = make(map[string]string)
+	}
+	if name == path.Base(imp) {
+		name = ""
+	}
+	p.imports[imp] = name
+}
+
+// qualified records the import needed by the qualified name
+// pkg.Name inserted by a rewrite, if pkg names a known package,
+// and returns the name as it should be printed.
+//
+// The config's import directives take precedence over the
+// standard packages: "import name path" makes name.X refer to path,
+// and makes references to path under its usual name use name instead.
+func (p *Printer) qualified(name string) string {
+	i := strings.Index(name, ".")
+	if i < 0 {
+		return name
+	}
+	pkg := name[:i]
+	if imp, ok := p.importMap[pkg]; ok {
+		p.addNamedImport(pkg, imp)
+		return name
+	}
+	imp, ok := stdImports[pkg]
+	if !ok {
+		return name
+	}
+	if alias := p.importAlias(imp); alias != "" {
+		pkg = alias
+	}
+	p.addNamedImport(pkg, imp)
+	return pkg + name[i:]
+}
+
+// replacementImports records the imports needed by src,
+// Go code given by the config to replace a declaration,
+// and returns src with its qualified names printed as
+// qualified would print them.
+func (p *Printer) replacementImports(src string) string {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	var s scanner.Scanner
+	s.Init(file, []byte(src), nil, 0)
+	var toks []token.Token
+	var lits []string
+	var offs []int
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		toks = append(toks, tok)
+		lits = append(lits, lit)
+		offs = append(offs, file.Offset(pos))
+	}
+	var b strings.Builder
+	last := 0
+	for i := 0; i+2 < len(toks); i++ {
+		if toks[i] != token.IDENT || toks[i+1] != token.PERIOD || toks[i+2] != token.IDENT || i > 0 && toks[i-1] == token.PERIOD {
+			continue
+		}
+		name := p.qualified(lits[i] + "." + lits[i+2])
+		b.WriteString(src[last:offs[i]])
+		b.WriteString(name[:strings.Index(name, ".")])
+		last = offs[i] + len(lits[i])
+	}
+	b.WriteString(src[last:])
+	return b.String()
+}
+
+// importAlias returns the name given to the package path imp
+// by an import directive, or "" if there is none.
+func (p *Printer) importAlias(imp string) string {
+	alias := ""
+	for name, path := range p.importMap {
+		if path == imp && (alias == "" || name < alias) {
+			alias = name
+		}
+	}
+	return alias
+}
+
+// importBlock returns the import declaration for the recorded imports,
+// with the standard packages first, each group sorted by path.
+func (p *Printer) importBlock() []byte {
+	if len(p.imports) == 0 {
+		return nil
+	}
+	var std, other []string
+	for imp := range p.imports {
+		if p.isStdImport(imp) {
+			std = append(std, imp)
+		} else {
+			other = append(other, imp)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+
+	var b bytes.Buffer
+	b.WriteString("import (\n")
+	for i, group := range [][]string{std, other} {
+		if i > 0 && len(std) > 0 && len(other) > 0 {
+			b.WriteString("\n")
+		}
+		for _, imp := range group {
+			if name := p.imports[imp]; name != "" {
+				fmt.Fprintf(&b, "\t%s %q\n", name, imp)
+			} else {
+				fmt.Fprintf(&b, "\t%q\n", imp)
+			}
+		}
+	}
+	b.WriteString(")\n\n")
+	return b.Bytes()
+}
+
+// isStdImport reports whether imp is the path of a standard package:
+// one outside the module being written whose first element,
+// unlike a module path's, has no dot.
+func (p *Printer) isStdImport(imp string) bool {
+	if imp == p.module || strings.HasPrefix(imp, p.module+"/") {
+		return false
+	}
+	if i := strings.Index(imp, "/"); i >= 0 {
+		imp = imp[:i]
+	}
+	return !strings.Contains(imp, ".")
+}
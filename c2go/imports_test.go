@@ -0,0 +1,123 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go_test
+
+import (
+	"strings"
+	"testing"
+)
+
+const importSrc = `
+int print(char*, ...);
+void qsort(void*, int, int, int(*)(const void*, const void*));
+void *memmove(void*, void*, int);
+typedef struct Item Item;
+struct Item { int key; };
+static int itemcmp(const void *a, const void *b)
+{
+	Item *p, *q;
+
+	p = (Item*)a;
+	q = (Item*)b;
+	return p->key - q->key;
+}
+`
+
+var importTests = []struct {
+	name string
+	cfg  string
+	src  string
+	want string // import block, or "" for none
+}{
+	{
+		name: "none",
+		src:  importSrc,
+	},
+	{
+		name: "sort",
+		cfg:  "slice sortitems.items\n",
+		src: importSrc + `
+void sortitems(Item *items, int n)
+{
+	qsort(items, n, sizeof items[0], itemcmp);
+}
+`,
+		want: "import (\n\t\"sort\"\n)\n",
+	},
+	{
+		name: "math",
+		cfg:  "model lp64\n",
+		src: importSrc + `
+unsigned int bits(float f)
+{
+	unsigned int u;
+
+	memmove(&u, &f, 4);
+	return u;
+}
+`,
+		want: "import (\n\t\"math\"\n)\n",
+	},
+	{
+		// Standard packages come first, each group sorted.
+		name: "sorted",
+		cfg:  "model lp64\nslice sortitems.items\n",
+		src: importSrc + `
+void sortitems(Item *items, int n)
+{
+	qsort(items, n, sizeof items[0], itemcmp);
+	print("%d\n", n);
+}
+unsigned int bits(float f)
+{
+	unsigned int u;
+
+	memmove(&u, &f, 4);
+	return u;
+}
+`,
+		want: "import (\n\t\"fmt\"\n\t\"math\"\n\t\"sort\"\n)\n",
+	},
+	{
+		// fmt.Printf from the print rewrite refers to log.Printf.
+		name: "override",
+		cfg:  "import fmt log\nslice sortitems.items\n",
+		src: importSrc + `
+void sortitems(Item *items, int n)
+{
+	qsort(items, n, sizeof items[0], itemcmp);
+	print("%d\n", n);
+}
+`,
+		want: "import (\n\tfmt \"log\"\n\t\"sort\"\n)\n",
+	},
+	{
+		name: "alias",
+		cfg:  "import sorting sort\nslice sortitems.items\n",
+		src: importSrc + `
+void sortitems(Item *items, int n)
+{
+	qsort(items, n, sizeof items[0], itemcmp);
+}
+`,
+		want: "import (\n\tsorting \"sort\"\n)\n",
+	},
+}
+
+func TestImports(t *testing.T) {
+	for _, tt := range importTests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _ := translate(t, tt.cfg, map[string]string{"x.c": tt.src})
+			src := string(out["x.go"])
+			got := ""
+			if i := strings.Index(src, "import ("); i >= 0 {
+				got = src[i : i+strings.Index(src[i:], ")\n")+2]
+			}
+			if got != tt.want {
+				t.Errorf("imports:\n%s\nwant:\n%s\nin:\n%s", got, tt.want, src)
+			}
+		})
+	}
+}
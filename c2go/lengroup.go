@@ -0,0 +1,386 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go
+
+import (
+	"sort"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// A lenGroup is a struct field holding a pointer to an array,
+// along with the sibling fields holding its length and capacity.
+// It is what a "slice T.p T.n T.max" config line declares.
+type lenGroup struct {
+	ptr, len, cap *cc.Decl
+	span          cc.Span // evidence of the group
+	why           string
+	weak          bool // only an allocation is evidence, too little to apply the group
+}
+
+// A lenEvidence is a sibling field found to be a length or capacity.
+type lenEvidence struct {
+	len, cap *cc.Decl
+	span     cc.Span
+}
+
+// inferLenGroups looks for struct fields that are pointers to arrays
+// whose length and capacity live in sibling fields.
+//
+// A sibling bounding a loop that indexes the pointer is its length,
+// and one in the size of an allocation assigned to it is its length
+// or, when the length is known otherwise, its capacity.
+// A comparison of two siblings guarding a reallocation of the pointer,
+// as in if(s->n >= s->max) s->p = realloc(s->p, ...), settles both,
+// as does one guarding a store at the end, as in
+// if(s->n < s->max) s->p[s->n++] = v.
+//
+// An allocation is only evidence if its size is a single sibling,
+// possibly times the size of an element: in s->w * s->h * sizeof *s->p
+// neither sibling is the length.
+//
+// Fields already named by slice or string config lines are left alone.
+// With the autoslice config directive the groups found are applied
+// as if they had been configured, except those whose only evidence
+// is an allocation; otherwise each is reported
+// as a suggested slice line. A capacity is only applied if each
+// assignment to it can be dropped by rewriteLen: Go has no way
+// to set the capacity of a slice but allocating it.
+func inferLenGroups(cfg *Config, prog *cc.Prog) {
+	g := &lenGroups{
+		cfg:    cfg,
+		loop:   make(map[*cc.Decl]lenEvidence),
+		alloc:  make(map[*cc.Decl]lenEvidence),
+		growth: make(map[*cc.Decl]lenEvidence),
+		guard:  make(map[*cc.Decl]lenEvidence),
+		sets:   make(map[*cc.Decl][]fieldSet),
+	}
+	cc.Preorder(prog, func(x cc.Syntax) {
+		switch x := x.(type) {
+		case *cc.Stmt:
+			g.stmt(x)
+		case *cc.Expr:
+			g.expr(x)
+		}
+	})
+
+	cfg.allocSets = make(map[*cc.Stmt]bool)
+	for _, sets := range g.sets {
+		for _, set := range sets {
+			if setByAlloc(set.list, set.i) {
+				cfg.allocSets[set.list[set.i]] = true
+			}
+		}
+	}
+
+	var groups []lenGroup
+	for p := range g.ptrs() {
+		grp := lenGroup{ptr: p}
+		if e, ok := g.growth[p]; ok {
+			grp.len, grp.cap, grp.span, grp.why = e.len, e.cap, e.span, "reallocated when full"
+		} else if e, ok := g.guard[p]; ok {
+			grp.len, grp.cap, grp.span, grp.why = e.len, e.cap, e.span, "guards stores at its end"
+		} else if e, ok := g.loop[p]; ok {
+			grp.len, grp.span, grp.why = e.len, e.span, "bounds a loop indexing it"
+			if a, ok := g.alloc[p]; ok && a.len != e.len {
+				grp.cap = a.len
+			}
+		} else {
+			e := g.alloc[p]
+			grp.len, grp.span, grp.why, grp.weak = e.len, e.span, "sizes its allocation", true
+		}
+		if grp.len == nil || g.configured(grp) {
+			continue
+		}
+		if grp.cap != nil && cfg.autoLen && !g.capDroppable(grp) {
+			grp.cap = nil
+		}
+		groups = append(groups, grp)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return declKey(groups[i].ptr) < declKey(groups[j].ptr)
+	})
+
+	for _, grp := range groups {
+		line := "slice " + declKey(grp.ptr) + " " + declKey(grp.len)
+		if grp.cap != nil {
+			line += " " + declKey(grp.cap)
+		}
+		cfg.suggest(grp.span, line, "%s %s", declKey(grp.len), grp.why)
+		if !cfg.autoLen || grp.weak {
+			cfg.infof(grp.ptr.Span, "slice-group", "suggest: %s", line)
+			continue
+		}
+		cfg.infof(grp.ptr.Span, "slice-group", "inferred: %s", line)
+		k := declKey(grp.ptr)
+		cfg.slice[k] = true
+		cfg.len[declKey(grp.len)] = k
+		if grp.cap != nil {
+			cfg.cap[declKey(grp.cap)] = k
+		}
+	}
+}
+
+type lenGroups struct {
+	cfg    *Config
+	loop   map[*cc.Decl]lenEvidence // pointer field -> sibling bounding a loop indexing it
+	alloc  map[*cc.Decl]lenEvidence // pointer field -> sibling sizing an allocation of it
+	growth map[*cc.Decl]lenEvidence // pointer field -> length and capacity guarding its reallocation
+	guard  map[*cc.Decl]lenEvidence // pointer field -> length and capacity guarding a store at its end
+	sets   map[*cc.Decl][]fieldSet  // integer field -> statements assigning to it
+}
+
+// A fieldSet is a statement assigning to an integer field:
+// the statement list[i].
+type fieldSet struct {
+	list []*cc.Stmt
+	i    int
+}
+
+// capDroppable reports whether each assignment to the capacity
+// of grp sets it to the capacity of an allocation of the pointer.
+func (g *lenGroups) capDroppable(grp lenGroup) bool {
+	for _, set := range g.sets[grp.cap] {
+		if !g.cfg.allocSets[set.list[set.i]] {
+			return false
+		}
+	}
+	return true
+}
+
+// ptrs returns the set of pointer fields with any evidence.
+func (g *lenGroups) ptrs() map[*cc.Decl]bool {
+	m := make(map[*cc.Decl]bool)
+	for p := range g.loop {
+		m[p] = true
+	}
+	for p := range g.alloc {
+		m[p] = true
+	}
+	for p := range g.growth {
+		m[p] = true
+	}
+	return m
+}
+
+// configured reports whether a field of grp already appears in the config.
+func (g *lenGroups) configured(grp lenGroup) bool {
+	for _, d := range []*cc.Decl{grp.ptr, grp.len, grp.cap} {
+		if d == nil {
+			continue
+		}
+		k := declKey(d)
+		if g.cfg.slice[k] || g.cfg.ptr[k] || g.cfg.len[k] != "" || g.cfg.cap[k] != "" || g.cfg.delete[k] {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *lenGroups) stmt(x *cc.Stmt) {
+	switch x.Op {
+	case cc.Block:
+		for i, y := range x.Block {
+			if y.Op != cc.StmtExpr {
+				continue
+			}
+			switch y.Expr.Op {
+			case cc.Eq, cc.AddEq, cc.SubEq, cc.MulEq, cc.DivEq, cc.ModEq, cc.LshEq, cc.RshEq, cc.AndEq, cc.OrEq, cc.XorEq,
+				cc.PreInc, cc.PreDec, cc.PostInc, cc.PostDec:
+				if f := unparen(y.Expr.Left); isIntField(f) {
+					g.sets[f.XDecl] = append(g.sets[f.XDecl], fieldSet{x.Block, i})
+				}
+			}
+		}
+
+	case cc.For, cc.While, cc.Do:
+		// for(i = 0; i < s->n; i++) ... s->p[i] ...
+		cond := unparen(x.Expr)
+		if cond == nil {
+			return
+		}
+		var i, bound *cc.Expr
+		switch cond.Op {
+		case cc.Lt, cc.LtEq, cc.NotEq:
+			i, bound = unparen(cond.Left), unparen(cond.Right)
+		case cc.Gt, cc.GtEq:
+			i, bound = unparen(cond.Right), unparen(cond.Left)
+		default:
+			return
+		}
+		if i.Op != cc.Name || i.XDecl == nil || !isIntField(bound) {
+			return
+		}
+		cc.Preorder(x.Body, func(y cc.Syntax) {
+			y1, ok := y.(*cc.Expr)
+			if !ok || y1.Op != cc.Index {
+				return
+			}
+			if index := unparen(y1.Right); index.Op != cc.Name || index.XDecl != i.XDecl {
+				return
+			}
+			if p := unparen(y1.Left); isPtrField(p) && siblings(p, bound) {
+				if _, ok := g.loop[p.XDecl]; !ok {
+					g.loop[p.XDecl] = lenEvidence{len: bound.XDecl, span: x.Span}
+				}
+			}
+		})
+
+	case cc.If:
+		// if(s->n >= s->max) { ...; s->p = realloc(s->p, ...); }
+		cond := unparen(x.Expr)
+		if cond == nil {
+			return
+		}
+		var n, max *cc.Expr
+		switch cond.Op {
+		case cc.GtEq, cc.Gt, cc.EqEq:
+			n, max = unparen(cond.Left), unparen(cond.Right)
+		case cc.LtEq, cc.Lt:
+			n, max = unparen(cond.Right), unparen(cond.Left)
+		default:
+			return
+		}
+		if !isIntField(n) || !isIntField(max) || !siblings(n, max) || n.XDecl == max.XDecl {
+			return
+		}
+		if cond.Op == cc.Lt {
+			// if(s->n < s->max) s->p[s->n++] = v
+			// Here the length is on the left.
+			n, max := max, n
+			cc.Preorder(x.Body, func(y cc.Syntax) {
+				y1, ok := y.(*cc.Expr)
+				if !ok || y1.Op != cc.Eq || unparen(y1.Left).Op != cc.Index {
+					return
+				}
+				index := unparen(y1.Left)
+				i := unparen(index.Right)
+				if i.Op == cc.PostInc {
+					i = unparen(i.Left)
+				}
+				if p := unparen(index.Left); isPtrField(p) && siblings(p, n) && isIntField(i) && i.XDecl == n.XDecl {
+					g.guard[p.XDecl] = lenEvidence{len: n.XDecl, cap: max.XDecl, span: x.Span}
+				}
+			})
+		}
+		cc.Preorder(x.Body, func(y cc.Syntax) {
+			y1, ok := y.(*cc.Expr)
+			if !ok || y1.Op != cc.Eq || allocSize(unparen(y1.Right)) == nil {
+				return
+			}
+			if p := unparen(y1.Left); isPtrField(p) && siblings(p, n) {
+				g.growth[p.XDecl] = lenEvidence{len: n.XDecl, cap: max.XDecl, span: x.Span}
+			}
+		})
+	}
+}
+
+func (g *lenGroups) expr(x *cc.Expr) {
+	// s->p = malloc(s->n * sizeof *s->p)
+	if x.Op != cc.Eq {
+		return
+	}
+	p := unparen(x.Left)
+	if !isPtrField(p) {
+		return
+	}
+	size := allocSize(unparen(x.Right))
+	if _, ok := g.alloc[p.XDecl]; ok || size == nil {
+		return
+	}
+	if n := elemCount(size); n != nil && isIntField(n) && siblings(p, n) {
+		g.alloc[p.XDecl] = lenEvidence{len: n.XDecl, span: x.Span}
+	}
+}
+
+// elemCount returns the number of elements allocated by an allocation
+// of size bytes, if size is that number n or n * sizeof(elem).
+// In any other size, such as w * h * sizeof(elem), no single
+// expression is the count.
+func elemCount(size *cc.Expr) *cc.Expr {
+	size = unparen(size)
+	if size.Op != cc.Mul {
+		return size
+	}
+	l, r := unparen(size.Left), unparen(size.Right)
+	switch {
+	case r.Op == cc.SizeofExpr || r.Op == cc.SizeofType:
+		return l
+	case l.Op == cc.SizeofExpr || l.Op == cc.SizeofType:
+		return r
+	}
+	return nil
+}
+
+// setByAlloc reports whether list[i] is a statement s->max = e
+// where another statement of list allocates s->p with room for e
+// elements, as in s->p = malloc(e * sizeof *s->p),
+// which gives the slice s->p its capacity in Go.
+func setByAlloc(list []*cc.Stmt, i int) bool {
+	x := list[i].Expr
+	if list[i].Op != cc.StmtExpr || x.Op != cc.Eq || !isIntField(unparen(x.Left)) {
+		return false
+	}
+	max, e := unparen(x.Left), unparen(x.Right)
+	for _, s := range list {
+		if s.Op != cc.StmtExpr || s.Expr.Op != cc.Eq {
+			continue
+		}
+		p, size := unparen(s.Expr.Left), allocSize(unparen(s.Expr.Right))
+		if size == nil || !isPtrField(p) || !siblings(p, max) {
+			continue
+		}
+		size = unparen(size)
+		if size.String() == e.String() || size.Op == cc.Mul && (unparen(size.Left).String() == e.String() || unparen(size.Right).String() == e.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// allocSize returns the size argument of the allocation x, if it is one.
+func allocSize(x *cc.Expr) *cc.Expr {
+	if x.Op == cc.Cast {
+		return allocSize(unparen(x.Left))
+	}
+	if x.Op != cc.Call || x.Left.Op != cc.Name {
+		return nil
+	}
+	switch x.Left.Text {
+	case "mal", "malloc", "emallocz", "xmalloc":
+		if len(x.List) == 1 {
+			return x.List[0]
+		}
+	case "realloc", "erealloc", "xrealloc":
+		if len(x.List) == 2 {
+			return x.List[1]
+		}
+	case "calloc":
+		if len(x.List) == 2 {
+			return x.List[0]
+		}
+	}
+	return nil
+}
+
+// isPtrField reports whether x selects a struct field holding a data pointer.
+func isPtrField(x *cc.Expr) bool {
+	return (x.Op == cc.Arrow || x.Op == cc.Dot) && x.XDecl != nil && x.XDecl.OuterType != nil && isDataPtr(x.XDecl.Type)
+}
+
+// isIntField reports whether x selects a struct field holding an integer.
+func isIntField(x *cc.Expr) bool {
+	if (x.Op != cc.Arrow && x.Op != cc.Dot) || x.XDecl == nil || x.XDecl.OuterType == nil {
+		return false
+	}
+	t := x.XDecl.Type.Def()
+	return t != nil && cc.Char <= t.Kind && t.Kind <= cc.Ulonglong && x.XDecl.Bits == nil
+}
+
+// siblings reports whether the field selections x and y
+// select fields of the same struct value.
+func siblings(x, y *cc.Expr) bool {
+	return x.Op == y.Op && x.XDecl.OuterType == y.XDecl.OuterType && x.Left.String() == y.Left.String()
+}
@@ -0,0 +1,103 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/hajimehoshi/cingo/c2go"
+)
+
+const lenGroupSrc = `
+void *malloc(unsigned long);
+void *realloc(void*, unsigned long);
+typedef struct Buf Buf;
+struct Buf {
+	int *p;
+	int np;
+	int maxp;
+};
+`
+
+var lenGroupTests = []struct {
+	name string
+	src  string
+	want []string // slice lines of the suggested config
+}{
+	{
+		name: "loop",
+		src: lenGroupSrc + `
+int sum(Buf *b) {
+	int i, s;
+	s = 0;
+	for(i = 0; i < b->np; i++)
+		s += b->p[i];
+	return s;
+}
+`,
+		want: []string{"slice Buf.p Buf.np"},
+	},
+	{
+		name: "growth",
+		src: lenGroupSrc + `
+void add(Buf *b, int x) {
+	if(b->np >= b->maxp) {
+		b->maxp = 2*b->maxp + 1;
+		b->p = realloc(b->p, b->maxp * sizeof b->p[0]);
+	}
+	b->p[b->np++] = x;
+}
+`,
+		want: []string{"slice Buf.p Buf.np Buf.maxp"},
+	},
+	{
+		// A loop bound that is not a sibling field
+		// makes a slice with no length field.
+		name: "local",
+		src: lenGroupSrc + `
+int sum(Buf *b, int n) {
+	int i, s;
+	s = 0;
+	for(i = 0; i < n; i++)
+		s += b->p[i];
+	return s;
+}
+`,
+		want: []string{"slice Buf.p"},
+	},
+	{
+		// An allocation of a sibling field's count of elements.
+		name: "alloc",
+		src: lenGroupSrc + `
+void binit(Buf *b) {
+	b->p = malloc(b->np * sizeof b->p[0]);
+}
+`,
+		want: []string{"slice Buf.p Buf.np"},
+	},
+}
+
+func TestLenGroups(t *testing.T) {
+	for _, tt := range lenGroupTests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, diags := Suggest(new(Config), []Input{{Name: "x.c", Data: []byte(tt.src)}})
+			for _, d := range diags {
+				if d.Severity == Error {
+					t.Errorf("%v", d)
+				}
+			}
+			var got []string
+			for _, line := range strings.Split(string(out), "\n") {
+				if strings.HasPrefix(line, "slice ") {
+					got = append(got, line)
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("suggested:\n%s\nwant slice lines:\n%s", out, strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
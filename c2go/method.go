@@ -0,0 +1,135 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go
+
+import (
+	"strings"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// A methodRule is a "method" config directive: functions whose names
+// match pattern and whose first parameter is a pointer to typ
+// become methods on typ.
+type methodRule struct {
+	typ     string
+	pattern string // name pattern; the part matching * is the method name
+}
+
+// methodName returns the name of the method translated from
+// the function name, or "" if name does not match the rule's pattern.
+func (r methodRule) methodName(name string) string {
+	i := strings.Index(r.pattern, "*")
+	prefix, suffix := r.pattern[:i], r.pattern[i+1:]
+	if len(name) <= len(prefix)+len(suffix) || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return ""
+	}
+	return name[len(prefix) : len(name)-len(suffix)]
+}
+
+// rewriteMethods turns the functions selected by "method" config
+// directives into Go methods on the type their first parameter points to.
+// A call foo_bar(f, x) becomes f.bar(x), and other uses of foo_bar
+// become the method expression (*Foo).bar.
+//
+// It runs before renameDecls and exportDecls: methods are exempt from
+// renaming, and a method is exported if its function was.
+func rewriteMethods(cfg *Config, prog *cc.Prog) {
+	if len(cfg.methods) == 0 {
+		return
+	}
+
+	type key struct {
+		recv *cc.Type
+		name string
+	}
+	taken := make(map[key]string) // C name of the function made each method
+	for _, d := range prog.Decls {
+		if d.Type == nil || d.Type.Kind != cc.Func || d.Body == nil || len(d.Type.Decls) == 0 || d.Method {
+			continue
+		}
+		recv := d.Type.Decls[0].Type.Def()
+		if recv == nil || recv.Kind != cc.Ptr || recv.Base == nil {
+			continue
+		}
+		base := recv.Base
+		for _, r := range cfg.methods {
+			if base.Name != r.typ && base.Def().Tag != r.typ {
+				continue
+			}
+			name := r.methodName(d.Name)
+			if name == "" {
+				continue
+			}
+			if unionMember(base.Def(), name) != nil {
+				cfg.warnf(d.Span, "method", "cannot make %s a method %s of %s: it has a field of that name", d.Name, name, r.typ)
+				break
+			}
+			k := key{base.Def(), strings.ToLower(name)}
+			if old := taken[k]; old != "" {
+				cfg.warnf(d.Span, "method", "cannot make %s a method %s of %s: %s already is", d.Name, name, r.typ, old)
+				break
+			}
+			if pkg, tpkg := funcPackage(cfg, d), typePackage(cfg, base); pkg != tpkg {
+				cfg.warnf(d.Span, "method", "cannot make %s a method of %s: it is in package %s, not %s", d.Name, r.typ, pkg, tpkg)
+				break
+			}
+			taken[k] = d.Name
+			if shouldExport(cfg, d.Name) {
+				name = exportName(name)
+			}
+			d.Name = name
+			d.Method = true
+			break
+		}
+	}
+
+	cc.Preorder(prog, func(x cc.Syntax) {
+		x1, ok := x.(*cc.Expr)
+		if !ok || x1.Op != cc.Call || x1.Left.Op != cc.Name || x1.Left.XDecl == nil || !x1.Left.XDecl.Method || len(x1.List) == 0 {
+			return
+		}
+		// Go takes the address of the receiver as needed.
+		recv := x1.List[0]
+		if recv.Op == cc.Addr {
+			recv = recv.Left
+		}
+		x1.Left = &cc.Expr{
+			SyntaxInfo: x1.Left.SyntaxInfo,
+			Op:         cc.Dot,
+			Left:       recv,
+			Text:       x1.Left.Text,
+			XDecl:      x1.Left.XDecl,
+		}
+		x1.List = x1.List[1:]
+	})
+}
+
+// funcPackage returns the Go package of the function d,
+// as renameDecls will assign it.
+func funcPackage(cfg *Config, d *cc.Decl) string {
+	span := d.Span
+	if d.Body != nil && d.Body.Span.Start.File != "" {
+		span = d.Body.Span
+	}
+	return cfg.filePackage(span.Start.File)
+}
+
+// typePackage returns the Go package of the named type t.
+func typePackage(cfg *Config, t *cc.Type) string {
+	if t.TypeDecl != nil {
+		return cfg.filePackage(t.TypeDecl.Span.Start.File)
+	}
+	return cfg.filePackage(t.Def().Span.Start.File)
+}
+
+// recvName returns the name of the receiver type of the method d.
+func recvName(d *cc.Decl) string {
+	t := d.Type.Decls[0].Type.Def().Base
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Def().Tag
+}
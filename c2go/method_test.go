@@ -0,0 +1,106 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go_test
+
+import (
+	"strings"
+	"testing"
+)
+
+const methodSrc = `
+typedef struct Buf Buf;
+struct Buf {
+	int len;
+};
+`
+
+var methodTests = []struct {
+	name  string
+	cfg   string
+	src   string
+	want  []string // lines of the output
+	diags []string // method diagnostics, as printed
+}{
+	{
+		name: "pattern",
+		cfg:  "method Buf *_buf\n",
+		src: methodSrc + `
+void reset_buf(Buf *b) { b->len = 0; }
+void buf_clear(Buf *b) { reset_buf(b); }
+`,
+		want: []string{
+			"func (b *Buf) reset() {",
+			"func buf_clear(b *Buf) {",
+			"\tb.reset()",
+		},
+	},
+	{
+		// Only pointers to the type are receivers.
+		name: "value",
+		cfg:  "method Buf\n",
+		src: methodSrc + `
+int buf_size(Buf b) { return b.len; }
+int buf_cap(Buf *b) { return buf_size(*b); }
+`,
+		want: []string{
+			"func buf_size(b Buf) int {",
+			"func (b *Buf) cap() int {",
+			"\treturn buf_size(*b)",
+		},
+	},
+	{
+		name: "field",
+		cfg:  "method Buf\n",
+		src: methodSrc + `
+int buf_len(Buf *b) { return b->len; }
+`,
+		want:  []string{"func buf_len(b *Buf) int {"},
+		diags: []string{"x.c:7: cannot make buf_len a method len of Buf: it has a field of that name"},
+	},
+	{
+		// Go method names that differ only in case
+		// would conflict once exported.
+		name: "taken",
+		cfg:  "method Buf\n",
+		src: methodSrc + `
+void buf_reset(Buf *b) { b->len = 0; }
+void buf_Reset(Buf *b) { b->len = 0; }
+`,
+		want:  []string{"func (b *Buf) reset() {", "func buf_Reset(b *Buf) {"},
+		diags: []string{"x.c:8: cannot make buf_Reset a method Reset of Buf: buf_reset already is"},
+	},
+}
+
+func TestMethods(t *testing.T) {
+	for _, tt := range methodTests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, diags := translate(t, tt.cfg, map[string]string{"x.c": tt.src})
+			lines := strings.Split(string(out["x.go"]), "\n")
+			for _, want := range tt.want {
+				if !contains(lines, want) {
+					t.Errorf("missing line %q in:\n%s", want, out["x.go"])
+				}
+			}
+			var got []string
+			for _, d := range diags {
+				if d.Code == "method" {
+					got = append(got, d.String())
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.diags, "\n") {
+				t.Errorf("method diagnostics:\n%q\nwant:\n%q", got, tt.diags)
+			}
+		})
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+// Copyright 2015 The Go Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/scanner"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// goVersion is the Go version declared in generated go.mod files.
+const goVersion = "1.12"
+
+// modulePath returns the path of the module the Go files are written to.
+// It defaults to main.
+func (cfg *Config) modulePath() string {
+	if cfg.module != "" {
+		return cfg.module
+	}
+	return "main"
+}
+
+// packageDir returns the directory of the Go package pkg
+// relative to the root of module.
+// Packages are named by paths relative to the module root,
+// or by full import paths within the module.
+func packageDir(module, pkg string) string {
+	if pkg == module {
+		return ""
+	}
+	return strings.TrimPrefix(pkg, module+"/")
+}
+
+// importPath returns the import path of the Go package pkg in module.
+func importPath(module, pkg string) string {
+	return path.Join(module, packageDir(module, pkg))
+}
+
+// writeGoFiles writes prog to Go source files in a tree of packages
+// in cfg.output, along with the go.mod file of their module
+// and, with the lines option, the source maps of the files.
+func writeGoFiles(cfg *Config, prog *cc.Prog) {
+	module := cfg.modulePath()
+	printers := map[string]*Printer{}
+	cfiles := map[string]string{}
+	for _, decl := range prog.Decls {
+		if decl.GoPackage == "" {
+			decl.GoPackage = "other"
+		}
+		cfile := decl.Span.Start.File
+		gofile := strings.TrimSuffix(strings.TrimSuffix(cfile, ".c"), ".h") + ".go"
+		gofile = path.Join(packageDir(module, decl.GoPackage), filepath.Base(gofile))
+		cfiles[gofile] = cfile
+		p := printers[gofile]
+		if p == nil {
+			p = new(Printer)
+			p.Package = decl.GoPackage
+			p.module = module
+			p.importMap = cfg.imports
+			p.model = cfg.dataModel()
+			p.stringers = cfg.stringers
+			p.cfg = cfg
+			pkg := path.Base(importPath(module, p.Package))
+			if strings.Count(p.Package, "/") == 1 && strings.HasPrefix(p.Package, "cmd/") {
+				pkg = "main"
+			}
+			p.Print("package ", pkg, "\n\n")
+			printers[gofile] = p
+		}
+
+		off := len(p.Bytes())
+		repl, ok := cfg.replace[decl.Name]
+		if !ok {
+			repl, ok = cfg.replace[strings.ToLower(decl.Name)]
+		}
+		if cfg.delete[decl.Name] || cfg.delete[strings.ToLower(decl.Name)] {
+			repl, ok = "", true
+		}
+		if ok {
+			// Use replacement text from config but keep surrounding comments.
+			p.Print(decl.Comments.Before)
+			start := len(p.Bytes())
+			p.Print(p.replacementImports(repl))
+			p.mark(decl, start)
+			p.Print(decl.Comments.Suffix, decl.Comments.After)
+		} else {
+			p.Print(decl)
+		}
+		if len(p.Bytes()) > off {
+			p.Print(Newline)
+			p.Print(Newline)
+		}
+	}
+
+	cfg.output["go.mod"] = []byte(fmt.Sprintf("module %s\n\ngo %s\n", module, goVersion))
+
+	gofiles := make([]string, 0, len(printers))
+	for gofile := range printers {
+		gofiles = append(gofiles, gofile)
+	}
+	// Apply the diffs in a fixed order.
+	sort.Strings(gofiles)
+	for _, gofile := range gofiles {
+		p := printers[gofile]
+		buf := p.Bytes()
+		m := p.srcMap
+		if imp := p.importBlock(); imp != nil {
+			// Insert imports after the package clause.
+			i := bytes.Index(buf, []byte("\n\n")) + 2
+			buf = append(append(append([]byte(nil), buf[:i]...), imp...), buf[i:]...)
+			m.insert(i, len(imp))
+		}
+
+		// Not entirely sure why these lines get broken.
+		buf, _ = m.replace(buf, []byte("\n,"), []byte(","))
+		buf, _ = m.replace(buf, []byte("\n {"), []byte(" {"))
+
+		buf1, err := format.Source(buf)
+		if err != nil {
+			// Scream because it invalidates diffs.
+			span := cc.Span{Start: cc.Pos{File: cfiles[gofile]}}
+			if list, ok := err.(scanner.ErrorList); ok && len(list) > 0 {
+				if s, ok := m.lookup(lineOffset(buf, list[0].Pos.Line, list[0].Pos.Column)); ok {
+					span = s
+				}
+			}
+			cfg.errorf(span, "format", "formatting %s: %v", gofile, err)
+		}
+		if err == nil {
+			m = m.reformat(buf, buf1)
+			buf = buf1
+		}
+
+		// Not sure where these blank lines come from.
+		buf, _ = m.replace(buf, []byte("{\n\n"), []byte("{\n"))
+
+		for i, d := range cfg.diffs {
+			var n int
+			buf, n = m.replace(buf, d.before, d.after)
+			cfg.diffs[i].used += n
+		}
+
+		if cfg.lines {
+			buf, m = m.lineDirectives(path.Base(gofile), buf)
+			cfg.output[gofile+".map"] = m.encode(path.Base(gofile), buf)
+		}
+		cfg.output[gofile] = buf
+		cfg.srcMaps[gofile] = m
+	}
+}
+
+// lineOffset returns the offset in src of the 1-based line and column.
+func lineOffset(src []byte, line, col int) int {
+	off := 0
+	for ; line > 1; line-- {
+		i := bytes.IndexByte(src[off:], '\n')
+		if i < 0 {
+			return len(src)
+		}
+		off += i + 1
+	}
+	return off + col - 1
+}
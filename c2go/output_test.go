@@ -0,0 +1,99 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go_test
+
+import (
+	"sort"
+	"testing"
+
+	. "github.com/hajimehoshi/cingo/c2go"
+)
+
+// translate translates the C files in srcs, named by file name,
+// as configured by cfg, failing the test on any error.
+func translate(t *testing.T, cfg string, srcs map[string]string) (map[string][]byte, []*Diagnostic) {
+	t.Helper()
+	c, diags := ParseConfig("c2go.cfg", []byte(cfg))
+	for _, d := range diags {
+		t.Fatalf("%v", d)
+	}
+	var names []string
+	for name := range srcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var inputs []Input
+	for _, name := range names {
+		inputs = append(inputs, Input{Name: name, Data: []byte(srcs[name])})
+	}
+	out, diags := Translate(c, inputs)
+	for _, d := range diags {
+		if d.Severity == Error {
+			t.Errorf("%v", d)
+		}
+	}
+	return out, diags
+}
+
+var moduleTests = []struct {
+	name string
+	cfg  string
+	srcs map[string]string
+	want map[string]string // output files
+}{
+	{
+		name: "default",
+		srcs: map[string]string{
+			"x.c": "int f(int x) { return x; }\n",
+		},
+		want: map[string]string{
+			"go.mod": "module main\n\ngo 1.12\n",
+			"x.go":   "package main\n\nfunc f(x int) int {\n\treturn x\n}\n",
+		},
+	},
+	{
+		// Packages named by full import paths within the module
+		// are written to directories relative to its root,
+		// and only files referring to another package import it.
+		name: "import paths",
+		cfg: `module example.com/m
+package a.[ch] example.com/m/internal/a
+package b.c example.com/m/b
+package c.c example.com/m/b
+`,
+		srcs: map[string]string{
+			"a.h": "int twice(int);\n",
+			"a.c": "#include \"a.h\"\nint twice(int x) { return 2*x; }\n",
+			"b.c": "#include \"a.h\"\nint quad(int x) { return twice(twice(x)); }\n",
+			"c.c": "int one(void) { return 1; }\n",
+		},
+		want: map[string]string{
+			"go.mod":          "module example.com/m\n\ngo 1.12\n",
+			"internal/a/a.go": "package a\n\nfunc Twice(x int) int {\n\treturn 2 * x\n}\n",
+			"b/b.go":          "package b\n\nimport (\n\t\"example.com/m/internal/a\"\n)\n\nfunc quad(x int) int {\n\treturn a.Twice(a.Twice(x))\n}\n",
+			"b/c.go":          "package b\n\nfunc one() int {\n\treturn 1\n}\n",
+		},
+	},
+}
+
+func TestModuleOutput(t *testing.T) {
+	for _, tt := range moduleTests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _ := translate(t, tt.cfg, tt.srcs)
+			for name, want := range tt.want {
+				if got, ok := out[name]; !ok {
+					t.Errorf("missing output %s", name)
+				} else if string(got) != want {
+					t.Errorf("%s:\n%s\nwant:\n%s", name, got, want)
+				}
+			}
+			for name := range out {
+				if _, ok := tt.want[name]; !ok {
+					t.Errorf("unexpected output %s", name)
+				}
+			}
+		})
+	}
+}
@@ -2,12 +2,11 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+package c2go
 
 import (
 	"bytes"
 	"fmt"
-	"os"
 	"path"
 	"strings"
 
@@ -23,12 +22,6 @@ const (
 	Newline
 )
 
-// print an error; fprintf is a bad name but helps go vet.
-func fprintf(span cc.Span, format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	fmt.Fprintf(os.Stderr, "%s:%d: %s\n", span.Start.File, span.Start.Line, msg)
-}
-
 type Printer struct {
 	Package  string
 	buf      bytes.Buffer
@@ -36,8 +29,23 @@ type Printer struct {
 	html     bool
 	lastline int
 
-	printed map[interface{}]bool
-	suffix  []cc.Comment // suffix comments to print at next newline
+	printed   map[interface{}]bool
+	suffix    []cc.Comment      // suffix comments to print at next newline
+	imports   map[string]string // import paths used, mapped to the names they are imported as
+	importMap map[string]string // package names in import directives, mapped to their paths
+	module    string            // path of the module being written
+	model     *cc.Model         // data model for evaluating C constants
+	stringers map[*cc.Type]bool // enums given String methods
+	cfg       *Config           // for reporting diagnostics
+	srcMap    srcMap            // C syntax printed, by offset in buf
+}
+
+// qualifier returns the name qualifying references to declarations
+// in the Go package pkg, and records that the printed code imports it.
+func (p *Printer) qualifier(pkg string) string {
+	imp := importPath(p.module, pkg)
+	p.addImport(imp)
+	return path.Base(imp)
 }
 
 func (p *Printer) dup(x interface{}) bool {
@@ -338,6 +346,7 @@ func (p *Printer) printExpr(x *cc.Expr, prec int) {
 	if x == nil {
 		return
 	}
+	defer p.mark(x, p.buf.Len())
 	if p.html {
 		fmt.Fprintf(&p.buf, "<span title='%s type %v'>", x.Op, x.XType)
 		defer fmt.Fprintf(&p.buf, "</span>")
@@ -461,12 +470,19 @@ func (p *Printer) printExpr(x *cc.Expr, prec int) {
 		p.Print(exprPrec{x.Left, prec}, "[", exprPrec{x.Right, precLow}, "]")
 
 	case cc.Name:
+		if x.XDecl != nil && x.XDecl.Method {
+			// A method expression.
+			p.Print("(", x.XDecl.Type.Decls[0].Type, ").", x.XDecl.Name)
+			break
+		}
 		name := x.Text
 		if x.XDecl != nil {
 			name = x.XDecl.Name
 			if x.XDecl.GoPackage != "" && p.Package != "" && x.XDecl.GoPackage != p.Package {
-				name = path.Base(x.XDecl.GoPackage) + "." + name
+				name = p.qualifier(x.XDecl.GoPackage) + "." + name
 			}
+		} else {
+			name = p.qualified(name)
 		}
 		p.Print(name)
 
@@ -474,7 +490,8 @@ func (p *Printer) printExpr(x *cc.Expr, prec int) {
 		p.Print(strings.TrimRight(x.Text, "LlUu"))
 
 	case cc.SizeofExpr:
-		p.Print("sizeof(", x.Left, ")")
+		p.Print("unsafe.Sizeof(", exprPrec{x.Left, precComma}, ")")
+		p.addImport("unsafe")
 
 	case cc.String:
 		for i, str := range x.Texts {
@@ -485,7 +502,8 @@ func (p *Printer) printExpr(x *cc.Expr, prec int) {
 		}
 
 	case cc.Offsetof:
-		p.Print("offsetof(", x.Type, ", ", exprPrec{x.Left, precComma}, ")")
+		p.Print("unsafe.Offsetof(", exprPrec{zeroFor(x.Type), precAddr}, ".", x.Left, ")")
+		p.addImport("unsafe")
 
 	case cc.Paren:
 		p.Print(exprPrec{x.Left, prec})
@@ -497,7 +515,8 @@ func (p *Printer) printExpr(x *cc.Expr, prec int) {
 		p.Print(exprPrec{x.Left, prec}, "++")
 
 	case cc.SizeofType:
-		p.Print("sizeof(", x.Type, ")")
+		p.Print("unsafe.Sizeof(", zeroFor(x.Type), ")")
+		p.addImport("unsafe")
 
 	case cc.VaArg:
 		p.Print("va_arg(", exprPrec{x.Left, precComma}, ", ", x.Type, ")")
@@ -555,7 +574,7 @@ func (p *Printer) printInit(typ *cc.Type, x *cc.Init) {
 				subtyp = typ.Def().Base
 			} else if !warned {
 				warned = true
-				fprintf(x.Span, "too many fields in braced initializer of %s", GoString(typ))
+				p.cfg.errorf(x.Span, "init", "too many fields in braced initializer of %s", GoString(typ))
 			}
 		}
 		p.printInit(subtyp, y)
@@ -594,6 +613,7 @@ const (
 )
 
 func (p *Printer) printStmt(x *cc.Stmt) {
+	defer p.mark(x, p.buf.Len())
 	if len(x.Labels) > 0 {
 		p.Print(Untab, Unindent, x.Comments.Before, Indent, "\t")
 		for i := 0; i < len(x.Labels); i++ {
@@ -737,6 +757,7 @@ const (
 	Ideal
 	String
 	Slice
+	Interface
 )
 
 func (p *Printer) printType(t *cc.Type) {
@@ -753,6 +774,17 @@ func (p *Printer) printType(t *cc.Type) {
 		p.Print("bool")
 		return
 	}
+	if isNamedEnum(t) {
+		name := t.Name
+		if d := t.TypeDecl; d != nil {
+			name = d.Name
+			if d.GoPackage != "" && p.Package != "" && d.GoPackage != p.Package {
+				name = p.qualifier(d.GoPackage) + "." + name
+			}
+		}
+		p.Print(name)
+		return
+	}
 	if typemap[t.Kind] != "" {
 		p.Print(typemap[t.Kind])
 		return
@@ -773,6 +805,11 @@ func (p *Printer) printType(t *cc.Type) {
 		p.Print("string")
 
 	case cc.Struct:
+		if t.Tag != "" && hasBitFields(t) {
+			// Bit-fields need methods, which need a named type.
+			p.Print(t.Tag)
+			break
+		}
 		if len(t.Decls) == 0 {
 			p.Print("struct{}")
 			break
@@ -781,6 +818,16 @@ func (p *Printer) printType(t *cc.Type) {
 		p.printStructBody(t)
 		p.Print(Unindent, Newline, "}")
 
+	case cc.Union:
+		if t.Tag == "" {
+			p.Print("C.union")
+			break
+		}
+		p.Print(t.Tag)
+
+	case Interface:
+		p.Print(t.Tag)
+
 	case cc.Enum:
 		if t.Tag != "" {
 			p.Print(t.Tag)
@@ -793,8 +840,12 @@ func (p *Printer) printType(t *cc.Type) {
 			p.Print(typemap[t.Base.Kind])
 			return
 		}
+		if strings.Contains(t.Name, ".") {
+			p.Print(p.qualified(t.Name))
+			break
+		}
 		if t.TypeDecl != nil && t.TypeDecl.GoPackage != "" && p.Package != "" && t.TypeDecl.GoPackage != p.Package {
-			p.Print(path.Base(t.TypeDecl.GoPackage) + "." + t.Name)
+			p.Print(p.qualifier(t.TypeDecl.GoPackage) + "." + t.Name)
 			break
 		}
 		p.Print(t.Name)
@@ -893,23 +944,24 @@ func (p *Printer) printDecl(decl *cc.Decl) {
 	if p.dup(decl) {
 		return
 	}
+	defer p.mark(decl, p.buf.Len())
 
 	p.Print(decl.Comments.Before)
 	defer p.Print(decl.Comments.Suffix, decl.Comments.After)
 
 	t := decl.Type
 	if decl.Storage&cc.Typedef != 0 {
-		if t.Kind == cc.Struct || t.Kind == cc.Union {
+		if t.Kind == cc.Enum && t.TypeDecl == decl {
+			p.printEnumDecl(decl)
+			return
+		}
+		if t.Kind == cc.Struct || t.Kind == cc.Union || t.Kind == Interface {
 			if t.Tag == "" {
 				t.Tag = decl.Name
 			} else if decl.Name != t.Tag {
-				fprintf(decl.Span, "typedef %s and tag %s do not match", decl.Name, t.Tag)
-			}
-			if t.Kind == cc.Enum {
-				p.printEnumDecl(t)
-			} else {
-				p.printStructDecl(t)
+				p.cfg.warnf(decl.Span, "typedef-tag", "typedef %s and tag %s do not match", decl.Name, t.Tag)
 			}
+			p.printStructDecl(t)
 			return
 		}
 		p.Print("type ", decl.Name, " ", decl.Type)
@@ -918,19 +970,16 @@ func (p *Printer) printDecl(decl *cc.Decl) {
 
 	if decl.Name == "" {
 		switch t.Kind {
-		case cc.Struct, cc.Union:
+		case cc.Struct, cc.Union, Interface:
 			p.printStructDecl(t)
 			return
 		case cc.Enum:
-			p.printEnumDecl(t)
-			return
-		}
-		if Bool <= t.Kind && t.Kind <= Float64 && t.Decls != nil {
-			// was an enum
-			p.printEnumDecl(t)
+			if t.TypeDecl == nil || t.TypeDecl == decl {
+				p.printEnumDecl(decl)
+			}
 			return
 		}
-		fprintf(decl.Span, "empty declaration of type %s", GoString(t))
+		p.cfg.warnf(decl.Span, "empty-decl", "empty declaration of type %s", GoString(t))
 		return
 	}
 
@@ -939,6 +988,16 @@ func (p *Printer) printDecl(decl *cc.Decl) {
 		return
 	}
 
+	if decl.Macro != nil {
+		p.Print("const ", decl.Name, " = ", decl.Init.Expr)
+		return
+	}
+
+	if decl.Init != nil && len(decl.Init.Braced) > 0 && isInterface(decl.Type) {
+		p.printVtableDecl(decl)
+		return
+	}
+
 	if decl.Init != nil && len(decl.Init.Braced) > 0 {
 		p.Print("var ", decl.Name, " = ", typedInit{decl.Type, decl.Init})
 		return
@@ -955,20 +1014,35 @@ func (p *Printer) printStructDecl(t *cc.Type) {
 		return
 	}
 	if t.Kind == cc.Union {
-		fprintf(t.Span, "cannot convert union")
+		p.printUnionDecl(t)
+		return
+	}
+	if t.Kind == Interface {
+		p.printInterfaceDecl(t)
 		return
 	}
 	p.Print("type ", t.Tag, " struct {", Indent)
 	p.printStructBody(t)
 	p.Print(Unindent, Newline, "}")
+	p.printBitFieldMethods(t)
 }
 
 func (p *Printer) printStructBody(t *cc.Type) {
+	groups := make(map[*cc.Decl]*bitGroup)
+	for _, g := range bitGroups(t, p.model) {
+		groups[g.decls[0]] = g
+	}
 	for _, decl := range t.Decls {
+		if decl.Bits != nil {
+			if g := groups[decl]; g != nil {
+				p.Print(Newline, g.field, " ", fmt.Sprint("uint", g.bits))
+			}
+			continue
+		}
 		if decl.Name == "" {
 			// Hope this is a struct definition.
 			if decl.Type.Kind != cc.Struct {
-				fprintf(decl.Span, "unnamed non-struct field of type %v", decl.Type)
+				p.cfg.errorf(decl.Span, "unnamed-field", "unnamed non-struct field of type %v", decl.Type)
 				continue
 			}
 			p.printStructBody(decl.Type)
@@ -978,34 +1052,139 @@ func (p *Printer) printStructBody(t *cc.Type) {
 	}
 }
 
-func (p *Printer) printEnumDecl(t *cc.Type) {
+// printEnumDecl prints the constants of the enum declared by decl.
+// A named enum's constants have the named type it declares,
+// and runs of consecutive values use iota.
+func (p *Printer) printEnumDecl(decl *cc.Decl) {
+	t := decl.Type
+	if p.dup(t) {
+		return
+	}
 	typeSuffix := ""
-	if t.Tag != "" {
-		typeSuffix = " " + t.Tag
-		p.Print("type ", t.Tag, " int", Newline)
-		//	fprintf(t.Span, "cannot handle enum tags")
-		//	return
+	if t.TypeDecl == decl {
+		typeSuffix = " " + decl.Name
+		p.Print("type ", decl.Name, " ", typemap[p.enumKind(t)], Newline)
 	}
+	values, ok := p.enumValues(t)
 	p.Print("const (", Indent)
-	for i, decl := range t.Decls {
-		p.Print(Newline, decl.Name)
-		if decl.Init == nil && i == 0 {
-			if len(t.Decls) >= 2 && t.Decls[1].Init == nil {
+	run := false // whether the last expression printed uses iota
+	for i, d := range t.Decls {
+		p.Print(Newline, d.Name)
+		// A constant is left implicit if it continues a run of iota values,
+		// as C's implicit values do. So is one whose initializer is
+		// a literal with the value the run gives it anyway.
+		literal := d.Init == nil || d.Init.Expr.Op == cc.Number
+		next := i+1 < len(t.Decls) && t.Decls[i+1].Init == nil
+		switch {
+		case run && d.Init == nil, run && ok && literal && values[i] == values[i-1]+1:
+			// implicit
+		case ok && literal && next:
+			p.Print(typeSuffix, " = iota")
+			if off := values[i] - int64(i); off > 0 {
+				p.Print(fmt.Sprintf(" + %d", off))
+			} else if off < 0 {
+				p.Print(fmt.Sprintf(" - %d", -off))
+			}
+			run = true
+		case d.Init == nil && i == 0:
+			if next {
 				p.Print(typeSuffix, " = iota")
 			} else {
 				p.Print(typeSuffix, " = 0")
 			}
-		} else if decl.Init != nil {
-			p.Print(typeSuffix, " = ", decl.Init.Expr)
-			if i+1 < len(t.Decls) && t.Decls[i+1].Init == nil {
+			run = next
+		case d.Init != nil && ok && !goConstExpr(d.Init.Expr):
+			// Go would evaluate the expression differently.
+			p.Print(typeSuffix, " = ", fmt.Sprint(values[i]))
+			if next {
+				p.Print(fmt.Sprintf(" + iota - %d", i))
+			}
+			run = next
+		case d.Init != nil:
+			p.Print(typeSuffix, " = ", d.Init.Expr)
+			if next {
 				p.Print(" + iota")
 				if i > 0 {
-					p.Print(fmt.Sprintf("-%d", i))
+					p.Print(fmt.Sprintf(" - %d", i))
 				}
 			}
+			run = next
 		}
 	}
 	p.Print(Unindent, Newline, ")")
+	if p.stringers[t] {
+		p.printEnumString(decl, values, ok)
+	}
+}
+
+// goConstExpr reports whether the C constant expression x
+// means the same as a Go constant expression, so that it can be
+// printed as one: it has no comparisons or logical operators,
+// which give Go bools, and no unsigned or floating-point values,
+// which Go keeps exactly.
+func goConstExpr(x *cc.Expr) bool {
+	ok := true
+	cc.Preorder(x, func(x cc.Syntax) {
+		x1, isExpr := x.(*cc.Expr)
+		if !isExpr {
+			return
+		}
+		switch x1.Op {
+		case cc.EqEq, cc.NotEq, cc.Lt, cc.LtEq, cc.Gt, cc.GtEq, cc.AndAnd, cc.OrOr, cc.Not, cc.Cond, cc.Cast:
+			ok = false
+		case cc.Number:
+			if c, err := cc.Eval(x1, nil); err != nil || !c.IsInt() || c.Kind != cc.Int && c.Kind != cc.Long && c.Kind != cc.Longlong {
+				ok = false
+			}
+		}
+	})
+	return ok
+}
+
+// enumKind returns the Go kind of the named enum type t.
+func (p *Printer) enumKind(t *cc.Type) cc.TypeKind {
+	if len(t.Decls) > 0 && t.Decls[0].Type != nil && t.Decls[0].Type.Kind >= Bool {
+		return t.Decls[0].Type.Kind
+	}
+	return Int
+}
+
+// enumValues returns the values of the constants of the enum t,
+// and reports whether all of them could be computed.
+func (p *Printer) enumValues(t *cc.Type) ([]int64, bool) {
+	var values []int64
+	for _, d := range t.Decls {
+		v, ok := cc.EnumValue(d, p.model)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, v)
+	}
+	return values, true
+}
+
+// printEnumString prints a String method for the named enum
+// declared by decl, whose constants have the given values.
+// Constants with the value of an earlier one are left out.
+func (p *Printer) printEnumString(decl *cc.Decl, values []int64, ok bool) {
+	t := decl.Type
+	if !ok {
+		p.cfg.warnf(decl.Span, "stringer", "cannot generate String method for %s: cannot compute its values", decl.Name)
+		return
+	}
+	p.Print(Newline, Newline, "func (x ", decl.Name, ") String() string {", Indent)
+	p.Print(Newline, "switch x {")
+	seen := make(map[int64]bool)
+	for i, d := range t.Decls {
+		if seen[values[i]] {
+			continue
+		}
+		seen[values[i]] = true
+		p.Print(Newline, "case ", d.Name, ":", Indent, Newline, fmt.Sprintf("return %q", d.Name), Unindent)
+	}
+	p.Print(Newline, "}")
+	p.Print(Newline, fmt.Sprintf("return %q + %s(int64(x), 10) + \")\"", decl.Name+"(", p.qualified("strconv.FormatInt")))
+	p.Print(Unindent, Newline, "}")
 }
 
 func (p *Printer) printFuncDecl(decl *cc.Decl) {
@@ -1019,8 +1198,14 @@ func (p *Printer) printFuncDecl(decl *cc.Decl) {
 			p.Print(s.Decl, Newline)
 		}
 	}
-	p.Print("func ", decl.Name, "(")
-	for i, arg := range decl.Type.Decls {
+	args := decl.Type.Decls
+	if decl.Method {
+		p.Print("func (", args[0].Name, " ", args[0].Type, ") ", decl.Name, "(")
+		args = args[1:]
+	} else {
+		p.Print("func ", decl.Name, "(")
+	}
+	for i, arg := range args {
 		if arg.Type.Is(cc.Void) {
 			continue
 		}
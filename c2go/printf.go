@@ -2,7 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+package c2go
 
 import (
 	"bytes"
@@ -13,9 +13,9 @@ import (
 	"github.com/hajimehoshi/cingo/cc"
 )
 
-func tryPrintf(curfn *cc.Decl, x *cc.Expr, name string, fmtpos int, newName string) bool {
+func (cfg *Config) tryPrintf(curfn *cc.Decl, x *cc.Expr, name string, fmtpos int, newName string) bool {
 	if (x.Left.Text == name || (strings.Contains(name, ".") || strings.Contains(name, "->")) && x.Left.String() == name) && len(x.List) >= fmtpos+1 && x.List[fmtpos].Op == cc.String {
-		x.List = append(x.List[:fmtpos+1], fixPrintFormat(curfn, x.List[fmtpos], x.List[fmtpos+1:])...)
+		x.List = append(x.List[:fmtpos+1], cfg.fixPrintFormat(curfn, x.List[fmtpos], x.List[fmtpos+1:])...)
 		if newName != "" {
 			x.Left.Text = newName
 			x.Left.XDecl = nil
@@ -25,11 +25,11 @@ func tryPrintf(curfn *cc.Decl, x *cc.Expr, name string, fmtpos int, newName stri
 	return false
 }
 
-func fixPrintf(curfn *cc.Decl, x *cc.Expr) bool {
+func (cfg *Config) fixPrintf(curfn *cc.Decl, x *cc.Expr) bool {
 	if x.Op != cc.Call {
 		return false
 	}
-	if tryPrintf(curfn, x, "sprint", 1, "fmt.Sprintf") {
+	if cfg.tryPrintf(curfn, x, "sprint", 1, "fmt.Sprintf") {
 		targ := x.List[0]
 		x.List = x.List[1:]
 		x.Right = copyExpr(x)
@@ -47,7 +47,7 @@ func fixPrintf(curfn *cc.Decl, x *cc.Expr) bool {
 		}
 		return true
 	}
-	if tryPrintf(curfn, x, "snprint", 2, "fmt.Sprintf") {
+	if cfg.tryPrintf(curfn, x, "snprint", 2, "fmt.Sprintf") {
 		targ := x.List[0]
 		x.List = x.List[2:]
 		x.Right = copyExpr(x)
@@ -56,7 +56,7 @@ func fixPrintf(curfn *cc.Decl, x *cc.Expr) bool {
 		x.Op = cc.Eq
 		return true
 	}
-	if tryPrintf(curfn, x, "fmtprint", 1, "fmt.Sprintf") {
+	if cfg.tryPrintf(curfn, x, "fmtprint", 1, "fmt.Sprintf") {
 		targ := x.List[0]
 		x.List = x.List[1:]
 		x.Right = copyExpr(x)
@@ -68,52 +68,52 @@ func fixPrintf(curfn *cc.Decl, x *cc.Expr) bool {
 		}
 		return true
 	}
-	if tryPrintf(curfn, x, "smprint", 0, "fmt.Sprintf") {
+	if cfg.tryPrintf(curfn, x, "smprint", 0, "fmt.Sprintf") {
 		x.XType = stringType
 		return true
 	}
-	if tryPrintf(curfn, x, "print", 0, "fmt.Printf") {
+	if cfg.tryPrintf(curfn, x, "print", 0, "fmt.Printf") {
 		return true
 	}
-	if tryPrintf(curfn, x, "fprint", 1, "fmt.Fprintf") {
+	if cfg.tryPrintf(curfn, x, "fprint", 1, "fmt.Fprintf") {
 		if x.List[0].String() == "2" {
 			x.List[0] = &cc.Expr{Op: cc.Name, Text: "os.Stderr"}
 		}
 		return true
 	}
-	if tryPrintf(curfn, x, "sysfatal", 0, "log.Fatalf") {
+	if cfg.tryPrintf(curfn, x, "sysfatal", 0, "log.Fatalf") {
 		return true
 	}
-	if tryPrintf(curfn, x, "fatal", 0, "") {
+	if cfg.tryPrintf(curfn, x, "fatal", 0, "") {
 		return true
 	}
-	if tryPrintf(curfn, x, "ctxt->diag", 0, "") {
+	if cfg.tryPrintf(curfn, x, "ctxt->diag", 0, "") {
 		return true
 	}
-	if tryPrintf(curfn, x, "diag", 0, "") {
+	if cfg.tryPrintf(curfn, x, "diag", 0, "") {
 		return true
 	}
-	if tryPrintf(curfn, x, "werrstr", 0, "") {
+	if cfg.tryPrintf(curfn, x, "werrstr", 0, "") {
 		return true
 	}
-	if tryPrintf(curfn, x, "yyerror", 0, "") {
+	if cfg.tryPrintf(curfn, x, "yyerror", 0, "") {
 		return true
 	}
-	if tryPrintf(curfn, x, "yyerrorl", 1, "") {
+	if cfg.tryPrintf(curfn, x, "yyerrorl", 1, "") {
 		forceConvert(curfn, x.List[0], x.List[0].XType, intType)
 		return true
 	}
-	if tryPrintf(curfn, x, "onearg", 1, "") {
+	if cfg.tryPrintf(curfn, x, "onearg", 1, "") {
 		return true
 	}
-	if tryPrintf(curfn, x, "warn", 0, "") {
+	if cfg.tryPrintf(curfn, x, "warn", 0, "") {
 		return true
 	}
-	if tryPrintf(curfn, x, "warnl", 1, "") {
+	if cfg.tryPrintf(curfn, x, "warnl", 1, "") {
 		forceConvert(curfn, x.List[0], x.List[0].XType, intType)
 		return true
 	}
-	if tryPrintf(curfn, x, "Bprint", 1, "fmt.Fprintf") {
+	if cfg.tryPrintf(curfn, x, "Bprint", 1, "fmt.Fprintf") {
 		return true
 	}
 
@@ -143,9 +143,9 @@ func fixPrintf(curfn *cc.Decl, x *cc.Expr) bool {
 	return false
 }
 
-func fixPrintFormat(curfn *cc.Decl, fx *cc.Expr, args []*cc.Expr) []*cc.Expr {
+func (cfg *Config) fixPrintFormat(curfn *cc.Decl, fx *cc.Expr, args []*cc.Expr) []*cc.Expr {
 	for _, arg := range args {
-		fixGoTypesExpr(curfn, arg, nil)
+		cfg.fixGoTypesExpr(curfn, arg, nil)
 		cc.Preorder(arg, func(x cc.Syntax) {
 			if x, ok := x.(*cc.Expr); ok && x.Op == cc.Name && strings.HasPrefix(x.Text, "bigP") {
 				x.Text = "p"
@@ -161,7 +161,7 @@ func fixPrintFormat(curfn *cc.Decl, fx *cc.Expr, args []*cc.Expr) []*cc.Expr {
 	for j, text := range fx.Texts {
 		format, err := strconv.Unquote(text)
 		if err != nil {
-			fprintf(fx.Span, "cannot parse quoted string: %v", err)
+			cfg.warnf(fx.Span, "printf", "cannot parse quoted string: %v", err)
 			return args
 		}
 
@@ -192,7 +192,7 @@ func fixPrintFormat(curfn *cc.Decl, fx *cc.Expr, args []*cc.Expr) []*cc.Expr {
 				break
 			}
 			if i >= len(format) {
-				fprintf(fx.Span, "print format ends mid-verb")
+				cfg.warnf(fx.Span, "printf", "print format ends mid-verb")
 				return args
 			}
 			flags, verb := format[start:i], format[i]
@@ -219,7 +219,7 @@ func fixPrintFormat(curfn *cc.Decl, fx *cc.Expr, args []*cc.Expr) []*cc.Expr {
 			convert := ""
 			switch verb {
 			default:
-				fprintf(fx.Span, "unrecognized format %s%c", flags, verb)
+				cfg.warnf(fx.Span, "printf", "unrecognized format %s%c", flags, verb)
 				buf.WriteString("%")
 				buf.WriteString(flags)
 				buf.WriteString(string(verb))
@@ -263,7 +263,7 @@ func fixPrintFormat(curfn *cc.Decl, fx *cc.Expr, args []*cc.Expr) []*cc.Expr {
 
 			case 'A': // asm opcode
 				if allFlags != "%" {
-					fprintf(fx.Span, "format %s%c", allFlags, verb)
+					cfg.warnf(fx.Span, "printf", "format %s%c", allFlags, verb)
 				}
 				buf.WriteString("%v")
 				if narg < len(args) {
@@ -285,7 +285,7 @@ func fixPrintFormat(curfn *cc.Decl, fx *cc.Expr, args []*cc.Expr) []*cc.Expr {
 
 			case 'L':
 				if allFlags != "%" {
-					fprintf(fx.Span, "format %s%c", allFlags, verb)
+					cfg.warnf(fx.Span, "printf", "format %s%c", allFlags, verb)
 				}
 				buf.WriteString("%v")
 				if narg >= len(args) {
@@ -310,21 +310,21 @@ func fixPrintFormat(curfn *cc.Decl, fx *cc.Expr, args []*cc.Expr) []*cc.Expr {
 
 			case '@':
 				if allFlags != "%" {
-					fprintf(fx.Span, "format %s%c", allFlags, verb)
+					cfg.warnf(fx.Span, "printf", "format %s%c", allFlags, verb)
 				}
 				buf.WriteString("%v")
 				convert = "RAconv" + suffix
 
 			case '^':
 				if allFlags != "%" {
-					fprintf(fx.Span, "format %s%c", allFlags, verb)
+					cfg.warnf(fx.Span, "printf", "format %s%c", allFlags, verb)
 				}
 				buf.WriteString("%v")
 				convert = "DRconv" + suffix
 
 			case 'D':
 				if allFlags != "%" && allFlags != "%l" {
-					fprintf(fx.Span, "format %s%c", allFlags, verb)
+					cfg.warnf(fx.Span, "printf", "format %s%c", allFlags, verb)
 				}
 				buf.WriteString("%v")
 				if narg >= len(args) {
@@ -355,14 +355,14 @@ func fixPrintFormat(curfn *cc.Decl, fx *cc.Expr, args []*cc.Expr) []*cc.Expr {
 
 			case 'M':
 				if allFlags != "%" {
-					fprintf(fx.Span, "format %s%c", allFlags, verb)
+					cfg.warnf(fx.Span, "printf", "format %s%c", allFlags, verb)
 				}
 				buf.WriteString("%v")
 				convert = "Mconv" + suffix
 
 			case 'R':
 				if allFlags != "%" {
-					fprintf(fx.Span, "format %s%c", allFlags, verb)
+					cfg.warnf(fx.Span, "printf", "format %s%c", allFlags, verb)
 				}
 				buf.WriteString("%v")
 				forceConvert(curfn, args[narg], args[narg].XType, intType)
@@ -376,13 +376,13 @@ func fixPrintFormat(curfn *cc.Decl, fx *cc.Expr, args []*cc.Expr) []*cc.Expr {
 
 			case '$':
 				if allFlags != "%" {
-					fprintf(fx.Span, "format %s%c", allFlags, verb)
+					cfg.warnf(fx.Span, "printf", "format %s%c", allFlags, verb)
 				}
 				buf.WriteString("%q")
 
 			case 'P':
 				if allFlags != "%" {
-					fprintf(fx.Span, "format %s%c", allFlags, verb)
+					cfg.warnf(fx.Span, "printf", "format %s%c", allFlags, verb)
 				}
 				buf.WriteString("%v")
 
@@ -433,7 +433,7 @@ func fixPrintFormat(curfn *cc.Decl, fx *cc.Expr, args []*cc.Expr) []*cc.Expr {
 					f = strings.Replace(f, "u", "", 1)
 				}
 				if f != "%" {
-					fprintf(fx.Span, "format %s%c", allFlags, verb)
+					cfg.warnf(fx.Span, "printf", "format %s%c", allFlags, verb)
 				}
 				buf.WriteString("%v")
 				if narg >= len(args) {
@@ -479,7 +479,7 @@ func fixPrintFormat(curfn *cc.Decl, fx *cc.Expr, args []*cc.Expr) []*cc.Expr {
 	return args
 }
 
-func fixFormatter(fn *cc.Decl) {
+func (cfg *Config) fixFormatter(fn *cc.Decl) {
 	// Find va_arg assignment.
 	var arg *cc.Expr
 	//var argType *cc.Type
@@ -508,7 +508,7 @@ func fixFormatter(fn *cc.Decl) {
 				return
 			}
 			if arg != nil {
-				fprintf(fn.Span, "multiple va_arg in formatter")
+				cfg.warnf(fn.Span, "printf", "multiple va_arg in formatter")
 			}
 			arg = expr.Left
 			//argType = expr.Right.Type
@@ -523,7 +523,7 @@ func fixFormatter(fn *cc.Decl) {
 		fn.Type.Decls[0] = arg.XDecl
 	} else {
 		if len(fn.Type.Decls) == 1 {
-			fprintf(fn.Span, "missing va_arg in formatter")
+			cfg.warnf(fn.Span, "printf", "missing va_arg in formatter")
 			return
 		}
 		fn.Type.Decls = fn.Type.Decls[1:]
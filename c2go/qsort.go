@@ -2,29 +2,29 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+package c2go
 
 import (
 	"github.com/hajimehoshi/cingo/cc"
 )
 
-func fixQsort(prog *cc.Prog, x *cc.Expr) {
+func (cfg *Config) fixQsort(prog *cc.Prog, x *cc.Expr) {
 	if len(x.List) != 4 {
-		fprintf(x.Span, "unsupported %v - wrong arg count", x)
+		cfg.warnf(x.Span, "qsort", "unsupported %v - wrong arg count", x)
 		return
 	}
 	if x.List[2].Op != cc.SizeofExpr || unparen(x.List[2].Left).String() != unparen(x.List[0]).String()+"[0]" {
-		fprintf(x.Span, "unsupported %v - wrong elem size %v vs %v", x, unparen(x.List[2].Left).String(), unparen(x.List[0]).String()+"[0]")
+		cfg.warnf(x.Span, "qsort", "unsupported %v - wrong elem size %v vs %v", x, unparen(x.List[2].Left).String(), unparen(x.List[0]).String()+"[0]")
 		return
 	}
 	if x.List[3].Op != cc.Name || x.List[3].XDecl == nil {
-		fprintf(x.Span, "unsupported %v - unknown comparison function", x)
+		cfg.warnf(x.Span, "qsort", "unsupported %v - unknown comparison function", x)
 		return
 	}
 	cmp := x.List[3].XDecl.Name
 	decl := x.List[3].XDecl
 
-	typ, newDecl := fixQsortCmp(decl)
+	typ, newDecl := cfg.fixQsortCmp(decl)
 	if typ == nil {
 		return
 	}
@@ -125,10 +125,10 @@ func isGoVoidPtr(t *cc.Type) bool {
 	return t.Kind == cc.Void || t.Kind == cc.Struct && len(t.Decls) == 0
 }
 
-func fixQsortCmp(decl *cc.Decl) (*cc.Type, *cc.Decl) {
+func (cfg *Config) fixQsortCmp(decl *cc.Decl) (*cc.Type, *cc.Decl) {
 	ftyp := decl.Type
 	if ftyp.Kind != cc.Func || len(ftyp.Decls) != 2 || !isEmptyInterface(ftyp.Decls[0].Type) || !isEmptyInterface(ftyp.Decls[1].Type) {
-		fprintf(decl.Span, "invalid qsort cmp function %v - wrong args", GoString(ftyp))
+		cfg.warnf(decl.Span, "qsort", "invalid qsort cmp function %v - wrong args", GoString(ftyp))
 		return nil, nil
 	}
 
@@ -162,23 +162,23 @@ func fixQsortCmp(decl *cc.Decl) (*cc.Type, *cc.Decl) {
 	})
 
 	if p1 == nil || p2 == nil {
-		fprintf(decl.Span, "invalid qsort cmp function - cannot find arg extraction")
+		cfg.warnf(decl.Span, "qsort", "invalid qsort cmp function - cannot find arg extraction")
 		return nil, nil
 	}
 
 	if !sameType(p1.XType, p2.XType) {
-		fprintf(decl.Span, "invalid qsort cmp function - different arg types %v and %v", GoString(p1.XType), GoString(p2.XType))
+		cfg.warnf(decl.Span, "qsort", "invalid qsort cmp function - different arg types %v and %v", GoString(p1.XType), GoString(p2.XType))
 		return nil, nil
 	}
 	if indir1 != indir2 {
-		fprintf(decl.Span, "invalid qsort cmp function - different arg indirection")
+		cfg.warnf(decl.Span, "qsort", "invalid qsort cmp function - different arg indirection")
 		return nil, nil
 	}
 
 	typ := p1.XType
 	if !indir1 {
 		if typ.Def().Kind != cc.Ptr {
-			fprintf(decl.Span, "invalid qsort cmp function - arg ptr cast to non-ptr %v", GoString(typ))
+			cfg.warnf(decl.Span, "qsort", "invalid qsort cmp function - arg ptr cast to non-ptr %v", GoString(typ))
 			return nil, nil
 		}
 		typ = typ.Def().Base
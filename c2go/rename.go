@@ -2,7 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+package c2go
 
 import (
 	"fmt"
@@ -67,8 +67,10 @@ func renameDecls(cfg *Config, prog *cc.Prog) {
 	})
 
 	// Assign to packages (needed below but also in writeGoFiles).
+	// A function goes with the file holding its body; when that is
+	// the file it starts in, its span keeps the line of its header.
 	for _, d := range prog.Decls {
-		if d.Body != nil && d.Body.Span.Start.File != "" {
+		if d.Body != nil && d.Body.Span.Start.File != "" && d.Body.Span.Start.File != d.Span.Start.File {
 			d.Span = d.Body.Span
 		}
 		d.GoPackage = cfg.filePackage(d.Span.Start.File)
@@ -90,7 +92,7 @@ func renameDecls(cfg *Config, prog *cc.Prog) {
 				continue
 			}
 			switch d.Type.Kind {
-			case cc.Struct:
+			case cc.Struct, Interface:
 				if d.Type.Tag != "" {
 					decls = append(decls, d)
 					d.Name = d.Type.Tag
@@ -100,7 +102,12 @@ func renameDecls(cfg *Config, prog *cc.Prog) {
 					d.Type.TypeDecl = d
 				}
 			case cc.Enum:
-				d.Type.Tag = "" // enum tags are worthless
+				if d.Type.TypeDecl == d {
+					// A named enum declares a type named by its tag.
+					decls = append(decls, d)
+					d.Name = d.Type.Tag
+					d.Storage = cc.Typedef
+				}
 				for _, dd := range d.Type.Decls {
 					decls = append(decls, dd)
 				}
@@ -108,6 +115,11 @@ func renameDecls(cfg *Config, prog *cc.Prog) {
 			continue
 		}
 		decls = append(decls, d)
+		if d.Storage&cc.Typedef != 0 && d.Type != nil && d.Type.Kind == cc.Enum {
+			for _, dd := range d.Type.Decls {
+				decls = append(decls, dd)
+			}
+		}
 		if d.Storage&cc.Typedef != 0 && d.Type != nil && d.Type.TypeDecl == nil {
 			d.Type.TypeDecl = d
 		}
@@ -118,10 +130,14 @@ func renameDecls(cfg *Config, prog *cc.Prog) {
 	src := make(map[string]string)
 	for _, d := range decls {
 		// TODO(rsc): I don't understand why this is necessary given the above.
-		if d.Body != nil && d.Body.Span.Start.File != "" {
+		if d.Body != nil && d.Body.Span.Start.File != "" && d.Body.Span.Start.File != d.Span.Start.File {
 			d.Span = d.Body.Span
 		}
 		d.GoPackage = cfg.filePackage(d.Span.Start.File)
+		if d.Method {
+			// Methods are named within their receiver type.
+			continue
+		}
 		key := d.GoPackage + "." + d.Name
 		if count[key]++; count[key] > 1 {
 			if d.Span.String() == src[key] {
@@ -129,7 +145,7 @@ func renameDecls(cfg *Config, prog *cc.Prog) {
 				count[key] = 1
 				continue
 			}
-			fprintf(d.Span, "conflicting name %s in %s (last at %s)", d.Name, d.GoPackage, src[key])
+			cfg.errorf(d.Span, "name-conflict", "conflicting name %s in %s (last at %s)", d.Name, d.GoPackage, src[key])
 			continue
 		}
 		src[key] = fmt.Sprintf("%s:%d", d.Span.Start.File, d.Span.Start.Line)
@@ -138,7 +154,7 @@ func renameDecls(cfg *Config, prog *cc.Prog) {
 	// Rename static, conflicting names.
 	for _, d := range decls {
 		key := d.GoPackage + "." + d.Name
-		if count[key] > 1 {
+		if count[key] > 1 && !d.Method {
 			file := filepath.Base(d.Span.Start.File)
 			if i := strings.Index(file, "."); i >= 0 {
 				file = file[:i]
@@ -153,7 +169,11 @@ func renameDecls(cfg *Config, prog *cc.Prog) {
 						// Add function name as prefix.
 						// Will print at top level.
 						dd := s.Decl
-						dd.Name = d.Name + "_" + dd.Name
+						if d.Method {
+							dd.Name = recvName(d) + "_" + d.Name + "_" + dd.Name
+						} else {
+							dd.Name = d.Name + "_" + dd.Name
+						}
 					}
 				}
 			}
@@ -0,0 +1,115 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go
+
+import (
+	"strconv"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// computeSizes records the C values of the sizeof and offsetof
+// expressions that can be folded into Go constants.
+// It must run before rewriteTypes, while the C types are still available.
+//
+// The size of a scalar, or of an array of scalars, does not depend
+// on how the C types are translated, so it can be folded.
+// Sizes and offsets involving structs and unions are left for the printer,
+// which uses unsafe.Sizeof and unsafe.Offsetof so that they match
+// the layout of the Go types. The exception is offsets of union members,
+// which are always zero.
+func computeSizes(cfg *Config, prog *cc.Prog) {
+	cfg.sizes = make(map[*cc.Expr]int64)
+	m := cfg.dataModel()
+	cc.Preorder(prog, func(x cc.Syntax) {
+		x1, ok := x.(*cc.Expr)
+		if !ok {
+			return
+		}
+		switch x1.Op {
+		case cc.SizeofType:
+			if !isScalar(x1.Type) {
+				return
+			}
+		case cc.SizeofExpr:
+			if !isScalar(x1.Left.XType) {
+				return
+			}
+		case cc.Offsetof:
+			if t := x1.Type.Def(); t == nil || t.Kind != cc.Union {
+				return
+			}
+		default:
+			return
+		}
+		if n, ok := cc.ConstInt(x1, m); ok {
+			cfg.sizes[x1] = n
+		}
+	})
+}
+
+// foldSizes replaces the sizeof and offsetof expressions recorded
+// by computeSizes with their values.
+// It runs after fixGoTypes, which recognizes sizeof in calls
+// like memset and malloc and rewrites them into Go idioms.
+// fixGoTypes gives the recorded expressions untyped Go types,
+// so that they are not converted, and leaves them in place:
+// a copy would not be found in cfg.sizes and would be printed
+// as unsafe.Sizeof of the Go type, which need not have the C size.
+// Explicit C conversions of the folded values are dropped too.
+func foldSizes(cfg *Config, prog *cc.Prog) {
+	folded := make(map[*cc.Expr]bool)
+	cc.Postorder(prog, func(x cc.Syntax) {
+		x1, ok := x.(*cc.Expr)
+		if !ok {
+			return
+		}
+		switch x1.Op {
+		case cc.SizeofType, cc.SizeofExpr, cc.Offsetof:
+			n, ok := cfg.sizes[x1]
+			if !ok {
+				return
+			}
+			*x1 = cc.Expr{
+				SyntaxInfo: x1.SyntaxInfo,
+				Op:         cc.Number,
+				Text:       strconv.FormatInt(n, 10),
+				XType:      x1.XType,
+			}
+			folded[x1] = true
+
+		case cc.Paren:
+			folded[x1] = folded[x1.Left]
+
+		case cc.Add, cc.Sub, cc.Mul, cc.Div:
+			l, r := unparen(x1.Left), unparen(x1.Right)
+			folded[x1] = (folded[l] || l.Op == cc.Number) && (folded[r] || r.Op == cc.Number) && (folded[l] || folded[r])
+
+		case cc.Cast:
+			if y := unparen(x1.Left); folded[y] {
+				typ := x1.Type
+				*x1 = *y
+				x1.XType = typ
+				folded[x1] = true
+			}
+		}
+	})
+}
+
+// isScalar reports whether the C type t is a scalar type
+// or an array of them.
+func isScalar(t *cc.Type) bool {
+	t = t.Def()
+	if t == nil {
+		return false
+	}
+	switch t.Kind {
+	case cc.Array:
+		return isScalar(t.Base)
+	case cc.Char, cc.Uchar, cc.Short, cc.Ushort, cc.Int, cc.Uint, cc.Long, cc.Ulong, cc.Longlong, cc.Ulonglong, cc.Float, cc.Double, cc.Enum, cc.Ptr:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,335 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go
+
+import (
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// inferSlices decides which C pointer declarations become Go slices
+// rather than pointers.
+//
+// A pointer becomes a slice if it is indexed, used in pointer arithmetic
+// or ordered comparisons, assigned an array or an allocation of
+// several elements, or passed to memmove and friends as bytes.
+// Since Go cannot mix the two, the decision spreads across
+// assignments, initializers, call arguments and return values:
+// everything that exchanges values with a slice is a slice too.
+// The "slice" and "ptr" config directives override the inference.
+//
+// It must run once the parameters and locals know their functions
+// (CurFn), so that declKey tells them apart, and before the C types
+// are translated.
+func inferSlices(cfg *Config, prog *cc.Prog) {
+	s := &sliceInference{
+		cfg:     cfg,
+		parent:  make(map[*cc.Decl]*cc.Decl),
+		slice:   make(map[*cc.Decl]bool),
+		why:     make(map[*cc.Decl]evidence),
+		direct:  make(map[*cc.Decl]bool),
+		deref:   make(map[*cc.Decl]cc.Span),
+		params:  make(map[string][]*cc.Decl),
+		results: make(map[string]*cc.Decl),
+	}
+
+	// Prototypes and definitions of a function share parameters.
+	for _, d := range prog.Decls {
+		if d.Type == nil || d.Type.Kind != cc.Func {
+			continue
+		}
+		if old, ok := s.params[d.Name]; ok {
+			for i, p := range d.Type.Decls {
+				if i < len(old) {
+					s.union(old[i], p)
+				}
+			}
+			continue
+		}
+		s.params[d.Name] = d.Type.Decls
+	}
+
+	for _, d := range prog.Decls {
+		var fn *cc.Decl
+		if d.Type != nil && d.Type.Kind == cc.Func {
+			fn = d
+		}
+		cc.Preorder(d, func(x cc.Syntax) {
+			switch x := x.(type) {
+			case *cc.Decl:
+				if isDataPtr(x.Type) {
+					if cfg.slice[declKey(x)] {
+						s.mark(x, x.Span, "configured")
+					}
+					if x.Init != nil && x.Init.Expr != nil {
+						s.flow(x, x.Init.Expr)
+					}
+				}
+			case *cc.Stmt:
+				if x.Op == cc.Return && x.Expr != nil && fn != nil {
+					s.flow(s.result(fn.Name, fn.Type), x.Expr)
+				}
+			case *cc.Expr:
+				s.expr(x)
+			}
+		})
+	}
+
+	cfg.slices = make(map[*cc.Decl]bool)
+	cfg.sliceResults = make(map[string]bool)
+	ptr := make(map[*cc.Decl]bool) // classes forced to stay pointers
+	for d := range s.parent {
+		if cfg.ptr[declKey(d)] {
+			ptr[s.find(d)] = true
+		}
+	}
+	for d := range s.parent {
+		r := s.find(d)
+		if !s.slice[r] || ptr[r] || cfg.ptr[declKey(d)] {
+			continue
+		}
+		if d.Name == "return" {
+			cfg.sliceResults[d.CurFn.Name] = true
+		} else {
+			cfg.slices[d] = true
+		}
+		k := declKey(d)
+		if cfg.slice[k] {
+			continue
+		}
+		if span, ok := s.deref[d]; ok && !s.direct[d] {
+			// Maybe a pointer to a single element that meets slices.
+			cfg.suggest(span, "ptr "+k, "%s dereferenced, but a slice because %s", k, s.why[r].why)
+			continue
+		}
+		cfg.suggest(s.why[r].span, "slice "+k, "%s", s.why[r].why)
+	}
+}
+
+type sliceInference struct {
+	cfg     *Config
+	parent  map[*cc.Decl]*cc.Decl // union-find forest of decls exchanging values
+	slice   map[*cc.Decl]bool     // roots of classes that must be slices
+	why     map[*cc.Decl]evidence // why each of those roots must be a slice
+	direct  map[*cc.Decl]bool     // decls with evidence of their own
+	deref   map[*cc.Decl]cc.Span  // decls dereferenced as single objects
+	params  map[string][]*cc.Decl // parameters of each function
+	results map[string]*cc.Decl   // pseudo-decls of function results
+}
+
+func (s *sliceInference) find(d *cc.Decl) *cc.Decl {
+	p, ok := s.parent[d]
+	if !ok {
+		s.parent[d] = d
+		return d
+	}
+	if p == d {
+		return d
+	}
+	r := s.find(p)
+	s.parent[d] = r
+	return r
+}
+
+func (s *sliceInference) union(d1, d2 *cc.Decl) {
+	if d1 == nil || d2 == nil || !isDataPtr(d1.Type) || !isDataPtr(d2.Type) {
+		return
+	}
+	r1, r2 := s.find(d1), s.find(d2)
+	if r1 == r2 {
+		return
+	}
+	s.parent[r2] = r1
+	if !s.slice[r1] && s.slice[r2] {
+		s.slice[r1] = true
+		s.why[r1] = s.why[r2]
+	}
+}
+
+// mark records that d must be a slice, because of what happens at span.
+func (s *sliceInference) mark(d *cc.Decl, span cc.Span, why string) {
+	if d == nil || !isDataPtr(d.Type) {
+		return
+	}
+	s.direct[d] = true
+	r := s.find(d)
+	if !s.slice[r] {
+		s.slice[r] = true
+		s.why[r] = evidence{span, declKey(d) + " " + why}
+	}
+}
+
+// result returns the pseudo-decl standing for the result
+// of the function name of type t, as toGoType sees it.
+func (s *sliceInference) result(name string, t *cc.Type) *cc.Decl {
+	d := s.results[name]
+	if d == nil {
+		d = &cc.Decl{Name: "return", Type: t.Base, CurFn: &cc.Decl{Name: name}}
+		s.results[name] = d
+	}
+	return d
+}
+
+// expr records what the expression x says about the pointers it uses.
+func (s *sliceInference) expr(x *cc.Expr) {
+	switch x.Op {
+	case cc.Arrow, cc.Indir:
+		if d := s.root(x.Left); d != nil {
+			if _, ok := s.deref[d]; !ok {
+				s.deref[d] = x.Span
+			}
+		}
+
+	case cc.Index:
+		s.mark(s.root(x.Left), x.Span, "indexed")
+
+	case cc.Add, cc.Sub:
+		l, r := isDataPtr(x.Left.XType), isDataPtr(x.Right.XType)
+		if l {
+			s.mark(s.root(x.Left), x.Span, "used in pointer arithmetic")
+		}
+		if r {
+			s.mark(s.root(x.Right), x.Span, "used in pointer arithmetic")
+		}
+
+	case cc.AddEq, cc.SubEq, cc.PreInc, cc.PreDec, cc.PostInc, cc.PostDec:
+		if isDataPtr(x.Left.XType) {
+			s.mark(s.root(x.Left), x.Span, "used in pointer arithmetic")
+		}
+
+	case cc.Lt, cc.LtEq, cc.Gt, cc.GtEq:
+		if isDataPtr(x.Left.XType) && isDataPtr(x.Right.XType) {
+			s.mark(s.root(x.Left), x.Span, "compared as a pointer")
+			s.mark(s.root(x.Right), x.Span, "compared as a pointer")
+		}
+
+	case cc.EqEq, cc.NotEq:
+		s.union(s.root(x.Left), s.root(x.Right))
+
+	case cc.Cond:
+		s.union(s.root(x.List[1]), s.root(x.List[2]))
+
+	case cc.Eq:
+		if isDataPtr(x.Left.XType) {
+			s.flow(s.root(x.Left), x.Right)
+		}
+
+	case cc.Call:
+		if x.Left.Op != cc.Name {
+			break
+		}
+		switch x.Left.Text {
+		case "memmove", "memcpy", "memcmp":
+			// Byte copies and comparisons work on slices.
+			for _, arg := range x.List {
+				if t := unparen(arg).XType; isDataPtr(t) && t.Def().Base.Def().Size(s.cfg.dataModel()) == 1 {
+					s.mark(s.root(arg), x.Span, "passed to "+x.Left.Text)
+				}
+			}
+		}
+		params := s.params[x.Left.Text]
+		for i, arg := range x.List {
+			if i < len(params) && params[i].Name != "..." {
+				s.flow(params[i], arg)
+			}
+		}
+	}
+}
+
+// flow records that the value of x is stored in d.
+func (s *sliceInference) flow(d *cc.Decl, x *cc.Expr) {
+	if d == nil || !isDataPtr(d.Type) {
+		return
+	}
+	x = unparen(x)
+	if t := x.XType.Def(); t != nil && t.Kind == cc.Array {
+		s.mark(d, x.Span, "assigned an array")
+		return
+	}
+	if isSliceAlloc(x) {
+		s.mark(d, x.Span, "assigned an allocation of several elements")
+		return
+	}
+	s.union(d, s.root(x))
+}
+
+// root returns the decl holding the pointer value of x, if any.
+func (s *sliceInference) root(x *cc.Expr) *cc.Decl {
+	if x == nil {
+		return nil
+	}
+	switch x.Op {
+	case cc.Paren, cc.Cast, cc.Eq, cc.AddEq, cc.SubEq, cc.PreInc, cc.PreDec, cc.PostInc, cc.PostDec:
+		return s.root(x.Left)
+	case cc.Name, cc.Dot, cc.Arrow:
+		if x.XDecl != nil && isDataPtr(x.XDecl.Type) {
+			return x.XDecl
+		}
+	case cc.Add, cc.Sub:
+		if isDataPtr(x.Left.XType) {
+			return s.root(x.Left)
+		}
+		if isDataPtr(x.Right.XType) {
+			return s.root(x.Right)
+		}
+	case cc.Cond:
+		if d := s.root(x.List[1]); d != nil {
+			return d
+		}
+		return s.root(x.List[2])
+	case cc.Call:
+		if x.Left.Op == cc.Name && x.Left.XDecl != nil {
+			if f := x.Left.XDecl.Type.Def(); f != nil && f.Kind == cc.Func {
+				return s.result(x.Left.Text, f)
+			}
+		}
+	}
+	return nil
+}
+
+// isSliceAlloc reports whether x allocates an array of several elements,
+// which fixGoTypes turns into a make of a slice.
+func isSliceAlloc(x *cc.Expr) bool {
+	if x.Op == cc.Cast {
+		return isSliceAlloc(unparen(x.Left))
+	}
+	if x.Op != cc.Call || x.Left.Op != cc.Name || len(x.List) != 1 {
+		return false
+	}
+	switch x.Left.Text {
+	case "mal", "malloc", "emallocz", "xmalloc":
+		siz := unparen(x.List[0])
+		return siz.Op != cc.SizeofType && siz.Op != cc.SizeofExpr
+	}
+	return false
+}
+
+// isDataPtr reports whether the C type t is a pointer to data
+// that could be translated as either a Go pointer or a slice.
+// Strings, void pointers and func pointers have translations of their own.
+func isDataPtr(t *cc.Type) bool {
+	t = t.Def()
+	if t == nil || t.Kind != cc.Ptr || t.Base == nil {
+		return false
+	}
+	switch t.Base.Def().Kind {
+	case cc.Func, cc.Void, cc.Char:
+		return false
+	}
+	return true
+}
+
+// isSlice reports whether the pointer decl d becomes a slice.
+func (cfg *Config) isSlice(d *cc.Decl) bool {
+	if cfg.ptr[declKey(d)] {
+		return false
+	}
+	if cfg.slice[declKey(d)] {
+		return true
+	}
+	if d.Name == "return" && d.CurFn != nil {
+		return cfg.sliceResults[d.CurFn.Name]
+	}
+	return cfg.slices[d]
+}
@@ -0,0 +1,69 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go_test
+
+import (
+	"strings"
+	"testing"
+)
+
+var sliceTests = []struct {
+	name string
+	cfg  string
+	src  string
+	want []string // lines of the output
+}{
+	{
+		name: "deref",
+		src:  "void inc(int *x) { *x = *x + 1; }\n",
+		want: []string{"func inc(x *int) {"},
+	},
+	{
+		name: "arithmetic",
+		src:  "int second(int *p) { return *(p+1); }\n",
+		want: []string{"func second(p []int) int {", "\treturn (p[1:])[0]"},
+	},
+	{
+		name: "compare",
+		src:  "int before(int *a, int *b) { return a < b; }\n",
+		want: []string{"func before(a []int, b []int) bool {"},
+	},
+	{
+		// The result of vp is an array, so r and the
+		// parameter of get that r is passed to are slices.
+		name: "flow",
+		src: `
+int vals[8];
+int *vp(void) { return vals; }
+int get(int *q) { return *q; }
+int use(void) { int *r; r = vp(); return get(r); }
+`,
+		want: []string{
+			"func vp() []int {",
+			"func get(q []int) int {",
+			"\tvar r []int",
+		},
+	},
+	{
+		name: "configured",
+		cfg:  "slice get.q\n",
+		src:  "int get(int *q) { return *q; }\n",
+		want: []string{"func get(q []int) int {", "\treturn q[0]"},
+	},
+}
+
+func TestSlices(t *testing.T) {
+	for _, tt := range sliceTests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _ := translate(t, tt.cfg, map[string]string{"x.c": tt.src})
+			lines := strings.Split(string(out["x.go"]), "\n")
+			for _, want := range tt.want {
+				if !contains(lines, want) {
+					t.Errorf("missing line %q in:\n%s", want, out["x.go"])
+				}
+			}
+		})
+	}
+}
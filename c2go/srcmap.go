@@ -0,0 +1,312 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"sort"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// A srcRange says that the bytes [start, end) of a Go file
+// were printed for the C syntax at span.
+type srcRange struct {
+	start, end int
+	span       cc.Span
+	stmt       bool // a statement or declaration rather than an expression
+}
+
+// A srcMap maps the bytes of a Go file back to the C code they translate.
+// Ranges nest as the syntax printed for them does.
+type srcMap []srcRange
+
+// mark records that the bytes printed since offset start are for x.
+// Syntax made up by the rewrites has no span and is not recorded,
+// so that its bytes map to the syntax enclosing it.
+func (p *Printer) mark(x spanner, start int) {
+	span := x.GetSpan()
+	if end := p.buf.Len(); end > start && span.Start.File != "" && span.Start.Line > 0 {
+		_, expr := x.(*cc.Expr)
+		p.srcMap = append(p.srcMap, srcRange{start, end, span, !expr})
+	}
+}
+
+// lookup returns the span of the innermost range containing offset off.
+func (m srcMap) lookup(off int) (cc.Span, bool) {
+	best := -1
+	for i, r := range m {
+		if r.start <= off && off < r.end && (best < 0 || r.end-r.start < m[best].end-m[best].start) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return cc.Span{}, false
+	}
+	return m[best].span, true
+}
+
+// insert updates m for n bytes inserted at offset off.
+func (m srcMap) insert(off, n int) {
+	for i := range m {
+		r := &m[i]
+		if r.start >= off {
+			r.start += n
+		}
+		if r.end > off {
+			r.end += n
+		}
+	}
+}
+
+// replace replaces the non-overlapping instances of old in buf with new,
+// updating m to match, and returns the result and the number of instances.
+// Ranges starting or ending inside an instance are moved to its start.
+func (m srcMap) replace(buf, old, new []byte) ([]byte, int) {
+	if len(old) == 0 {
+		return buf, 0
+	}
+	var at []int
+	for off := 0; ; {
+		i := bytes.Index(buf[off:], old)
+		if i < 0 {
+			break
+		}
+		at = append(at, off+i)
+		off += i + len(old)
+	}
+	if len(at) == 0 {
+		return buf, 0
+	}
+	delta := len(new) - len(old)
+	adjust := func(off int) int {
+		// k instances end at or before off.
+		k := sort.Search(len(at), func(i int) bool { return at[i]+len(old) > off })
+		if k < len(at) && at[k] < off {
+			return at[k] + k*delta
+		}
+		return off + k*delta
+	}
+	for i := range m {
+		m[i].start = adjust(m[i].start)
+		m[i].end = adjust(m[i].end)
+	}
+	return bytes.Replace(buf, old, new, -1), len(at)
+}
+
+// A goToken is a token of a Go file.
+type goToken struct {
+	off  int
+	text string
+}
+
+// goTokens returns the tokens of the Go source src,
+// leaving out comments and automatically inserted semicolons.
+func goTokens(src []byte) []goToken {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, 0)
+	var toks []goToken
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		text := lit
+		if text == "" {
+			text = tok.String()
+		}
+		toks = append(toks, goToken{file.Offset(pos), text})
+	}
+	return toks
+}
+
+// reformat returns m, a map of the Go source src, updated to match
+// the source formatted, which has the tokens of src except for
+// semicolons and parentheses that gofmt drops.
+func (m srcMap) reformat(src, formatted []byte) srcMap {
+	before, after := goTokens(src), goTokens(formatted)
+	match := make([]int, len(before)) // index in after of each token, or -1
+	j := 0
+	for i := 0; i < len(before); i++ {
+		match[i] = -1
+		for j < len(after) && after[j].text == ";" && before[i].text != ";" {
+			j++
+		}
+		if j >= len(after) {
+			continue
+		}
+		switch t := before[i].text; {
+		case t == after[j].text:
+			match[i] = j
+			j++
+		case t == ";" || t == "(" || t == ")":
+			// Dropped by gofmt.
+		default:
+			j++
+		}
+	}
+
+	var out srcMap
+	for _, r := range m {
+		lo := sort.Search(len(before), func(i int) bool { return before[i].off >= r.start })
+		hi := sort.Search(len(before), func(i int) bool { return before[i].off >= r.end })
+		for lo < hi && match[lo] < 0 {
+			lo++
+		}
+		for lo < hi && match[hi-1] < 0 {
+			hi--
+		}
+		if lo == hi {
+			continue
+		}
+		first, last := after[match[lo]], after[match[hi-1]]
+		out = append(out, srcRange{first.off, last.off + len(last.text), r.span, r.stmt})
+	}
+	return out
+}
+
+// lineDirectives returns src, the Go file named file, with //line
+// directives giving the C position of each line starting a statement
+// or declaration, and of lines of Go code translating no C code,
+// their position in file. A directive is left out where the position
+// is the one the line would have anyway.
+// It returns m updated to match.
+func (m srcMap) lineDirectives(file string, src []byte) ([]byte, srcMap) {
+	starts := make(map[int]bool) // offsets of tokens
+	for _, t := range goTokens(src) {
+		starts[t.off] = true
+	}
+	stmts := make(map[int]srcRange) // innermost statement starting at each offset
+	for _, r := range m {
+		if old, ok := stmts[r.start]; r.stmt && (!ok || r.end-r.start < old.end-old.start) {
+			stmts[r.start] = r
+		}
+	}
+	byStart := append(srcMap(nil), m...)
+	sort.Slice(byStart, func(i, j int) bool { return byStart[i].start < byStart[j].start })
+
+	var out bytes.Buffer
+	var inserts []int // offsets in src of the directives, in order
+	curFile, curLine := file, 1
+	next, maxEnd := 0, 0 // ranges starting before a line, and their furthest end
+	for off, line := 0, 1; off < len(src); line++ {
+		end := bytes.IndexByte(src[off:], '\n') + 1
+		if end == 0 {
+			end = len(src) - off
+		}
+		text := src[off : off+end]
+		tok := off + len(text) - len(bytes.TrimLeft(text, " \t"))
+		for next < len(byStart) && byStart[next].start <= tok {
+			if byStart[next].end > maxEnd {
+				maxEnd = byStart[next].end
+			}
+			next++
+		}
+		if starts[tok] {
+			wantFile, wantLine := curFile, curLine
+			if r, ok := stmts[tok]; ok {
+				wantFile, wantLine = r.span.Start.File, r.span.Start.Line
+			} else if maxEnd <= tok {
+				// Code made up by the translation.
+				wantFile, wantLine = file, line+len(inserts)
+				if wantFile != curFile || wantLine != curLine {
+					// The directive itself takes a line.
+					wantLine++
+				}
+			}
+			if wantFile != curFile || wantLine != curLine {
+				fmt.Fprintf(&out, "//line %s:%d\n", wantFile, wantLine)
+				inserts = append(inserts, off)
+				curFile, curLine = wantFile, wantLine
+			}
+		}
+		out.Write(text)
+		curLine++
+		off += end
+	}
+
+	// Move the ranges past the directives inserted before them.
+	buf := out.Bytes()
+	added := 0
+	for _, off := range inserts {
+		n := bytes.IndexByte(buf[off+added:], '\n') + 1
+		m.insert(off+added, n)
+		added += n
+	}
+	return buf, m
+}
+
+// A sourceMap is the JSON form of the map of a Go file
+// written with the lines option: the lines of the Go file
+// translating each C statement and declaration.
+type sourceMap struct {
+	File   string        `json:"file"`
+	Ranges []sourceRange `json:"ranges"`
+}
+
+type sourceRange struct {
+	GoStart int    `json:"goStart"` // first line in the Go file
+	GoEnd   int    `json:"goEnd"`   // last line in the Go file
+	File    string `json:"file"`    // C file
+	Start   int    `json:"start"`   // first line in the C file
+	End     int    `json:"end"`     // last line in the C file
+}
+
+// encode returns the JSON source map for m, the map of src,
+// the Go file named file.
+func (m srcMap) encode(file string, src []byte) []byte {
+	lines := []int{0} // offsets of the lines
+	for i, c := range src {
+		if c == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	lineOf := func(off int) int {
+		return sort.Search(len(lines), func(i int) bool { return lines[i] > off })
+	}
+
+	sm := sourceMap{File: file, Ranges: []sourceRange{}}
+	seen := make(map[sourceRange]bool)
+	for _, r := range m {
+		if !r.stmt {
+			continue
+		}
+		sr := sourceRange{
+			GoStart: lineOf(r.start),
+			GoEnd:   lineOf(r.end - 1),
+			File:    r.span.Start.File,
+			Start:   r.span.Start.Line,
+			End:     r.span.End.Line,
+		}
+		if sr.End < sr.Start {
+			sr.End = sr.Start
+		}
+		if !seen[sr] {
+			seen[sr] = true
+			sm.Ranges = append(sm.Ranges, sr)
+		}
+	}
+	sort.SliceStable(sm.Ranges, func(i, j int) bool {
+		ri, rj := sm.Ranges[i], sm.Ranges[j]
+		if ri.GoStart != rj.GoStart {
+			return ri.GoStart < rj.GoStart
+		}
+		if ri.GoEnd != rj.GoEnd {
+			return ri.GoEnd > rj.GoEnd
+		}
+		return ri.Start < rj.Start
+	})
+	data, _ := json.MarshalIndent(sm, "", "\t")
+	return append(data, '\n')
+}
@@ -0,0 +1,253 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// An evidence is the reason an analysis gives for a decision.
+type evidence struct {
+	span cc.Span
+	why  string
+}
+
+// A suggestion is a config line proposed by an analysis.
+type suggestion struct {
+	line string
+	evidence
+}
+
+// suggest records the config line proposed because of what happens at span,
+// for the -suggest flag.
+func (cfg *Config) suggest(span cc.Span, line, format string, args ...interface{}) {
+	cfg.suggestions = append(cfg.suggestions, suggestion{line, evidence{span, fmt.Sprintf(format, args...)}})
+}
+
+// goPredeclared lists the Go predeclared identifiers
+// that top-level C names would shadow in the translation.
+var goPredeclared = map[string]bool{
+	"append":  true,
+	"bool":    true,
+	"byte":    true,
+	"cap":     true,
+	"close":   true,
+	"complex": true,
+	"copy":    true,
+	"delete":  true,
+	"error":   true,
+	"float32": true,
+	"float64": true,
+	"imag":    true,
+	"int":     true,
+	"int16":   true,
+	"int32":   true,
+	"int64":   true,
+	"int8":    true,
+	"len":     true,
+	"make":    true,
+	"new":     true,
+	"nil":     true,
+	"panic":   true,
+	"print":   true,
+	"println": true,
+	"real":    true,
+	"recover": true,
+	"string":  true,
+	"uint":    true,
+	"uint16":  true,
+	"uint32":  true,
+	"uint64":  true,
+	"uint8":   true,
+	"uintptr": true,
+}
+
+// suggestVerbs orders the sections of a suggested config.
+var suggestVerbs = []string{"package", "slice", "ptr", "bool", "export", "rename"}
+
+// writeSuggestions writes to w a draft config for translating prog,
+// read from files, with each line preceded by a comment giving its evidence.
+// Besides the lines proposed by the analyses, which must have run,
+// it proposes package rules from the directory layout,
+// exports of names used across packages and renames of names
+// that would shadow Go builtins.
+// Lines already in effect in cfg are not repeated.
+func writeSuggestions(w io.Writer, cfg *Config, prog *cc.Prog, files []string) {
+	list := append([]suggestion(nil), cfg.suggestions...)
+
+	rules := cfg.pkgRules
+	if len(rules) == 0 {
+		var pkgs []suggestion
+		rules, pkgs = suggestPackages(prog, files)
+		list = append(list, pkgs...)
+	}
+	list = append(list, suggestExports(cfg, prog, &Config{pkgRules: rules})...)
+
+	for _, d := range prog.Decls {
+		if d.Name != "" && goPredeclared[d.Name] && cfg.rename[declKey(d)] == "" {
+			list = append(list, suggestion{
+				"rename " + declKey(d) + " " + d.Name + "_",
+				evidence{d.Span, d.Name + " would shadow the Go builtin"},
+			})
+		}
+	}
+
+	// Slice lines naming a length supersede those without.
+	grouped := make(map[string]bool)
+	for _, s := range list {
+		if f := strings.Fields(s.line); f[0] == "slice" && len(f) > 2 {
+			grouped[f[1]] = true
+		}
+	}
+
+	bySection := make(map[string][]suggestion)
+	seen := make(map[string]bool)
+	for _, s := range list {
+		f := strings.Fields(s.line)
+		if seen[s.line] || f[0] == "slice" && len(f) == 2 && grouped[f[1]] {
+			continue
+		}
+		seen[s.line] = true
+		bySection[f[0]] = append(bySection[f[0]], s)
+	}
+
+	fmt.Fprintf(w, "# Draft config suggested by c2go -suggest.\n")
+	fmt.Fprintf(w, "# Each line follows the evidence for it; review before use.\n")
+	for _, verb := range suggestVerbs {
+		section := bySection[verb]
+		if len(section) == 0 {
+			continue
+		}
+		sort.Slice(section, func(i, j int) bool {
+			return section[i].line < section[j].line
+		})
+		fmt.Fprintf(w, "\n")
+		for _, s := range section {
+			fmt.Fprintf(w, "# %s: %s\n%s\n", s.span, s.why, s.line)
+		}
+	}
+}
+
+// suggestPackages proposes a package for each directory holding the
+// files of prog, named by its path below the directories' common prefix.
+// Files in the current directory, or all files if they share one directory,
+// go to package main.
+func suggestPackages(prog *cc.Prog, files []string) ([]pkgRule, []suggestion) {
+	byDir := make(map[string][]string)
+	add := func(file string) {
+		if file == "" || filepath.IsAbs(file) {
+			return
+		}
+		file = filepath.ToSlash(file)
+		dir := filepath.ToSlash(filepath.Dir(file))
+		for _, f := range byDir[dir] {
+			if f == file {
+				return
+			}
+		}
+		byDir[dir] = append(byDir[dir], file)
+	}
+	for _, file := range files {
+		add(file)
+	}
+	for _, d := range prog.Decls {
+		add(d.Span.Start.File)
+	}
+	if len(byDir) < 2 {
+		return nil, nil
+	}
+
+	var dirs []string
+	for dir := range byDir {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	prefix := dirs[0] + "/"
+	for _, dir := range dirs[1:] {
+		for !strings.HasPrefix(dir+"/", prefix) {
+			i := strings.LastIndex(prefix[:len(prefix)-1], "/")
+			prefix = prefix[:i+1]
+		}
+	}
+
+	var rules []pkgRule
+	var list []suggestion
+	for _, dir := range dirs {
+		pattern := dir + "/*"
+		pkg := strings.TrimSuffix(strings.TrimPrefix(dir+"/", prefix), "/")
+		if dir == "." {
+			pattern = "*"
+			pkg = ""
+		}
+		if pkg == "" {
+			pkg = "main"
+		}
+		rules = append(rules, pkgRule{pattern, pkg})
+		dirFiles := byDir[dir]
+		list = append(list, suggestion{
+			"package " + pattern + " " + pkg,
+			evidence{cc.Span{Start: cc.Pos{File: dirFiles[0], Line: 1}}, "files " + strings.Join(dirFiles, " ")},
+		})
+	}
+	return rules, list
+}
+
+// suggestExports proposes exporting the unexported top-level names
+// used from another package, with packages assigned as by pkgs.
+func suggestExports(cfg *Config, prog *cc.Prog, pkgs *Config) []suggestion {
+	top := make(map[*cc.Decl]bool)
+	for _, d := range prog.Decls {
+		top[d] = true
+		if d.Type != nil && d.Type.Kind == cc.Enum {
+			for _, c := range d.Type.Decls {
+				top[c] = true
+			}
+		}
+	}
+	pkgOf := func(d *cc.Decl) string {
+		span := d.Span
+		if d.Body != nil && d.Body.Span.Start.File != "" {
+			span = d.Body.Span
+		}
+		return pkgs.filePackage(span.Start.File)
+	}
+
+	var list []suggestion
+	done := make(map[string]bool)
+	use := func(d *cc.Decl, pkg string, span cc.Span) {
+		if d == nil || !top[d] || d.Name == "" || done[d.Name] || shouldExport(cfg, d.Name) || exportName(d.Name) == d.Name {
+			return
+		}
+		if other := pkgOf(d); other != pkg {
+			done[d.Name] = true
+			list = append(list, suggestion{
+				"export " + d.Name,
+				evidence{span, fmt.Sprintf("%s from %s used in %s", d.Name, other, pkg)},
+			})
+		}
+	}
+	for _, d := range prog.Decls {
+		pkg := pkgOf(d)
+		cc.Preorder(d, func(x cc.Syntax) {
+			switch x := x.(type) {
+			case *cc.Expr:
+				if x.Op == cc.Name {
+					use(x.XDecl, pkg, x.Span)
+				}
+			case *cc.Type:
+				if x.Kind == cc.TypedefType {
+					use(x.TypeDecl, pkg, d.Span)
+				}
+			}
+		})
+	}
+	return list
+}
@@ -0,0 +1,70 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	. "github.com/hajimehoshi/cingo/c2go"
+)
+
+// Each directory in testdata/suggest is a test case.
+// Its C files, and the headers they include, are analyzed
+// as configured by its c2go.cfg file, if any,
+// and the config suggested must match its want.cfg file.
+func TestSuggest(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join("testdata", "suggest", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range dirs {
+		dir := dir
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			testSuggest(t, dir)
+		})
+	}
+}
+
+func testSuggest(t *testing.T, dir string) {
+	cfg := new(Config)
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "c2go.cfg")); err == nil {
+		var diags []*Diagnostic
+		cfg, diags = ParseConfig("c2go.cfg", data)
+		for _, d := range diags {
+			t.Errorf("%v", d)
+		}
+	}
+	inputs, err := readInputs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, diags := Suggest(cfg, inputs)
+	for _, d := range diags {
+		if d.Severity == Error {
+			t.Errorf("%v", d)
+		}
+	}
+	if out == nil {
+		return
+	}
+
+	want := filepath.Join(dir, "want.cfg")
+	if *update {
+		if err := ioutil.WriteFile(want, out, 0666); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	golden, err := ioutil.ReadFile(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, golden) {
+		t.Errorf("suggested config differs from want.cfg\nhave:\n%s\nwant:\n%s", out, golden)
+	}
+}
@@ -2,7 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+package c2go
 
 import (
 	"fmt"
@@ -10,15 +10,13 @@ import (
 	"github.com/hajimehoshi/cingo/cc"
 )
 
-var numRewrite int
-
 // Rewrite from C constructs to Go constructs.
 func rewriteSyntax(cfg *Config, prog *cc.Prog) {
-	numRewrite++
+	cfg.numRewrite++
 	cc.Preorder(prog, func(x cc.Syntax) {
 		switch x := x.(type) {
 		case *cc.Stmt:
-			rewriteStmt(x)
+			cfg.rewriteStmt(x)
 
 		case *cc.Expr:
 			switch x.Op {
@@ -150,7 +148,7 @@ func filterBlock(x []*cc.Stmt) []*cc.Stmt {
 	return all
 }
 
-func rewriteStmt(stmt *cc.Stmt) {
+func (cfg *Config) rewriteStmt(stmt *cc.Stmt) {
 	// TODO: Double-check stmt.Labels
 
 	switch stmt.Op {
@@ -178,8 +176,8 @@ func rewriteStmt(stmt *cc.Stmt) {
 		fallthrough
 
 	case cc.For:
-		before1, _ := extractSideEffects(stmt.Pre, sideStmt|sideNoAfter)
-		before2, _ := extractSideEffects(stmt.Expr, sideNoAfter)
+		before1, _ := cfg.extractSideEffects(stmt.Pre, sideStmt|sideNoAfter)
+		before2, _ := cfg.extractSideEffects(stmt.Expr, sideNoAfter)
 		if len(before2) > 0 {
 			x := stmt.Expr
 			stmt.Expr = nil
@@ -200,7 +198,7 @@ func rewriteStmt(stmt *cc.Stmt) {
 			stmt.Op = BlockNoBrace
 			stmt.Block = append(before1, old)
 		}
-		before, after := extractSideEffects(stmt.Post, sideStmt)
+		before, after := cfg.extractSideEffects(stmt.Post, sideStmt)
 		if len(before)+len(after) > 0 {
 			all := append(append(before, &cc.Stmt{Op: cc.StmtExpr, Expr: stmt.Post}), after...)
 			stmt.Post = &cc.Expr{Op: ExprBlock, Block: all}
@@ -208,9 +206,9 @@ func rewriteStmt(stmt *cc.Stmt) {
 
 	case cc.If, cc.Return:
 		if stmt.Op == cc.If && stmt.Else == nil {
-			fixAndAndAssign(stmt)
+			cfg.fixAndAndAssign(stmt)
 		}
-		before, _ := extractSideEffects(stmt.Expr, sideNoAfter)
+		before, _ := cfg.extractSideEffects(stmt.Expr, sideNoAfter)
 		if len(before) > 0 {
 			old := copyStmt(stmt)
 			stmt.Expr = nil
@@ -221,7 +219,7 @@ func rewriteStmt(stmt *cc.Stmt) {
 		}
 
 	case cc.StmtExpr:
-		before, after := extractSideEffects(stmt.Expr, sideStmt)
+		before, after := cfg.extractSideEffects(stmt.Expr, sideStmt)
 		if len(before)+len(after) > 0 {
 			old := copyStmt(stmt)
 			stmt.Expr = nil
@@ -236,7 +234,7 @@ func rewriteStmt(stmt *cc.Stmt) {
 
 	case cc.Switch:
 		// TODO: Change default fallthrough to default break.
-		before, _ := extractSideEffects(stmt.Expr, sideNoAfter)
+		before, _ := cfg.extractSideEffects(stmt.Expr, sideNoAfter)
 		if len(before) > 0 {
 			old := copyStmt(stmt)
 			stmt.Expr = nil
@@ -246,16 +244,16 @@ func rewriteStmt(stmt *cc.Stmt) {
 			stmt.Block = append(before, old)
 			break // recursion will rewrite new inner switch
 		}
-		rewriteSwitch(stmt)
+		cfg.rewriteSwitch(stmt)
 	}
 }
 
 // fixAndAndAssign rewrites if(x && (y = z) ...) ...  to if(x) { y = z; if(...) ... }
-func fixAndAndAssign(stmt *cc.Stmt) {
+func (cfg *Config) fixAndAndAssign(stmt *cc.Stmt) {
 	changed := false
 	clauses := splitExpr(stmt.Expr, cc.AndAnd)
 	for i := len(clauses) - 1; i > 0; i-- {
-		before, _ := extractSideEffects(clauses[i], sideNoAfter)
+		before, _ := cfg.extractSideEffects(clauses[i], sideNoAfter)
 		if len(before) == 0 {
 			continue
 		}
@@ -302,8 +300,8 @@ func joinExpr(list []*cc.Expr, op cc.ExprOp) *cc.Expr {
 	return x
 }
 
-func rewriteSwitch(swt *cc.Stmt) {
-	if numRewrite != 1 {
+func (cfg *Config) rewriteSwitch(swt *cc.Stmt) {
+	if cfg.numRewrite != 1 {
 		return
 	}
 	var out []*cc.Stmt
@@ -377,22 +375,18 @@ const (
 	sideNoAfter
 )
 
-func extractSideEffects(x *cc.Expr, mode int) (before, after []*cc.Stmt) {
-	doSideEffects(x, &before, &after, mode)
-	return
+// newTmp returns the number of a new temporary variable.
+func (cfg *Config) newTmp() int {
+	cfg.tmpGen++
+	return cfg.tmpGen
 }
 
-var tmpGen = make(chan int)
-
-func init() {
-	go func() {
-		for i := 1; ; i++ {
-			tmpGen <- i
-		}
-	}()
+func (cfg *Config) extractSideEffects(x *cc.Expr, mode int) (before, after []*cc.Stmt) {
+	cfg.doSideEffects(x, &before, &after, mode)
+	return
 }
 
-func doSideEffects(x *cc.Expr, before, after *[]*cc.Stmt, mode int) {
+func (cfg *Config) doSideEffects(x *cc.Expr, before, after *[]*cc.Stmt, mode int) {
 	if x == nil {
 		return
 	}
@@ -402,13 +396,13 @@ func doSideEffects(x *cc.Expr, before, after *[]*cc.Stmt, mode int) {
 	// For now, detect but do not handle.
 	switch x.Op {
 	case cc.Cond:
-		doSideEffects(x.List[0], before, after, mode&^sideStmt|sideNoAfter)
-		checkNoSideEffects(x.List[1], 0)
-		checkNoSideEffects(x.List[2], 0)
+		cfg.doSideEffects(x.List[0], before, after, mode&^sideStmt|sideNoAfter)
+		cfg.checkNoSideEffects(x.List[1], 0)
+		cfg.checkNoSideEffects(x.List[2], 0)
 
 	case cc.AndAnd, cc.OrOr:
-		doSideEffects(x.Left, before, after, mode&^sideStmt|sideNoAfter)
-		checkNoSideEffects(x.Right, 0)
+		cfg.doSideEffects(x.Left, before, after, mode&^sideStmt|sideNoAfter)
+		cfg.checkNoSideEffects(x.Right, 0)
 
 	case cc.Comma:
 		var leftover []*cc.Expr
@@ -417,7 +411,7 @@ func doSideEffects(x *cc.Expr, before, after *[]*cc.Stmt, mode int) {
 			if i+1 < len(x.List) {
 				m |= sideStmt
 			}
-			doSideEffects(y, before, after, m)
+			cfg.doSideEffects(y, before, after, m)
 			switch y.Op {
 			case cc.PostInc, cc.PostDec, cc.Eq, cc.AddEq, cc.SubEq, cc.MulEq, cc.DivEq, cc.ModEq, cc.XorEq, cc.OrEq, cc.AndEq, cc.LshEq, cc.RshEq:
 				*before = append(*before, &cc.Stmt{Op: cc.StmtExpr, Expr: y})
@@ -428,10 +422,10 @@ func doSideEffects(x *cc.Expr, before, after *[]*cc.Stmt, mode int) {
 		x.List = leftover
 
 	default:
-		doSideEffects(x.Left, before, after, mode&^sideStmt)
-		doSideEffects(x.Right, before, after, mode&^sideStmt)
+		cfg.doSideEffects(x.Left, before, after, mode&^sideStmt)
+		cfg.doSideEffects(x.Right, before, after, mode&^sideStmt)
 		for _, y := range x.List {
-			doSideEffects(y, before, after, mode&^sideStmt)
+			cfg.doSideEffects(y, before, after, mode&^sideStmt)
 		}
 	}
 
@@ -478,7 +472,7 @@ func doSideEffects(x *cc.Expr, before, after *[]*cc.Stmt, mode int) {
 		if mode&sideNoAfter != 0 {
 			// Not allowed to generate fixups afterward.
 			d := &cc.Decl{
-				Name: fmt.Sprintf("tmp%d", <-tmpGen),
+				Name: fmt.Sprintf("tmp%d", cfg.newTmp()),
 				Type: x.Left.XType,
 			}
 			eq := &cc.Expr{
@@ -582,12 +576,12 @@ func fixMerge(dst, src *cc.Expr) {
 	dst.SyntaxInfo = syn
 }
 
-func checkNoSideEffects(x *cc.Expr, mode int) {
+func (cfg *Config) checkNoSideEffects(x *cc.Expr, mode int) {
 	var before, after []*cc.Stmt
 	old := x.String()
-	doSideEffects(x, &before, &after, mode)
+	cfg.doSideEffects(x, &before, &after, mode)
 	if len(before)+len(after) > 0 {
-		fprintf(x.Span, "cannot handle side effects in %s", old)
+		cfg.errorf(x.Span, "side-effect", "cannot handle side effects in %s", old)
 	}
 }
 
@@ -0,0 +1,27 @@
+void *malloc(unsigned long);
+typedef struct Img Img;
+struct Img {
+	int *pix;
+	int w;
+	int h;
+};
+void iinit(Img *m, int w, int h) {
+	m->w = w;
+	m->h = h;
+	m->pix = malloc(m->w * m->h * sizeof(int));
+}
+int at(Img *m, int x, int y) {
+	return m->pix[y*m->w + x];
+}
+typedef struct Arr Arr;
+struct Arr {
+	int *a;
+	int n;
+};
+void ainit(Arr *r, int n) {
+	r->n = n;
+	r->a = malloc(r->n * sizeof(int));
+}
+int get(Arr *r, int i) {
+	return r->a[i];
+}
@@ -0,0 +1,31 @@
+package main
+
+type Img struct {
+	pix []int
+	w   int
+	h   int
+}
+
+func iinit(m *Img, w int, h int) {
+	m.w = w
+	m.h = h
+	m.pix = make([]int, m.w*m.h)
+}
+
+func at(m *Img, x int, y int) int {
+	return m.pix[y*m.w+x]
+}
+
+type Arr struct {
+	a []int
+	n int
+}
+
+func ainit(r *Arr, n int) {
+	r.n = n
+	r.a = make([]int, r.n)
+}
+
+func get(r *Arr, i int) int {
+	return r.a[i]
+}
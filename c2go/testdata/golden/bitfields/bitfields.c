@@ -0,0 +1,14 @@
+struct Flags {
+	unsigned int ready : 1;
+	unsigned int mode : 3;
+	unsigned int level : 4;
+	int count;
+};
+
+int check(struct Flags *f)
+{
+	f->ready = 1;
+	f->mode = 5;
+	f->level = f->mode + 2;
+	return f->ready + f->mode + f->level + f->count;
+}
@@ -0,0 +1,37 @@
+package main
+
+type Flags struct {
+	bits0 uint8
+	count int
+}
+
+func (x *Flags) ready() uint {
+	return uint(x.bits0 & 0x1)
+}
+
+func (x *Flags) setReady(v uint) {
+	x.bits0 = x.bits0&^0x1 | uint8(v)&0x1
+}
+
+func (x *Flags) mode() uint {
+	return uint(x.bits0 >> 1 & 0x7)
+}
+
+func (x *Flags) setMode(v uint) {
+	x.bits0 = x.bits0&^0xe | uint8(v)<<1&0xe
+}
+
+func (x *Flags) level() uint {
+	return uint(x.bits0 >> 4 & 0xf)
+}
+
+func (x *Flags) setLevel(v uint) {
+	x.bits0 = x.bits0&^0xf0 | uint8(v)<<4&0xf0
+}
+
+func check(f *Flags) int {
+	f.setReady(1)
+	f.setMode(5)
+	f.setLevel(f.mode() + 2)
+	return int(f.ready() + f.mode() + f.level() + uint(f.count))
+}
@@ -0,0 +1,39 @@
+int nzero;
+void report(int n) { nzero += n; }
+typedef struct Opt Opt;
+struct Opt {
+	int verbose;
+	int count;
+	int done;
+};
+int isneg(int x) { return x < 0; }
+int haszero(int *a, int n) {
+	int i, found;
+	found = 0;
+	for(i = 0; i < n; i++)
+		if(a[i] == 0)
+			found = 1;
+	return found;
+}
+int small;
+void run(Opt *o, int *a, int n) {
+	int neg = isneg(n);
+	small = n < 10;
+	o->done = 0;
+	o->count++;
+	if(neg || !small)
+		o->verbose = 1;
+	if(o->verbose && haszero(a, n))
+		report(o->count);
+	while(!o->done)
+		o->done = o->count > 3;
+}
+int start(void) {
+	Opt o;
+	int a[3];
+	int flag = 0;
+	run(&o, a, 3);
+	if(flag)
+		return 1;
+	return 0;
+}
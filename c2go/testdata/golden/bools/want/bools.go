@@ -0,0 +1,58 @@
+package main
+
+var nzero int
+
+func report(n int) {
+	nzero += n
+}
+
+type Opt struct {
+	verbose bool
+	count   int
+	done    bool
+}
+
+func isneg(x int) bool {
+	return x < 0
+}
+
+func haszero(a []int, n int) bool {
+	var i int
+	var found bool
+	found = false
+	for i = 0; i < n; i++ {
+		if a[i] == 0 {
+			found = true
+		}
+	}
+	return found
+}
+
+var small bool
+
+func run(o *Opt, a []int, n int) {
+	var neg bool = isneg(n)
+	small = n < 10
+	o.done = false
+	o.count++
+	if neg || !small {
+		o.verbose = true
+	}
+	if o.verbose && haszero(a, n) {
+		report(o.count)
+	}
+	for !o.done {
+		o.done = o.count > 3
+	}
+}
+
+func start() int {
+	var o Opt
+	var a [3]int
+	var flag bool = false
+	run(&o, a[:], 3)
+	if flag {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,5 @@
+enum { N = 1 << 3, M = N * 2 + (int)sizeof(long), K = 'a', U = -1u > 0 };
+int a[] = {1, 2, 3};
+int b[] = {[4] = 1, [1] = 2};
+int n = sizeof a / sizeof a[0];
+int f(int i) { return a[i] + b[i]; }
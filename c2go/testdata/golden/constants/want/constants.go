@@ -0,0 +1,18 @@
+package main
+
+const (
+	N = 1 << 3
+	M = N*2 + 8
+	K = 'a'
+	U = 1
+)
+
+var a = []int{1, 2, 3}
+
+var b = []int{4: 1, 1: 2}
+
+var n int = 12 / 4
+
+func f(i int) int {
+	return a[i] + b[i]
+}
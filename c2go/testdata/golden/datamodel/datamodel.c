@@ -0,0 +1,19 @@
+long l;
+unsigned long ul;
+long long ll;
+int *p;
+
+int lsize(void)
+{
+	return sizeof(long);
+}
+
+int psize(void)
+{
+	return sizeof p;
+}
+
+long add(long a, int b)
+{
+	return a + b;
+}
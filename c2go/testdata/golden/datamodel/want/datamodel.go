@@ -0,0 +1,21 @@
+package main
+
+var l int32
+
+var ul uint32
+
+var ll int64
+
+var p *int
+
+func lsize() int {
+	return 4
+}
+
+func psize() int {
+	return 4
+}
+
+func add(a int32, b int) int32 {
+	return a + int32(b)
+}
@@ -0,0 +1,16 @@
+enum Color { Red, Green = 5, Blue, Black = Green + 10 };
+typedef enum { Small, Medium, Large } Size;
+enum { Anon1 = 1, Anon2 = 2 };
+typedef enum Dir { North, East, South, West } Dir;
+enum Color paint(enum Color c, Size s, Dir d) {
+	int x = c + 1;
+	enum Color k = Blue;
+	if (c == Red && s == Large)
+		return Black;
+	if (d == West)
+		k = x;
+	switch (c) {
+	case Green: return Red;
+	}
+	return k + Anon1;
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strconv"
+)
+
+type Color int
+
+const (
+	Red   Color = 0
+	Green Color = iota + 4
+	Blue
+	Black Color = Green + 10
+)
+
+func (x Color) String() string {
+	switch x {
+	case Red:
+		return "Red"
+	case Green:
+		return "Green"
+	case Blue:
+		return "Blue"
+	case Black:
+		return "Black"
+	}
+	return "Color(" + strconv.FormatInt(int64(x), 10) + ")"
+}
+
+type Size int
+
+const (
+	Small Size = iota
+	Medium
+	Large
+)
+
+const (
+	Anon1 = 1
+	Anon2 = 2
+)
+
+type Dir int
+
+const (
+	North Dir = iota
+	East
+	South
+	West
+)
+
+func (x Dir) String() string {
+	switch x {
+	case North:
+		return "North"
+	case East:
+		return "East"
+	case South:
+		return "South"
+	case West:
+		return "West"
+	}
+	return "Dir(" + strconv.FormatInt(int64(x), 10) + ")"
+}
+
+func paint(c Color, s Size, d Dir) Color {
+	var x int = int(c + 1)
+	var k Color = Blue
+	if c == Red && s == Large {
+		return Black
+	}
+	if d == West {
+		k = Color(x)
+	}
+	switch c {
+	case Green:
+		return Red
+	}
+
+	return k + Anon1
+}
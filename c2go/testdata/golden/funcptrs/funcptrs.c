@@ -0,0 +1,29 @@
+typedef int (*cmp)(void*, void*);
+struct ops {
+	int (*get)(int);
+	void (*put)(int, int);
+};
+int get1(int x) { return x + 1; }
+void put1(int x, int y) { }
+int (*table[2])(int) = { get1, get1 };
+struct ops defops = { get1, put1 };
+static int compare(void *a, void *b) { return 0; }
+cmp thecmp = compare;
+typedef void (*handler)(int);
+handler onexit;
+int *last;
+void reset(void) { onexit = (handler)0; thecmp = (cmp)0; last = (int*)0; }
+int call(struct ops *o, int (*fp)(int), cmp c) {
+	int (*g)(int);
+	g = &get1;
+	if (fp == 0)
+		fp = get1;
+	if (o->get != 0)
+		o->put(1, 2);
+	(*o->put)(3, 4);
+	if (!c)
+		return 0;
+	c(0, 0);
+	(*c)(0, 0);
+	return (*fp)(1) + g(2) + table[0](3) + (*table[1])(4) + o->get(5);
+}
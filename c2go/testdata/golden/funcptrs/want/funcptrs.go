@@ -0,0 +1,61 @@
+package main
+
+type cmp func(interface{}, interface{}) int
+
+type ops struct {
+	get func(int) int
+	put func(int, int)
+}
+
+func get1(x int) int {
+	return x + 1
+}
+
+func put1(x int, y int) {
+}
+
+var table = [2]func(int) int{get1, get1}
+
+var defops = struct {
+	get func(int) int
+	put func(int, int)
+}{get1, put1}
+
+func compare(a interface{}, b interface{}) int {
+	return 0
+}
+
+var thecmp cmp = compare
+
+type handler func(int)
+
+var onexit handler
+
+var last *int
+
+func reset() {
+	onexit = nil
+	thecmp = nil
+	last = nil
+}
+
+func call(o *struct {
+	get func(int) int
+	put func(int, int)
+}, fp func(int) int, c cmp) int {
+	var g func(int) int
+	g = get1
+	if fp == nil {
+		fp = get1
+	}
+	if o.get != nil {
+		o.put(1, 2)
+	}
+	(o.put)(3, 4)
+	if c == nil {
+		return 0
+	}
+	c(0, 0)
+	c(0, 0)
+	return fp(1) + g(2) + table[0](3) + (table[1])(4) + o.get(5)
+}
@@ -0,0 +1,12 @@
+int print(char *fmt, ...);
+int trim(char *s);
+int words(char *s);
+
+int count(char *s)
+{
+	int n;
+
+	n = words(s) + trim(s);
+	print("%s: %d\n", s, n);
+	return n;
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	str "strings"
+	"unicode/utf8"
+)
+
+func trim(s string) int {
+	return len(str.TrimSpace(s))
+}
+
+func words(s string) int {
+	return len(str.Fields(s)) + utf8.RuneCountInString(s)
+}
+
+func count(s string) int {
+	var n int
+
+	n = words(s) + trim(s)
+	fmt.Printf("%s: %d\n", s, n)
+	return n
+}
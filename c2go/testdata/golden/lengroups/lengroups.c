@@ -0,0 +1,40 @@
+void *malloc(unsigned long);
+typedef struct Buf Buf;
+struct Buf {
+	int *p;
+	int np;
+	int maxp;
+};
+typedef struct Vec Vec;
+struct Vec {
+	double *v;
+	int n;
+};
+void push(Buf *b, int x) {
+	if(b->np < b->maxp)
+		b->p[b->np++] = x;
+}
+void binit(Buf *b, int n) {
+	b->p = malloc(n * sizeof b->p[0]);
+	b->np = 0;
+	b->maxp = n;
+}
+int sum(Buf *b) {
+	int i, s;
+	s = 0;
+	for(i = 0; i < b->np; i++)
+		s += b->p[i];
+	return s;
+}
+void vinit(Vec *v, int n) {
+	v->v = malloc(n * sizeof(double));
+	v->n = n;
+}
+double vsum(Vec *v) {
+	int i;
+	double s;
+	s = 0;
+	for(i = 0; i < v->n; i++)
+		s += v->v[i];
+	return s;
+}
@@ -0,0 +1,45 @@
+package main
+
+type Buf struct {
+	p []int
+}
+
+type Vec struct {
+	v []float64
+}
+
+func push(b *Buf, x int) {
+	if len(b.p) < cap(b.p) {
+		b.p = append(b.p, x)
+	}
+}
+
+func binit(b *Buf, n int) {
+	b.p = make([]int, n)
+	b.p = b.p[:0]
+}
+
+func sum(b *Buf) int {
+	var i int
+	var s int
+	s = 0
+	for i = 0; i < len(b.p); i++ {
+		s += b.p[i]
+	}
+	return s
+}
+
+func vinit(v *Vec, n int) {
+	v.v = make([]float64, n)
+	v.v = v.v[:n]
+}
+
+func vsum(v *Vec) float64 {
+	var i int
+	var s float64
+	s = 0
+	for i = 0; i < len(v.v); i++ {
+		s += v.v[i]
+	}
+	return s
+}
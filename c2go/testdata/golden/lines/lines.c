@@ -0,0 +1,26 @@
+typedef struct Node Node;
+struct Node {
+	int v;
+	Node *next;
+};
+
+int sum(Node *n)
+{
+	int s;
+
+	s = 0;
+	while(n->v != 0) {
+		s += n->v;
+		n = n->next;
+	}
+	return s;
+}
+
+void start(void)
+{
+	Node a;
+
+	a.v = 1;
+	a.next = 0;
+	sum(&a);
+}
@@ -0,0 +1,31 @@
+package main
+
+//line lines.c:1
+type Node struct {
+	v    int
+	next *Node
+}
+
+//line lines.c:7
+func sum(n *Node) int {
+//line lines.c:9
+	var s int
+
+	s = 0
+	for n.v != 0 {
+		s += n.v
+		n = n.next
+	}
+
+//line lines.c:16
+	return s
+}
+
+func start() {
+//line lines.c:21
+	var a Node
+
+	a.v = 1
+	a.next = nil
+	sum(&a)
+}
@@ -0,0 +1,24 @@
+#define N 16
+#define MASK (N - 1)
+#define SQ(x) ((x) * (x))
+#define AREA(w, h) ((w) * (h))
+#define BAD 1 + 2
+
+int seed;
+
+int next(void)
+{
+	return seed++;
+}
+
+int a[N];
+
+int f(int i)
+{
+	return a[i & MASK] + SQ(i) + AREA(i, 3) + BAD;
+}
+
+int g(void)
+{
+	return SQ(next());
+}
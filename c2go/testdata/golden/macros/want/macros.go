@@ -0,0 +1,31 @@
+package main
+
+const N = 16
+
+const MASK = N - 1
+
+func SQ(x int) int {
+	return x * x
+}
+
+func AREA(w int, h int) int {
+	return w * h
+}
+
+var seed int
+
+func next() int {
+	tmp1 := seed
+	seed++
+	return tmp1
+}
+
+var a [N]int
+
+func f(i int) int {
+	return a[i&MASK] + SQ(i) + AREA(i, 3) + 1 + 2
+}
+
+func g() int {
+	return (next()) * (next())
+}
@@ -0,0 +1,20 @@
+typedef struct Buf Buf;
+struct Buf {
+	char *data;
+	int len;
+};
+void buf_init(Buf *b) { b->len = 0; }
+int buf_len(Buf *b) { return b->len; }
+void buf_grow(Buf *b, int n) { static int calls; calls++; b->len += n; }
+int buf_data(Buf *b) { return 0; }
+int other(Buf *b) { return buf_len(b); }
+void apply(void (*f)(Buf*), Buf *b) { f(b); }
+int use(void) {
+	Buf b;
+	Buf *p;
+	buf_init(&b);
+	p = &b;
+	buf_grow(p, 3);
+	apply(buf_init, p);
+	return buf_len(&b) + other(p);
+}
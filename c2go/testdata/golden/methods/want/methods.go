@@ -0,0 +1,43 @@
+package main
+
+type Buf struct {
+	data string
+	len  int
+}
+
+func (b *Buf) init() {
+	b.len = 0
+}
+
+func buf_len(b *Buf) int {
+	return b.len
+}
+
+var Buf_Grow_calls int
+
+func (b *Buf) Grow(n int) {
+	Buf_Grow_calls++
+	b.len += n
+}
+
+func buf_data(b *Buf) int {
+	return 0
+}
+
+func other(b *Buf) int {
+	return buf_len(b)
+}
+
+func apply(f func(*Buf), b *Buf) {
+	f(b)
+}
+
+func use() int {
+	var b Buf
+	var p *Buf
+	b.init()
+	p = &b
+	p.Grow(3)
+	apply((*Buf).init, p)
+	return buf_len(&b) + other(p)
+}
@@ -0,0 +1,2 @@
+#include "../../lib/lib.h"
+int run(Lib *l) { return lib_count(l) + len(l); }
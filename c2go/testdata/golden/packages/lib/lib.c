@@ -0,0 +1,3 @@
+#include "lib.h"
+int lib_count(Lib *l) { return l->n; }
+int len(Lib *l) { return l->n; }
@@ -0,0 +1,4 @@
+typedef struct Lib Lib;
+struct Lib { int n; };
+int lib_count(Lib*);
+int len(Lib*);
@@ -0,0 +1,9 @@
+package main
+
+import (
+	"example.com/pk/lib"
+)
+
+func run(l *lib.Lib) int {
+	return lib.Lib_count(l) + lib.Len(l)
+}
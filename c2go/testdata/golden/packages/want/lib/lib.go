@@ -0,0 +1,13 @@
+package lib
+
+type Lib struct {
+	N int
+}
+
+func Lib_count(l *Lib) int {
+	return l.N
+}
+
+func Len(l *Lib) int {
+	return l.N
+}
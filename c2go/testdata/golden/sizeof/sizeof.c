@@ -0,0 +1,19 @@
+int h = sizeof(int);
+int b = sizeof(int*);
+long l[4];
+int g = sizeof l;
+
+int f(void)
+{
+	return sizeof(int);
+}
+
+int k(int x)
+{
+	return x + sizeof x;
+}
+
+int m(int *p)
+{
+	return sizeof(char*) + sizeof p + sizeof *p;
+}
@@ -0,0 +1,21 @@
+package main
+
+var h int = 4
+
+var b int = 8
+
+var l [4]int
+
+var g int = 32
+
+func f() int {
+	return 4
+}
+
+func k(x int) int {
+	return x + 4
+}
+
+func m(p *int) int {
+	return 8 + 8 + 4
+}
@@ -0,0 +1,26 @@
+void *malloc(unsigned long);
+typedef struct Buf Buf;
+struct Buf {
+	int *data;
+	int n;
+	Buf *next;
+};
+int sum(int *p, int n) {
+	int s = 0, i;
+	for (i = 0; i < n; i++)
+		s += p[i];
+	return s;
+}
+int total(Buf *b) { return sum(b->data, b->n); }
+void inc(int *x) { *x = *x + 1; }
+int table[4];
+int *first(void) { return table; }
+unsigned char *bytes;
+void fill(Buf *b, int n) {
+	int *q;
+	b->data = malloc(n * sizeof(int));
+	q = b->data;
+	inc(q);
+	b->n = n;
+	b->next = b;
+}
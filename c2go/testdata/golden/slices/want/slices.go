@@ -0,0 +1,41 @@
+package main
+
+type Buf struct {
+	data []int
+	n    int
+	next *Buf
+}
+
+func sum(p []int, n int) int {
+	var s int = 0
+	var i int
+	for i = 0; i < n; i++ {
+		s += p[i]
+	}
+	return s
+}
+
+func total(b *Buf) int {
+	return sum(b.data, b.n)
+}
+
+func inc(x []int) {
+	x[0] = x[0] + 1
+}
+
+var table [4]int
+
+func first() []int {
+	return table[:]
+}
+
+var bytes *uint8
+
+func fill(b *Buf, n int) {
+	var q []int
+	b.data = make([]int, n)
+	q = b.data
+	inc(q)
+	b.n = n
+	b.next = b
+}
@@ -0,0 +1,42 @@
+union Value {
+	int i;
+	float f;
+	unsigned char b[4];
+};
+
+typedef union Num Num;
+union Num {
+	int n;
+	double d;
+};
+
+int bits(float x)
+{
+	union Value v;
+
+	v.f = x;
+	return v.i;
+}
+
+int low(union Value *v)
+{
+	return v->b[0];
+}
+
+double get(Num *n)
+{
+	return n->d;
+}
+
+float frombits(int i)
+{
+	union Value v;
+
+	v.i = i;
+	return v.f;
+}
+
+int size(void)
+{
+	return sizeof(union Value);
+}
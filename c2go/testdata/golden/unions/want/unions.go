@@ -0,0 +1,51 @@
+package main
+
+import (
+	"unsafe"
+)
+
+type Value struct {
+	storage [1]uint32
+}
+
+func (u *Value) i() *int32 {
+	return (*int32)(unsafe.Pointer(&u.storage))
+}
+
+func (u *Value) f() *float32 {
+	return (*float32)(unsafe.Pointer(&u.storage))
+}
+
+func (u *Value) b() *[4]uint8 {
+	return (*[4]uint8)(unsafe.Pointer(&u.storage))
+}
+
+type Num struct {
+	d float64
+}
+
+func bits(x float32) int {
+	var v Value
+
+	*v.f() = x
+	return int(*v.i())
+}
+
+func low(v *Value) int {
+	return int((*v.b())[0])
+}
+
+func get(n *Num) float64 {
+	return n.d
+}
+
+func frombits(i int) float32 {
+	var v Value
+
+	*v.i() = int32(i)
+	return *v.f()
+}
+
+func size() int {
+	return int(unsafe.Sizeof(Value{}))
+}
@@ -0,0 +1,28 @@
+typedef struct Obj Obj;
+struct ops {
+	int (*read)(Obj*, char *buf, int n);
+	void (*close)(Obj*);
+	int (*flags)(Obj*);
+};
+
+struct Obj {
+	struct ops *ops;
+	int fd;
+};
+static int file_read(Obj *o, char *buf, int n) { return n + o->fd; }
+static void file_close(Obj *o) { o->fd = -1; }
+static int file_flags(Obj *o) { return 0; }
+static struct ops fileops = { file_read, file_close, file_flags };
+static struct ops nullops = { .close = file_close, .read = &file_read, .flags = file_flags };
+void open_file(Obj *o, int fd) {
+	o->ops = &fileops;
+	o->fd = fd;
+}
+int rd(Obj *o, char *buf, int n) {
+	int r;
+	if (o->ops == 0)
+		return -1;
+	r = o->ops->read(o, buf, n);
+	(*o->ops->close)(o);
+	return r;
+}
@@ -0,0 +1,71 @@
+package main
+
+type Obj struct {
+	ops ops
+	fd  int
+}
+
+type ops interface {
+	read(*Obj, string, int) int
+	close(*Obj)
+	flags(*Obj) int
+}
+
+func file_read(o *Obj, buf string, n int) int {
+	return n + o.fd
+}
+
+func file_close(o *Obj) {
+	o.fd = -1
+}
+
+func file_flags(o *Obj) int {
+	return 0
+}
+
+type fileops_ops struct{}
+
+func (fileops_ops) read(a0 *Obj, buf string, n int) int {
+	return file_read(a0, buf, n)
+}
+
+func (fileops_ops) close(a0 *Obj) {
+	file_close(a0)
+}
+
+func (fileops_ops) flags(a0 *Obj) int {
+	return file_flags(a0)
+}
+
+var fileops ops = fileops_ops{}
+
+type nullops_ops struct{}
+
+func (nullops_ops) read(a0 *Obj, buf string, n int) int {
+	return file_read(a0, buf, n)
+}
+
+func (nullops_ops) close(a0 *Obj) {
+	file_close(a0)
+}
+
+func (nullops_ops) flags(a0 *Obj) int {
+	return file_flags(a0)
+}
+
+var nullops ops = nullops_ops{}
+
+func open_file(o *Obj, fd int) {
+	o.ops = fileops
+	o.fd = fd
+}
+
+func rd(o *Obj, buf string, n int) int {
+	var r int
+	if o.ops == nil {
+		return -1
+	}
+	r = o.ops.read(o, buf, n)
+	(o.ops.close)(o)
+	return r
+}
@@ -0,0 +1,48 @@
+typedef struct Vec Vec;
+struct Vec {
+	int *data;
+	int len;
+};
+
+int contains(Vec *v, int x)
+{
+	int i, found;
+
+	found = 0;
+	for(i = 0; i < v->len; i++)
+		if(v->data[i] == x)
+			found = 1;
+	if(found)
+		return 1;
+	return 0;
+}
+
+int sum(Vec *v)
+{
+	int i, s;
+
+	s = 0;
+	for(i = 0; i < v->len; i++)
+		s += v->data[i];
+	return s;
+}
+
+int copy(int *dst, int *src, int n)
+{
+	int i;
+
+	for(i = 0; i < n; i++)
+		dst[i] = src[i];
+	return n;
+}
+
+void zero(int *p)
+{
+	*p = 0;
+}
+
+void reset(Vec *v)
+{
+	zero(v->data);
+	v->len = 0;
+}
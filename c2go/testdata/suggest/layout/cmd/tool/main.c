@@ -0,0 +1,9 @@
+#include "../../lib/buf.h"
+
+int main(void)
+{
+	Buf b;
+
+	b.n = 0;
+	return buflen(&b);
+}
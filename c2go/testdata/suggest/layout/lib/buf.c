@@ -0,0 +1,6 @@
+#include "buf.h"
+
+int buflen(Buf *b)
+{
+	return b->n;
+}
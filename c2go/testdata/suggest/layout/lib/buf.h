@@ -0,0 +1,7 @@
+typedef struct Buf Buf;
+struct Buf {
+	char *p;
+	int n;
+};
+
+int buflen(Buf *b);
@@ -2,11 +2,10 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+package c2go
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/hajimehoshi/cingo/cc"
@@ -19,7 +18,7 @@ func rewriteTypes(cfg *Config, prog cc.Syntax) {
 
 	cc.Postorder(prog, func(x cc.Syntax) {
 		if t, ok := x.(*cc.Type); ok {
-			if t.Kind == cc.Struct || t.Kind == cc.Enum {
+			if t.Kind == cc.Struct || t.Kind == cc.Union || t.Kind == cc.Enum {
 				for _, d := range t.Decls {
 					d.OuterType = t
 				}
@@ -44,36 +43,38 @@ func rewriteTypes(cfg *Config, prog cc.Syntax) {
 		}
 	})
 
-	/*
-		t := g.canon.Def()
-		if cc.Char <= t.Kind && t.Kind <= cc.Enum {
-			// Convert to an appropriately sized number.
-			// Canon is largest rank from C; convert to Go.
-			g.goType = &cc.Type{Kind: c2goKind[t.Kind]}
-			continue
-		}
-		if t.Kind == cc.Ptr || t.Kind == cc.Array {
-			// Default is convert to pointer.
-			// If there are any arrays or any pointer arithmetic, convert to slice instead.
-			k := cc.Ptr
-			for _, d := range g.decls {
-				if d.Type != nil && d.Type.Kind == cc.Array {
-					k = Slice
-				}
+	if p, ok := prog.(*cc.Prog); ok {
+		inferLenGroups(cfg, p)
+		inferSlices(cfg, p)
+		inferBools(cfg, p)
+	}
+
+	// Named enums declared at top level become named Go types,
+	// declared by the typedef naming them or else by their tagged decl.
+	if p, ok := prog.(*cc.Prog); ok {
+		cfg.stringers = make(map[*cc.Type]bool)
+		found := make(map[string]bool)
+		for _, d := range p.Decls {
+			t := d.Type
+			if t == nil || t.Kind != cc.Enum || len(t.Decls) == 0 {
+				continue
 			}
-			for _, f := range g.syntax {
-				if f.ptrAdd {
-					k = Slice
+			if d.Storage&cc.Typedef != 0 || d.Name == "" && t.Tag != "" && t.TypeDecl == nil {
+				t.TypeDecl = d
+			}
+			for _, name := range []string{t.Tag, d.Name} {
+				if name != "" && cfg.stringer[name] {
+					cfg.stringers[t] = true
+					found[name] = true
 				}
 			}
-			if t.Base.Kind == cc.Char {
-				g.goType = &cc.Type{Kind: String}
-				continue
+		}
+		for name := range cfg.stringer {
+			if !found[name] {
+				cfg.warnf(cc.Span{}, "stringer", "no named enum %s to give a String method", name)
 			}
-			g.goType = &cc.Type{Kind: k, Base: toGoType(cfg, nil, nil, t.Base, cache)}
-			continue
 		}
-	*/
+	}
 
 	cc.Postorder(prog, func(x cc.Syntax) {
 		switch x := x.(type) {
@@ -82,9 +83,15 @@ func rewriteTypes(cfg *Config, prog cc.Syntax) {
 			if d.Name == "..." || d.Type == nil {
 				return
 			}
-			if d.Name == "" && d.Type.Is(cc.Enum) && len(d.Type.Decls) > 0 {
+			if (d.Name == "" || d.Storage&cc.Typedef != 0) && d.Type.Is(cc.Enum) && len(d.Type.Decls) > 0 {
+				// The constants of a named enum have its type;
+				// those of an unnamed one are untyped.
+				t := idealType
+				if d.Type.TypeDecl != nil {
+					t = toGoType(cfg, nil, d.Type, cache)
+				}
 				for _, dd := range d.Type.Decls {
-					dd.Type = idealType
+					dd.Type = t
 				}
 				return
 			}
@@ -92,18 +99,22 @@ func rewriteTypes(cfg *Config, prog cc.Syntax) {
 				// Initialization of array - do not override type.
 				// But if size is not given explicitly, change to slice.
 				d.Type.Base = toGoType(cfg, nil, d.Type.Base, cache)
-				if d.Type.Width == nil {
+				if d.Type.Width == nil || d.Type.ImplicitWidth {
 					d.Type.Kind = Slice
 				}
 				return
 			}
+			if d.OuterType != nil && d.OuterType.Kind == cc.Union && !cfg.isBool(d) {
+				d.Type = unionMemberType(cfg, d.Type, cache)
+				return
+			}
 			d.Type = toGoType(cfg, d, d.Type, cache)
 
 		case *cc.Expr:
 			if x.Type != nil {
 				t := toGoType(cfg, nil, x.Type, cache)
 				if t == nil {
-					fprintf(x.Span, "cannot convert %v to go type\n", GoString(x.Type))
+					cfg.errorf(x.Span, "go-type", "cannot convert %v to go type", GoString(x.Type))
 				}
 				x.Type = t
 			}
@@ -112,19 +123,22 @@ func rewriteTypes(cfg *Config, prog cc.Syntax) {
 }
 
 var (
-	boolType   = &cc.Type{Kind: Bool}
-	byteType   = &cc.Type{Kind: Byte}
-	intType    = &cc.Type{Kind: Int}
-	uintType   = &cc.Type{Kind: Uint}
-	int32Type  = &cc.Type{Kind: Int32}
-	uint32Type = &cc.Type{Kind: Uint32}
-	int64Type  = &cc.Type{Kind: Int64}
-	uint64Type = &cc.Type{Kind: Uint64}
-	idealType  = &cc.Type{Kind: Ideal}
-	stringType = &cc.Type{Kind: String}
-	runeType   = &cc.Type{Kind: Rune}
+	boolType    = &cc.Type{Kind: Bool}
+	byteType    = &cc.Type{Kind: Byte}
+	intType     = &cc.Type{Kind: Int}
+	uintType    = &cc.Type{Kind: Uint}
+	int32Type   = &cc.Type{Kind: Int32}
+	uint32Type  = &cc.Type{Kind: Uint32}
+	int64Type   = &cc.Type{Kind: Int64}
+	uint64Type  = &cc.Type{Kind: Uint64}
+	uintptrType = &cc.Type{Kind: Uintptr}
+	idealType   = &cc.Type{Kind: Ideal}
+	stringType  = &cc.Type{Kind: String}
+	runeType    = &cc.Type{Kind: Rune}
 )
 
+// c2goKind is the translation of C scalar kinds used
+// when no data model is configured.
 var c2goKind = map[cc.TypeKind]cc.TypeKind{
 	cc.Char:      Int8,
 	cc.Uchar:     Uint8,
@@ -141,6 +155,40 @@ var c2goKind = map[cc.TypeKind]cc.TypeKind{
 	cc.Enum:      Int,
 }
 
+var (
+	goIntKind  = map[int64]cc.TypeKind{1: Int8, 2: Int16, 4: Int32, 8: Int64}
+	goUintKind = map[int64]cc.TypeKind{1: Uint8, 2: Uint16, 4: Uint32, 8: Uint64}
+)
+
+// goKind returns the Go kind for the C scalar kind k.
+// With a data model configured, C int and enums become Go int,
+// which may be wider, and the other integer kinds become Go integers
+// of the size the model gives them and the same signedness, so that
+// their sizes and unsigned overflow behave as in C. A 4-byte long
+// becomes int32 even where it is the size of int.
+func (cfg *Config) goKind(k cc.TypeKind) cc.TypeKind {
+	m := cfg.model
+	if m == nil || k == cc.Float || k == cc.Double {
+		return c2goKind[k]
+	}
+	if k == cc.Int || k == cc.Enum {
+		return Int
+	}
+	if m.IsSigned(k) {
+		return goIntKind[m.KindSize(k)]
+	}
+	return goUintKind[m.KindSize(k)]
+}
+
+// dataModel returns the data model of the C sources.
+// Without one configured, sizes follow LP64.
+func (cfg *Config) dataModel() *cc.Model {
+	if cfg.model == nil {
+		return cc.LP64
+	}
+	return cfg.model
+}
+
 var c2goName = map[string]cc.TypeKind{
 	"uchar":  Uint8,
 	"int32":  Int32,
@@ -149,6 +197,17 @@ var c2goName = map[string]cc.TypeKind{
 	"uint64": Uint64,
 }
 
+// isBoolDecl reports whether x is a decl that becomes a bool.
+func isBoolDecl(cfg *Config, x cc.Syntax) bool {
+	d, ok := x.(*cc.Decl)
+	return ok && cfg.isBool(d)
+}
+
+// isNamedEnum reports whether t is the Go type of a named C enum.
+func isNamedEnum(t *cc.Type) bool {
+	return t != nil && t.Name != "" && Bool <= t.Kind && t.Kind <= Float64
+}
+
 func toGoType(cfg *Config, x cc.Syntax, typ *cc.Type, cache map[*cc.Type]*cc.Type) *cc.Type {
 	if typ == nil {
 		return nil
@@ -169,13 +228,17 @@ func toGoType(cfg *Config, x cc.Syntax, typ *cc.Type, cache map[*cc.Type]*cc.Typ
 		return &cc.Type{Kind: cc.Struct} // struct{}
 
 	case cc.Char, cc.Uchar, cc.Short, cc.Ushort, cc.Int, cc.Uint, cc.Long, cc.Ulong, cc.Longlong, cc.Ulonglong, cc.Float, cc.Double, cc.Enum:
-		t := &cc.Type{Kind: c2goKind[typ.Kind]}
-		if d, ok := x.(*cc.Decl); ok {
-			if cfg.bool[declKey(d)] {
-				t.Kind = Bool
-			} else if strings.HasPrefix(d.Name, "no") {
-				println("not bool", d.Name, declKey(d))
+		if d := typ.TypeDecl; typ.Kind == cc.Enum && d != nil && !isBoolDecl(cfg, x) {
+			// A named integer type, printed as the name of its decl.
+			name := d.Name
+			if name == "" {
+				name = typ.Tag
 			}
+			return &cc.Type{Kind: cfg.goKind(cc.Enum), Name: name, TypeDecl: d}
+		}
+		t := &cc.Type{Kind: cfg.goKind(typ.Kind)}
+		if isBoolDecl(cfg, x) {
+			t.Kind = Bool
 		}
 		return t
 
@@ -189,19 +252,18 @@ func toGoType(cfg *Config, x cc.Syntax, typ *cc.Type, cache map[*cc.Type]*cc.Typ
 		// If this is a typedef like uchar, translate the type by name.
 		// Otherwise fall back to base.
 		def := typ.Base
+		if def.Kind == cc.Enum && def.TypeDecl != nil {
+			return toGoType(cfg, x, def, cache)
+		}
 		if cc.Char <= def.Kind && def.Kind <= cc.Enum {
 			var t *cc.Type
 			if c2goName[typ.Name] != 0 {
 				t = &cc.Type{Kind: c2goName[typ.Name]}
 			} else {
-				t = &cc.Type{Kind: c2goKind[typ.Base.Kind]}
+				t = &cc.Type{Kind: cfg.goKind(typ.Base.Kind)}
 			}
-			if d, ok := x.(*cc.Decl); ok {
-				if cfg.bool[declKey(d)] {
-					t.Kind = Bool
-				} else if strings.HasPrefix(d.Name, "no") {
-					println("not bool", d.Name, declKey(d))
-				}
+			if isBoolDecl(cfg, x) {
+				t.Kind = Bool
 			}
 			return t
 		}
@@ -249,16 +311,17 @@ func toGoType(cfg *Config, x cc.Syntax, typ *cc.Type, cache map[*cc.Type]*cc.Typ
 			return t
 		}
 
-		d, ok := x.(*cc.Decl)
-
-		if typ.Base.Def().Kind == cc.Uchar && (!ok || !cfg.ptr[declKey(d)]) {
-			t.Kind = Slice
-			t.Base = byteType
+		if d, ok := x.(*cc.Decl); ok {
+			if cfg.isSlice(d) {
+				return &cc.Type{Kind: Slice, Base: t.Base}
+			}
+			return t
 		}
-		if ok && cfg.slice[declKey(d)] {
+		if typ.Base.Def().Kind == cc.Uchar {
+			// Byte pointers outside declarations are most likely buffers.
 			t.Kind = Slice
+			t.Base = byteType
 		}
-
 		return t
 
 	case cc.Func:
@@ -280,12 +343,12 @@ func toGoType(cfg *Config, x cc.Syntax, typ *cc.Type, cache map[*cc.Type]*cc.Typ
 		// Check for array passed as parameter. Doesn't translate well.
 		for _, d := range typ.Decls {
 			if d.Type.Is(cc.Array) {
-				fprintf(d.Span, "function taking array parameter!")
+				cfg.warnf(d.Span, "array-param", "function taking array parameter!")
 			}
 		}
 		return typ
 
-	case cc.Struct:
+	case cc.Struct, cc.Union:
 		// A struct Type contains Decls, and we don't fork the Decls, so don't fork the Type.
 		// The Decls themselves appear in the group lists, so they'll be handled by rewriteTypes.
 		return typ
@@ -306,14 +369,14 @@ func fixGoTypes(cfg *Config, prog *cc.Prog) {
 		}
 		did[decl] = true
 		if decl.Init != nil {
-			fixGoTypesInit(decl, decl.Init)
+			cfg.fixGoTypesInit(decl, decl.Init)
 		}
 		if decl.Body != nil {
 			t := decl.Type
 			if t != nil && t.Kind == cc.Func && t.Base.Is(Int) && len(t.Decls) >= 1 && t.Decls[0].Type.String() == "Fmt*" {
-				fixFormatter(decl)
+				cfg.fixFormatter(decl)
 			}
-			fixGoTypesStmt(prog, decl, decl.Body)
+			cfg.fixGoTypesStmt(prog, decl, decl.Body)
 		}
 	}
 
@@ -322,16 +385,20 @@ func fixGoTypes(cfg *Config, prog *cc.Prog) {
 	rewriteSyntax(cfg, prog)
 }
 
-func fixGoTypesInit(decl *cc.Decl, x *cc.Init) {
+func (cfg *Config) fixGoTypesInit(decl *cc.Decl, x *cc.Init) {
+	if decl != nil && x == decl.Init && x.Expr != nil && decl.Macro == nil {
+		cfg.forceGoType(nil, x.Expr, decl.Type)
+		return
+	}
 	if x.Expr != nil {
-		fixGoTypesExpr(nil, x.Expr, x.XType)
+		cfg.fixGoTypesExpr(nil, x.Expr, x.XType)
 	}
 	for _, init := range x.Braced {
-		fixGoTypesInit(decl, init)
+		cfg.fixGoTypesInit(decl, init)
 	}
 }
 
-func fixGoTypesStmt(prog *cc.Prog, fn *cc.Decl, x *cc.Stmt) {
+func (cfg *Config) fixGoTypesStmt(prog *cc.Prog, fn *cc.Decl, x *cc.Stmt) {
 	if x == nil {
 		return
 	}
@@ -341,16 +408,19 @@ func fixGoTypesStmt(prog *cc.Prog, fn *cc.Decl, x *cc.Stmt) {
 
 	switch x.Op {
 	case cc.StmtDecl:
-		fixGoTypesExpr(fn, x.Expr, nil)
+		cfg.fixGoTypesExpr(fn, x.Expr, nil)
+		if d := x.Decl; d != nil && d.Init != nil && d.Init.Expr != nil {
+			cfg.forceGoType(fn, d.Init.Expr, d.Type)
+		}
 
 	case cc.StmtExpr:
 		if x.Expr != nil && x.Expr.Op == cc.Call && x.Expr.Left.Op == cc.Name {
 			switch x.Expr.Left.Text {
 			case "qsort":
-				fixQsort(prog, x.Expr)
+				cfg.fixQsort(prog, x.Expr)
 				return
 			case "memset":
-				fixMemset(prog, fn, x)
+				cfg.fixMemset(prog, fn, x)
 				return
 			case "free":
 				x.Op = cc.Empty
@@ -358,37 +428,37 @@ func fixGoTypesStmt(prog *cc.Prog, fn *cc.Decl, x *cc.Stmt) {
 				return
 			}
 		}
-		fixGoTypesExpr(fn, x.Expr, nil)
+		cfg.fixGoTypesExpr(fn, x.Expr, nil)
 
 	case cc.If, cc.For:
-		fixGoTypesExpr(fn, x.Pre, nil)
-		fixGoTypesExpr(fn, x.Post, nil)
-		fixGoTypesExpr(fn, x.Expr, boolType)
+		cfg.fixGoTypesExpr(fn, x.Pre, nil)
+		cfg.fixGoTypesExpr(fn, x.Post, nil)
+		cfg.fixGoTypesExpr(fn, x.Expr, boolType)
 
 	case cc.Switch:
-		fixGoTypesExpr(fn, x.Expr, nil)
+		cfg.fixGoTypesExpr(fn, x.Expr, nil)
 
 	case cc.Return:
 		if x.Expr != nil {
-			forceGoType(fn, x.Expr, fn.Type.Base)
+			cfg.forceGoType(fn, x.Expr, fn.Type.Base)
 		}
 	}
 	for _, stmt := range x.Block {
-		fixGoTypesStmt(prog, fn, stmt)
+		cfg.fixGoTypesStmt(prog, fn, stmt)
 	}
 	if len(x.Block) > 0 && x.Body != nil {
 		panic("block and body")
 	}
-	fixGoTypesStmt(prog, fn, x.Body)
-	fixGoTypesStmt(prog, fn, x.Else)
+	cfg.fixGoTypesStmt(prog, fn, x.Body)
+	cfg.fixGoTypesStmt(prog, fn, x.Else)
 
 	for _, lab := range x.Labels {
 		// TODO: use correct type
-		fixGoTypesExpr(fn, lab.Expr, nil)
+		cfg.fixGoTypesExpr(fn, lab.Expr, nil)
 	}
 }
 
-func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
+func (cfg *Config) fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 	if x == nil {
 		return nil
 	}
@@ -398,7 +468,7 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 	}()
 
 	if x.Op == cc.Paren {
-		return fixGoTypesExpr(fn, x.Left, targ)
+		return cfg.fixGoTypesExpr(fn, x.Left, targ)
 	}
 
 	// Make explicit C's implicit conversions from boolean to non-boolean and vice versa.
@@ -416,13 +486,13 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 				x.Left = &cc.Expr{Op: cc.Call, Left: &cc.Expr{Op: cc.Name, Text: "bool2int"}, List: []*cc.Expr{old}}
 				x.Type = targ
 			}
-			fixGoTypesExpr(fn, old, boolType)
+			cfg.fixGoTypesExpr(fn, old, boolType)
 			return targ
 		}
 	default:
 		if targ != nil && targ.Kind == Bool {
 			old := copyExpr(x)
-			left := fixGoTypesExpr(fn, old, nil)
+			left := cfg.fixGoTypesExpr(fn, old, nil)
 			if left != nil && left.Kind == Bool {
 				return targ
 			}
@@ -441,12 +511,12 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 			x.Op = cc.NotEq
 			x.Left = old
 			x.Right = zeroFor(left)
-			fixSpecialCompare(fn, x)
+			cfg.fixSpecialCompare(fn, x)
 			return targ
 		}
 	}
 
-	fixArray(fn, x)
+	cfg.fixArray(fn, x)
 
 	switch x.Op {
 	default:
@@ -458,9 +528,9 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 
 	case ExprSlice:
 		// inserted by rewriteLen
-		left := fixGoTypesExpr(fn, x.List[0], targ)
-		fixGoTypesExpr(fn, x.List[1], nil)
-		fixGoTypesExpr(fn, x.List[2], nil)
+		left := cfg.fixGoTypesExpr(fn, x.List[0], targ)
+		cfg.fixGoTypesExpr(fn, x.List[1], nil)
+		cfg.fixGoTypesExpr(fn, x.List[2], nil)
 		return left
 
 	case cc.Comma:
@@ -469,20 +539,20 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 			if i+1 < len(x.List) {
 				t = nil
 			}
-			fixGoTypesExpr(fn, y, t)
+			cfg.fixGoTypesExpr(fn, y, t)
 		}
 		return nil
 
 	case ExprBlock:
 		for _, stmt := range x.Block {
-			fixGoTypesStmt(nil, fn, stmt)
+			cfg.fixGoTypesStmt(nil, fn, stmt)
 		}
 		return nil
 
 	case cc.Add, cc.And, cc.Div, cc.Mod, cc.Mul, cc.Or, cc.Sub, cc.Xor:
 		if x.Op == cc.Sub && isPtrSliceOrArray(x.Left.XType) && isPtrSliceOrArray(x.Right.XType) {
-			left := fixGoTypesExpr(fn, x.Left, nil)
-			right := fixGoTypesExpr(fn, x.Right, nil)
+			left := cfg.fixGoTypesExpr(fn, x.Left, nil)
+			right := cfg.fixGoTypesExpr(fn, x.Right, nil)
 			if left != nil && right != nil && left.Kind != right.Kind {
 				if left.Kind == Slice {
 					forceConvert(fn, x.Right, right, left)
@@ -496,7 +566,7 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 			return intType
 		}
 
-		left := fixGoTypesExpr(fn, x.Left, targ)
+		left := cfg.fixGoTypesExpr(fn, x.Left, targ)
 
 		if x.Op == cc.And && x.Right.Op == cc.Twid {
 			x.Op = AndNot
@@ -504,7 +574,7 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 		}
 
 		if x.Op == cc.Add && isSliceStringOrArray(left) {
-			fixGoTypesExpr(fn, x.Right, nil)
+			cfg.fixGoTypesExpr(fn, x.Right, nil)
 			x.Op = ExprSlice
 			x.List = []*cc.Expr{x.Left, x.Right, nil}
 			x.Left = nil
@@ -515,11 +585,11 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 			return left
 		}
 
-		right := fixGoTypesExpr(fn, x.Right, targ)
+		right := cfg.fixGoTypesExpr(fn, x.Right, targ)
 		return fixBinary(fn, x, left, right, targ)
 
 	case cc.AddEq, cc.AndEq, cc.DivEq, cc.Eq, cc.ModEq, cc.MulEq, cc.OrEq, cc.SubEq, cc.XorEq:
-		left := fixGoTypesExpr(fn, x.Left, nil)
+		left := cfg.fixGoTypesExpr(fn, x.Left, nil)
 
 		if x.Op == cc.AndEq && x.Right.Op == cc.Twid {
 			x.Op = AndNotEq
@@ -527,7 +597,7 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 		}
 
 		if x.Op == cc.AddEq && isSliceOrString(left) {
-			fixGoTypesExpr(fn, x.Right, nil)
+			cfg.fixGoTypesExpr(fn, x.Right, nil)
 			old := copyExpr(x.Left)
 			x.Op = cc.Eq
 			x.Right = &cc.Expr{Op: ExprSlice, List: []*cc.Expr{old, x.Right, nil}}
@@ -540,7 +610,7 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 			return x.Left.XType
 		}
 
-		forceGoType(fn, x.Right, left)
+		cfg.forceGoType(fn, x.Right, left)
 
 		if x.Op == cc.Eq && x.Left != nil && x.Right != nil && x.Right.XType != nil && isCall(x.Right, "make") && x.Left.XDecl != nil && x.Left.XDecl.Type != nil && x.Left.XDecl.Type.Kind == cc.Ptr && sameType(x.Left.XDecl.Type.Base, x.Right.XType.Base) {
 			x.Left.XDecl.Type = x.Right.XType
@@ -551,17 +621,23 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 		return left
 
 	case ColonEq:
-		left := fixGoTypesExpr(fn, x.Right, nil)
+		left := cfg.fixGoTypesExpr(fn, x.Right, nil)
 		x.Left.XType = left
 		x.Left.XDecl.Type = left
 		return left
 
 	case cc.Addr:
-		left := fixGoTypesExpr(fn, x.Left, nil)
+		left := cfg.fixGoTypesExpr(fn, x.Left, nil)
 		if left == nil {
 			return nil
 		}
 
+		// &f is the func f.
+		if isFunc(left) {
+			fixMerge(x, x.Left)
+			return left
+		}
+
 		if targ != nil && targ.Kind == Slice && sameType(targ.Base, left) {
 			l := x.Left
 			l.Op = ExprSlice
@@ -575,14 +651,14 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 		return &cc.Type{Kind: cc.Ptr, Base: left}
 
 	case cc.AndAnd, cc.OrOr, cc.Not:
-		fixGoTypesExpr(fn, x.Left, boolType)
+		cfg.fixGoTypesExpr(fn, x.Left, boolType)
 		if x.Right != nil {
-			fixGoTypesExpr(fn, x.Right, boolType)
+			cfg.fixGoTypesExpr(fn, x.Right, boolType)
 		}
 		return boolType
 
 	case cc.Arrow, cc.Dot:
-		left := fixGoTypesExpr(fn, x.Left, nil)
+		left := cfg.fixGoTypesExpr(fn, x.Left, nil)
 
 		if x.Op == cc.Arrow && isSliceOrString(left) {
 			x.Left = &cc.Expr{Op: cc.Index, Left: x.Left, Right: &cc.Expr{Op: cc.Number, Text: "0"}}
@@ -591,21 +667,18 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 		return x.XDecl.Type
 
 	case cc.Call:
-		if fixPrintf(fn, x) {
+		if cfg.fixPrintf(fn, x) {
 			return x.XType
 		}
-		if fixSpecialCall(fn, x, targ) {
+		if cfg.fixSpecialCall(fn, x, targ) {
 			return x.XType
 		}
-		left := fixGoTypesExpr(fn, x.Left, nil)
-		if left != nil && left.Kind == cc.Ptr && left.Base.Kind == cc.Func {
-			left = left.Base
-		}
+		left := funcOf(cfg.fixGoTypesExpr(fn, x.Left, nil))
 		for i, y := range x.List {
 			if left != nil && left.Kind == cc.Func && i < len(left.Decls) {
-				forceGoType(fn, y, left.Decls[i].Type)
+				cfg.forceGoType(fn, y, left.Decls[i].Type)
 			} else {
-				fixGoTypesExpr(fn, y, nil)
+				cfg.fixGoTypesExpr(fn, y, nil)
 			}
 		}
 		if left != nil && left.Kind == cc.Func {
@@ -614,23 +687,29 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 		return nil
 
 	case cc.Cast:
-		fixGoTypesExpr(fn, x.Left, nil)
+		if y := unparen(x.Left); y.Op == cc.Number && y.Text == "0" && (isFunc(x.Type) || isSliceOrPtr(x.Type)) {
+			// A null pointer constant: Go cannot convert 0.
+			typ := x.Type
+			*x = cc.Expr{SyntaxInfo: x.SyntaxInfo, Op: cc.Name, Text: "nil", XType: typ}
+			return typ
+		}
+		cfg.fixGoTypesExpr(fn, x.Left, nil)
 		if isEmptyInterface(x.Left.XType) {
 			x.Op = TypeAssert
 		}
 		return x.Type
 
 	case cc.CastInit:
-		fixGoTypesInit(nil, x.Init)
+		cfg.fixGoTypesInit(nil, x.Init)
 		return x.Type
 
 	case cc.EqEq, cc.Gt, cc.GtEq, cc.Lt, cc.LtEq, cc.NotEq:
-		if fixSpecialCompare(fn, x) {
+		if cfg.fixSpecialCompare(fn, x) {
 			return boolType
 		}
-		left := fixGoTypesExpr(fn, x.Left, nil)
+		left := cfg.fixGoTypesExpr(fn, x.Left, nil)
 		if x.Right.Op == cc.Number && x.Right.Text == "0" || x.Right.Op == cc.Name && x.Right.Text == "nil" {
-			if isSliceOrPtr(left) {
+			if isSliceOrPtr(left) || isFunc(left) {
 				x.Right.Op = cc.Name
 				x.Right.Text = "nil"
 				return boolType
@@ -641,7 +720,12 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 				return boolType
 			}
 		}
-		right := fixGoTypesExpr(fn, x.Right, nil)
+		right := cfg.fixGoTypesExpr(fn, x.Right, nil)
+
+		if isFunc(left) && isFunc(right) {
+			cfg.errorf(x.Span, "func-compare", "cannot compare function pointers %v and %v in Go", x.Left, x.Right)
+			return boolType
+		}
 
 		if isSliceOrArray(x.Left.XType) && isSliceOrArray(x.Right.XType) {
 			x.Left = &cc.Expr{Op: cc.Minus, Left: &cc.Expr{Op: cc.Call, Left: &cc.Expr{Op: cc.Name, Text: "cap"}, List: []*cc.Expr{x.Left}}}
@@ -653,14 +737,20 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 		return boolType
 
 	case cc.Index, cc.Indir:
-		left := fixGoTypesExpr(fn, x.Left, nil)
+		left := cfg.fixGoTypesExpr(fn, x.Left, nil)
 		if x.Right != nil {
-			fixGoTypesExpr(fn, x.Right, nil)
+			cfg.fixGoTypesExpr(fn, x.Right, nil)
 		}
 		if left == nil {
 			return nil
 		}
 
+		// (*fp)(x) calls the func fp.
+		if isFunc(left) && x.Op == cc.Indir {
+			fixMerge(x, x.Left)
+			return left
+		}
+
 		if isSliceOrString(left) && x.Op == cc.Indir {
 			x.Op = cc.Index
 			x.Right = &cc.Expr{Op: cc.Number, Text: "0"}
@@ -668,9 +758,6 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 
 		switch left.Kind {
 		case cc.Ptr, Slice, cc.Array:
-			if x.Op == cc.Indir && left.Kind == cc.Ptr && left.Base.Kind == cc.Func {
-				*x = *x.Left
-			}
 			return left.Base
 
 		case String:
@@ -679,17 +766,17 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 		return nil
 
 	case cc.Lsh, cc.Rsh:
-		left := fixGoTypesExpr(fn, x.Left, targ)
+		left := cfg.fixGoTypesExpr(fn, x.Left, targ)
 		if left != nil && targ != nil && Int8 <= left.Kind && left.Kind <= Float64 && targ.Kind > left.Kind {
 			forceConvert(fn, x.Left, left, targ)
 			left = targ
 		}
-		fixShiftCount(fn, x.Right)
+		cfg.fixShiftCount(fn, x.Right)
 		return left
 
 	case cc.LshEq, cc.RshEq:
-		left := fixGoTypesExpr(fn, x.Left, nil)
-		fixShiftCount(fn, x.Right)
+		left := cfg.fixGoTypesExpr(fn, x.Left, nil)
+		cfg.fixShiftCount(fn, x.Right)
 		return left
 
 	case cc.Name:
@@ -697,6 +784,10 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 		}
 		switch x.Text {
 		case "T", "S", "N", "L", "P", "C":
+			if x.XDecl != nil && x.XDecl.Macro != nil {
+				// A translated macro, not a Plan 9 nil.
+				break
+			}
 			x.Text = "nil"
 			x.XDecl = nil
 			return nil
@@ -719,17 +810,16 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 		return idealType
 
 	case cc.Minus, cc.Plus, cc.Twid:
-		return fixGoTypesExpr(fn, x.Left, targ)
+		return cfg.fixGoTypesExpr(fn, x.Left, targ)
 
 	case cc.Offsetof:
-		// TODO
-		return nil
+		return uintptrType
 
 	case cc.Paren:
-		return fixGoTypesExpr(fn, x.Left, targ)
+		return cfg.fixGoTypesExpr(fn, x.Left, targ)
 
 	case cc.PostDec, cc.PostInc:
-		left := fixGoTypesExpr(fn, x.Left, nil)
+		left := cfg.fixGoTypesExpr(fn, x.Left, nil)
 
 		if x.Op == cc.PostInc && isSliceOrString(left) {
 			old := copyExpr(x.Left)
@@ -740,17 +830,24 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 		return nil
 
 	case cc.SizeofExpr:
-		left := fixGoTypesExpr(fn, x.Left, nil)
+		left := cfg.fixGoTypesExpr(fn, x.Left, nil)
+		if _, ok := cfg.sizes[x]; ok {
+			// Folded into a constant by foldSizes.
+			return idealType
+		}
 		if left != nil && (left.Kind == cc.Array || left.Kind == Slice) && left.Base.Def().Is(Uint8) {
 			x.Op = cc.Call
 			x.List = []*cc.Expr{x.Left}
 			x.Left = &cc.Expr{Op: cc.Name, Text: "len"}
 			return intType
 		}
-		return nil
+		return uintptrType
 
 	case cc.SizeofType:
-		return nil
+		if _, ok := cfg.sizes[x]; ok {
+			return idealType
+		}
+		return uintptrType
 
 	case cc.String:
 		return &cc.Type{Kind: String}
@@ -761,8 +858,8 @@ func fixGoTypesExpr(fn *cc.Decl, x *cc.Expr, targ *cc.Type) (ret *cc.Type) {
 	}
 }
 
-func forceGoType(fn *cc.Decl, x *cc.Expr, targ *cc.Type) {
-	actual := fixGoTypesExpr(fn, x, targ)
+func (cfg *Config) forceGoType(fn *cc.Decl, x *cc.Expr, targ *cc.Type) {
+	actual := cfg.fixGoTypesExpr(fn, x, targ)
 	forceConvert(fn, x, actual, targ)
 }
 
@@ -782,8 +879,8 @@ func forceConvert(fn *cc.Decl, x *cc.Expr, actual, targ *cc.Type) {
 	}
 
 	if isNumericConst(x) && targ != nil {
-		switch targ.Kind {
-		case cc.Ptr, Slice:
+		switch targ.Def().Kind {
+		case cc.Ptr, Slice, cc.Func:
 			if x.Op == cc.Number && x.Text == "0" {
 				x.Op = cc.Name
 				x.Text = "nil"
@@ -800,7 +897,7 @@ func forceConvert(fn *cc.Decl, x *cc.Expr, actual, targ *cc.Type) {
 	}
 
 	if x.Op == cc.Name && x.Text == "nil" && targ != nil {
-		switch targ.Kind {
+		switch targ.Def().Kind {
 		case cc.Func, cc.Ptr, Slice:
 			return
 		case String:
@@ -820,7 +917,7 @@ func forceConvert(fn *cc.Decl, x *cc.Expr, actual, targ *cc.Type) {
 	}
 
 	if x != nil && x.Op == cc.Name && x.Text == "nil" {
-		if targ.Kind == cc.Func || targ.Kind == cc.Ptr || targ.Kind == Slice {
+		if isFunc(targ) || targ.Kind == cc.Ptr || targ.Kind == Slice {
 			return
 		}
 	}
@@ -829,7 +926,7 @@ func forceConvert(fn *cc.Decl, x *cc.Expr, actual, targ *cc.Type) {
 	// If the func types are different, the conversion will fail;
 	// if not, the conversion is unnecessary.
 	// Either way the conversion is an eyesore.
-	if targ.Kind == cc.Func || targ.Kind == cc.Ptr && targ.Base.Kind == cc.Func {
+	if isFunc(targ) {
 		return
 	}
 
@@ -891,8 +988,8 @@ func forceConvert(fn *cc.Decl, x *cc.Expr, actual, targ *cc.Type) {
 	}
 }
 
-func fixShiftCount(fn *cc.Decl, x *cc.Expr) {
-	typ := fixGoTypesExpr(fn, x, nil)
+func (cfg *Config) fixShiftCount(fn *cc.Decl, x *cc.Expr) {
+	typ := cfg.fixGoTypesExpr(fn, x, nil)
 	if typ == nil {
 		return
 	}
@@ -944,22 +1041,22 @@ func fixBinary(fn *cc.Decl, x *cc.Expr, left, right, targ *cc.Type) *cc.Type {
 	return t
 }
 
-func fixSpecialCall(fn *cc.Decl, x *cc.Expr, targ *cc.Type) bool {
+func (cfg *Config) fixSpecialCall(fn *cc.Decl, x *cc.Expr, targ *cc.Type) bool {
 	if x.Left.Op != cc.Name {
 		return false
 	}
 	switch x.Left.Text {
 	case "memmove":
 		if len(x.List) != 3 {
-			// fprintf(x.Span, "unsupported %v", x)
+			cfg.infof(x.Span, "unsupported", "unsupported %v", x)
 			return false
 		}
 		siz := x.List[2]
 		if siz.Op == cc.Number && siz.Text == "4" {
-			obj1, obj1Type := objIndir(fn, x.List[0])
-			obj2, obj2Type := objIndir(fn, x.List[1])
+			obj1, obj1Type := cfg.objIndir(fn, x.List[0])
+			obj2, obj2Type := cfg.objIndir(fn, x.List[1])
 			if obj1Type == nil || obj2Type == nil {
-				// fprintf(x.Span, "unsupported %v - missing types", x)
+				cfg.infof(x.Span, "unsupported", "unsupported %v - missing types", x)
 				return true
 			}
 			if (obj1Type.Kind == Uint32 || obj1Type.Kind == Int32) && obj2Type.Kind == Float32 {
@@ -975,13 +1072,13 @@ func fixSpecialCall(fn *cc.Decl, x *cc.Expr, targ *cc.Type) bool {
 				x.XType = uint32Type
 				return true
 			}
-			// fprintf(x.Span, "unsupported %v - size 4 type %v %v", x, GoString(obj1Type), GoString(obj2Type))
+			cfg.infof(x.Span, "unsupported", "unsupported %v - size 4 type %v %v", x, GoString(obj1Type), GoString(obj2Type))
 		}
 		if siz.Op == cc.Number && siz.Text == "8" {
-			obj1, obj1Type := objIndir(fn, x.List[0])
-			obj2, obj2Type := objIndir(fn, x.List[1])
+			obj1, obj1Type := cfg.objIndir(fn, x.List[0])
+			obj2, obj2Type := cfg.objIndir(fn, x.List[1])
 			if obj1Type == nil || obj2Type == nil {
-				// fprintf(x.Span, "unsupported %v - missing types", x)
+				cfg.infof(x.Span, "unsupported", "unsupported %v - missing types", x)
 				return true
 			}
 			if (obj1Type.Kind == Uint64 || obj1Type.Kind == Int64) && obj2Type.Kind == Float64 {
@@ -997,14 +1094,14 @@ func fixSpecialCall(fn *cc.Decl, x *cc.Expr, targ *cc.Type) bool {
 				x.XType = uint64Type
 				return true
 			}
-			// fprintf(x.Span, "unsupported %v - size 8 type %v %v", x, GoString(obj1Type), GoString(obj2Type))
+			cfg.infof(x.Span, "unsupported", "unsupported %v - size 8 type %v %v", x, GoString(obj1Type), GoString(obj2Type))
 		}
 		if siz.Op == cc.SizeofExpr {
-			obj1Type := fixGoTypesExpr(fn, x.List[0], nil)
-			obj2Type := fixGoTypesExpr(fn, x.List[1], nil)
-			sizeType := fixGoTypesExpr(fn, siz.Left, nil)
+			obj1Type := cfg.fixGoTypesExpr(fn, x.List[0], nil)
+			obj2Type := cfg.fixGoTypesExpr(fn, x.List[1], nil)
+			sizeType := cfg.fixGoTypesExpr(fn, siz.Left, nil)
 			if obj1Type == nil || obj2Type == nil {
-				// fprintf(x.Span, "unsupported %v - bad types", x)
+				cfg.infof(x.Span, "unsupported", "unsupported %v - bad types", x)
 				return true
 			}
 			if obj2Type.Kind == cc.Array && sameType(obj2Type, sizeType) || obj2Type.Kind == Slice && GoString(x.List[1]) == GoString(siz.Left) {
@@ -1013,12 +1110,12 @@ func fixSpecialCall(fn *cc.Decl, x *cc.Expr, targ *cc.Type) bool {
 				x.List = x.List[:2]
 				return true
 			}
-			// fprintf(x.Span, "unsupported %v - not array %v %v", x, GoString(obj2Type), GoString(sizeType))
+			cfg.infof(x.Span, "unsupported", "unsupported %v - not array %v %v", x, GoString(obj2Type), GoString(sizeType))
 			return true
 		}
-		left := fixGoTypesExpr(fn, x.List[0], nil)
-		right := fixGoTypesExpr(fn, x.List[1], nil)
-		fixGoTypesExpr(fn, siz, nil)
+		left := cfg.fixGoTypesExpr(fn, x.List[0], nil)
+		right := cfg.fixGoTypesExpr(fn, x.List[1], nil)
+		cfg.fixGoTypesExpr(fn, siz, nil)
 		if isSliceOrArray(left) && isSliceOrArray(right) && left.Base.Is(Uint8) && right.Base.Is(Uint8) {
 			x.Left.Text = "copy"
 			x.Left.XDecl = nil
@@ -1030,12 +1127,12 @@ func fixSpecialCall(fn *cc.Decl, x *cc.Expr, targ *cc.Type) bool {
 			x.List = x.List[:2]
 			return true
 		}
-		// fprintf(x.Span, "unsupported %v (%v %v)", x, GoString(left), GoString(right))
+		cfg.infof(x.Span, "unsupported", "unsupported %v (%v %v)", x, GoString(left), GoString(right))
 		return true
 
 	case "mal", "malloc", "emallocz", "xmalloc":
 		if len(x.List) != 1 {
-			fprintf(x.Span, "unsupported %v - too many args", x)
+			cfg.warnf(x.Span, "unsupported", "unsupported %v - too many args", x)
 			return false
 		}
 		siz := x.List[0]
@@ -1054,19 +1151,19 @@ func fixSpecialCall(fn *cc.Decl, x *cc.Expr, targ *cc.Type) bool {
 			count = siz
 
 		case cc.SizeofExpr:
-			typ = fixGoTypesExpr(fn, siz.Left, nil)
+			typ = cfg.fixGoTypesExpr(fn, siz.Left, nil)
 			if typ == nil {
-				fprintf(siz.Span, "failed to type check %v", siz.Left)
+				cfg.warnf(siz.Span, "special-call", "failed to type check %v", siz.Left)
 			}
 
 		case cc.SizeofType:
 			typ = siz.Type
 			if typ == nil {
-				fprintf(siz.Span, "sizeoftype missing type")
+				cfg.warnf(siz.Span, "special-call", "sizeoftype missing type")
 			}
 		}
 		if typ == nil {
-			fprintf(x.Span, "unsupported %v - cannot understand type", x)
+			cfg.warnf(x.Span, "unsupported", "unsupported %v - cannot understand type", x)
 			return true
 		}
 		if count == nil {
@@ -1098,21 +1195,21 @@ func fixSpecialCall(fn *cc.Decl, x *cc.Expr, targ *cc.Type) bool {
 
 	case "strdup", "estrdup":
 		if len(x.List) != 1 {
-			fprintf(x.Span, "unsupported %v - too many args", x)
+			cfg.warnf(x.Span, "unsupported", "unsupported %v - too many args", x)
 			return false
 		}
-		fixGoTypesExpr(fn, x.List[0], stringType)
+		cfg.fixGoTypesExpr(fn, x.List[0], stringType)
 		fixMerge(x, x.List[0])
 		x.XType = stringType
 		return true
 
 	case "strcpy", "strcat", "fmtstrcpy":
 		if len(x.List) != 2 {
-			fprintf(x.Span, "unsupported %v - too many args", x)
+			cfg.warnf(x.Span, "unsupported", "unsupported %v - too many args", x)
 			return false
 		}
-		fixGoTypesExpr(fn, x.List[0], nil)
-		fixGoTypesExpr(fn, x.List[1], stringType)
+		cfg.fixGoTypesExpr(fn, x.List[0], nil)
+		cfg.fixGoTypesExpr(fn, x.List[1], stringType)
 		x.Op = cc.Eq
 		if x.Left.Text == "strcat" || x.Left.Text == "fmtstrcpy" {
 			x.Op = cc.AddEq
@@ -1130,11 +1227,11 @@ func fixSpecialCall(fn *cc.Decl, x *cc.Expr, targ *cc.Type) bool {
 
 	case "strcmp":
 		if len(x.List) != 2 {
-			fprintf(x.Span, "unsupported %v - too many args", x)
+			cfg.warnf(x.Span, "unsupported", "unsupported %v - too many args", x)
 			return false
 		}
-		fixGoTypesExpr(fn, x.List[0], stringType)
-		fixGoTypesExpr(fn, x.List[1], stringType)
+		cfg.fixGoTypesExpr(fn, x.List[0], stringType)
+		cfg.fixGoTypesExpr(fn, x.List[1], stringType)
 		x.Left.Text = "stringsCompare"
 		x.Left.XDecl = nil
 		x.XType = intType
@@ -1148,11 +1245,11 @@ func fixSpecialCall(fn *cc.Decl, x *cc.Expr, targ *cc.Type) bool {
 
 	case "TUP", "CASE":
 		if len(x.List) != 2 {
-			fprintf(x.Span, "unsupported %v - too many args", x)
+			cfg.warnf(x.Span, "unsupported", "unsupported %v - too many args", x)
 			return false
 		}
-		left := fixGoTypesExpr(fn, x.List[0], targ)
-		right := fixGoTypesExpr(fn, x.List[1], targ)
+		left := cfg.fixGoTypesExpr(fn, x.List[0], targ)
+		right := cfg.fixGoTypesExpr(fn, x.List[1], targ)
 		forceConvert(fn, x.List[0], left, uint32Type)
 		forceConvert(fn, x.List[1], right, uint32Type)
 		x.Op = cc.Or
@@ -1164,11 +1261,11 @@ func fixSpecialCall(fn *cc.Decl, x *cc.Expr, targ *cc.Type) bool {
 
 	case "R":
 		if len(x.List) != 2 {
-			fprintf(x.Span, "unsupported %v - too many args", x)
+			cfg.warnf(x.Span, "unsupported", "unsupported %v - too many args", x)
 			return false
 		}
-		left := fixGoTypesExpr(fn, x.List[0], targ)
-		right := fixGoTypesExpr(fn, x.List[1], targ)
+		left := cfg.fixGoTypesExpr(fn, x.List[0], targ)
+		right := cfg.fixGoTypesExpr(fn, x.List[1], targ)
 		forceConvert(fn, x.List[0], left, uint32Type)
 		forceConvert(fn, x.List[1], right, uint32Type)
 		x.Op = cc.Or
@@ -1180,12 +1277,12 @@ func fixSpecialCall(fn *cc.Decl, x *cc.Expr, targ *cc.Type) bool {
 
 	case "FCASE":
 		if len(x.List) != 3 {
-			fprintf(x.Span, "unsupported %v - too many args", x)
+			cfg.warnf(x.Span, "unsupported", "unsupported %v - too many args", x)
 			return false
 		}
-		arg0 := fixGoTypesExpr(fn, x.List[0], targ)
-		arg1 := fixGoTypesExpr(fn, x.List[1], targ)
-		arg2 := fixGoTypesExpr(fn, x.List[2], targ)
+		arg0 := cfg.fixGoTypesExpr(fn, x.List[0], targ)
+		arg1 := cfg.fixGoTypesExpr(fn, x.List[1], targ)
+		arg2 := cfg.fixGoTypesExpr(fn, x.List[2], targ)
 		forceConvert(fn, x.List[0], arg0, uint32Type)
 		forceConvert(fn, x.List[1], arg1, uint32Type)
 		forceConvert(fn, x.List[2], arg2, uint32Type)
@@ -1205,17 +1302,17 @@ func fixSpecialCall(fn *cc.Decl, x *cc.Expr, targ *cc.Type) bool {
 	return false
 }
 
-func fixMemset(prog *cc.Prog, fn *cc.Decl, stmt *cc.Stmt) {
+func (cfg *Config) fixMemset(prog *cc.Prog, fn *cc.Decl, stmt *cc.Stmt) {
 	x := stmt.Expr
 	if len(x.List) != 3 || x.List[1].Op != cc.Number || x.List[1].Text != "0" {
-		// fprintf(x.Span, "unsupported %v - nonzero", x)
+		cfg.infof(x.Span, "unsupported", "unsupported %v - nonzero", x)
 		return
 	}
 
 	if x.List[2].Op == cc.SizeofExpr || x.List[2].Op == cc.SizeofType {
-		obj, objType := objIndir(fn, x.List[0])
+		obj, objType := cfg.objIndir(fn, x.List[0])
 		if !matchSize(fn, obj, objType, x.List[2]) {
-			// fprintf(x.Span, "unsupported %v - wrong size", x)
+			cfg.infof(x.Span, "unsupported", "unsupported %v - wrong size", x)
 			return
 		}
 
@@ -1233,7 +1330,7 @@ func fixMemset(prog *cc.Prog, fn *cc.Decl, stmt *cc.Stmt) {
 		count = siz.Left
 		siz = siz.Right
 		if siz.Op != cc.SizeofExpr && siz.Op != cc.SizeofType {
-			// fprintf(x.Span, "unsupported %v - wrong array size", x)
+			cfg.infof(x.Span, "unsupported", "unsupported %v - wrong array size", x)
 			return
 		}
 
@@ -1241,26 +1338,26 @@ func fixMemset(prog *cc.Prog, fn *cc.Decl, stmt *cc.Stmt) {
 		case cc.SizeofExpr:
 			p := unparen(siz.Left)
 			if p.Op != cc.Indir && p.Op != cc.Index || !sameType(p.Left.XType, x.List[0].XType) {
-				// fprintf(x.Span, "unsupported %v - wrong size", x)
+				cfg.infof(x.Span, "unsupported", "unsupported %v - wrong size", x)
 			}
-			objType = fixGoTypesExpr(fn, x.List[0], nil)
+			objType = cfg.fixGoTypesExpr(fn, x.List[0], nil)
 		case cc.SizeofType:
-			objType = fixGoTypesExpr(fn, x.List[0], nil)
+			objType = cfg.fixGoTypesExpr(fn, x.List[0], nil)
 			if !sameType(siz.Type, objType.Base) {
-				// fprintf(x.Span, "unsupported %v - wrong size", x)
+				cfg.infof(x.Span, "unsupported", "unsupported %v - wrong size", x)
 			}
 		}
 	} else {
 		count = siz
-		objType = fixGoTypesExpr(fn, x.List[0], nil)
+		objType = cfg.fixGoTypesExpr(fn, x.List[0], nil)
 		if !objType.Base.Is(Byte) && !objType.Base.Is(Uint8) {
-			// fprintf(x.Span, "unsupported %v - wrong size form for non-byte type", x)
+			cfg.infof(x.Span, "unsupported", "unsupported %v - wrong size form for non-byte type", x)
 			return
 		}
 	}
 
 	if objType == nil {
-		fprintf(x.Span, "unsupported %v - lost type", x)
+		cfg.warnf(x.Span, "unsupported", "unsupported %v - lost type", x)
 		return
 	}
 
@@ -1319,7 +1416,7 @@ func fixMemset(prog *cc.Prog, fn *cc.Decl, stmt *cc.Stmt) {
 	return
 }
 
-func fixSpecialCompare(fn *cc.Decl, x *cc.Expr) bool {
+func (cfg *Config) fixSpecialCompare(fn *cc.Decl, x *cc.Expr) bool {
 	if (x.Right.Op != cc.Number || x.Right.Text != "0") && x.Right.String() != "nil" && x.Right.String() != `""` || x.Left.Op != cc.Call || x.Left.Left.Op != cc.Name {
 		return false
 	}
@@ -1328,18 +1425,18 @@ func fixSpecialCompare(fn *cc.Decl, x *cc.Expr) bool {
 	switch call.Left.Text {
 	case "memcmp":
 		if len(call.List) != 3 {
-			// fprintf(x.Span, "unsupported %v", x)
+			cfg.infof(x.Span, "unsupported", "unsupported %v", x)
 			return false
 		}
-		obj1, obj1Type := objIndir(fn, call.List[0])
-		obj2, obj2Type := objIndir(fn, call.List[1])
+		obj1, obj1Type := cfg.objIndir(fn, call.List[0])
+		obj2, obj2Type := cfg.objIndir(fn, call.List[1])
 		if obj1Type == nil || !sameType(obj1Type, obj2Type) {
-			// fprintf(x.Span, "unsupported %v", call)
+			cfg.infof(x.Span, "unsupported", "unsupported %v", call)
 			return true
 		}
 
 		if !matchSize(fn, obj1, obj1Type, call.List[2]) && !matchSize(fn, obj2, obj2Type, call.List[2]) {
-			// fprintf(x.Span, "unsupported %v - wrong size", call)
+			cfg.infof(x.Span, "unsupported", "unsupported %v - wrong size", call)
 			return true
 		}
 
@@ -1351,7 +1448,7 @@ func fixSpecialCompare(fn *cc.Decl, x *cc.Expr) bool {
 
 	case "strncmp":
 		if len(call.List) != 3 {
-			fprintf(x.Span, "unsupported %v", x)
+			cfg.warnf(x.Span, "unsupported", "unsupported %v", x)
 			return false
 		}
 		call.Left = &cc.Expr{Op: cc.Name, Text: "strings.HasPrefix"}
@@ -1368,7 +1465,7 @@ func fixSpecialCompare(fn *cc.Decl, x *cc.Expr) bool {
 
 	case "strstr":
 		if len(call.List) != 2 {
-			fprintf(x.Span, "unsupported %v", x)
+			cfg.warnf(x.Span, "unsupported", "unsupported %v", x)
 			return false
 		}
 		call.Left = &cc.Expr{Op: cc.Name, Text: "strings.Contains"}
@@ -1384,7 +1481,7 @@ func fixSpecialCompare(fn *cc.Decl, x *cc.Expr) bool {
 
 	case "utfrune":
 		if len(call.List) != 2 {
-			fprintf(x.Span, "unsupported %v", x)
+			cfg.warnf(x.Span, "unsupported", "unsupported %v", x)
 			return false
 		}
 		call.Left = &cc.Expr{Op: cc.Name, Text: "strings.ContainsRune"}
@@ -1400,7 +1497,7 @@ func fixSpecialCompare(fn *cc.Decl, x *cc.Expr) bool {
 
 	case "ucistrcmp":
 		if len(call.List) != 2 {
-			fprintf(x.Span, "unsupported %v", x)
+			cfg.warnf(x.Span, "unsupported", "unsupported %v", x)
 			return false
 		}
 		call.Left = &cc.Expr{Op: cc.Name, Text: "strings.EqualFold"}
@@ -1416,7 +1513,7 @@ func fixSpecialCompare(fn *cc.Decl, x *cc.Expr) bool {
 
 	case "strcmp":
 		if len(call.List) != 2 {
-			fprintf(x.Span, "unsupported %v", x)
+			cfg.warnf(x.Span, "unsupported", "unsupported %v", x)
 			return false
 		}
 		obj1 := call.List[0]
@@ -1430,7 +1527,7 @@ func fixSpecialCompare(fn *cc.Decl, x *cc.Expr) bool {
 
 	case "isspacerune":
 		if len(call.List) != 1 {
-			fprintf(x.Span, "unsupported %v", x)
+			cfg.warnf(x.Span, "unsupported", "unsupported %v", x)
 			return false
 		}
 		call.Left.Text = "unicode.IsSpace"
@@ -1501,8 +1598,8 @@ func negate(x *cc.Expr) *cc.Expr {
 	return x
 }
 
-func objIndir(fn *cc.Decl, x *cc.Expr) (*cc.Expr, *cc.Type) {
-	objType := fixGoTypesExpr(fn, x, nil)
+func (cfg *Config) objIndir(fn *cc.Decl, x *cc.Expr) (*cc.Expr, *cc.Type) {
+	objType := cfg.fixGoTypesExpr(fn, x, nil)
 	obj := x
 	if obj.XType != nil && obj.XType.Kind == cc.Array {
 		// obj stays as is
@@ -1581,6 +1678,26 @@ func isSliceOrPtr(typ *cc.Type) bool {
 	return typ != nil && (typ.Kind == Slice || typ.Kind == cc.Ptr)
 }
 
+// isFunc reports whether the Go type typ is a func type.
+// C function pointers, which are printed as func types,
+// are func types too.
+func isFunc(typ *cc.Type) bool {
+	return funcOf(typ) != nil
+}
+
+// funcOf returns the func type that typ stands for, or nil.
+// It sees through typedefs and pointers to functions.
+func funcOf(typ *cc.Type) *cc.Type {
+	typ = typ.Def()
+	if typ != nil && typ.Kind == cc.Ptr {
+		typ = typ.Base.Def()
+	}
+	if typ == nil || typ.Kind != cc.Func {
+		return nil
+	}
+	return typ
+}
+
 func isPtrSliceOrArray(typ *cc.Type) bool {
 	return typ != nil && (typ.Kind == cc.Ptr || typ.Kind == cc.Array || typ.Kind == Slice)
 }
@@ -1614,10 +1731,10 @@ func zeroFor(targ *cc.Type) *cc.Expr {
 		case String:
 			return &cc.Expr{Op: cc.String, Texts: []string{`""`}}
 
-		case Slice, cc.Ptr:
+		case Slice, cc.Ptr, cc.Func:
 			return &cc.Expr{Op: cc.Name, Text: "nil"}
 
-		case cc.Struct, cc.Array:
+		case cc.Struct, cc.Union, cc.Array:
 			return &cc.Expr{Op: cc.CastInit, Type: targ, Init: &cc.Init{}}
 
 		case Bool:
@@ -1640,6 +1757,15 @@ func zeroFor(targ *cc.Type) *cc.Expr {
 
 // rewriteLen rewrites references to length/capacity fields to use len(f) and cap(f) instead.
 func rewriteLen(cfg *Config, prog *cc.Prog) {
+	// Drop assignments to capacity fields of the capacity
+	// the allocation of the slice gives it anyway.
+	cc.Preorder(prog, func(x cc.Syntax) {
+		if x, ok := x.(*cc.Stmt); ok && cfg.allocSets[x] && x.Expr.Left.XDecl != nil && cfg.cap[declKey(x.Expr.Left.XDecl)] != "" {
+			x.Op = cc.Empty
+			x.Expr = nil
+		}
+	})
+
 	cc.Postorder(prog, func(x cc.Syntax) {
 		switch x := x.(type) {
 		case *cc.Expr:
@@ -1654,6 +1780,35 @@ func rewriteLen(cfg *Config, prog *cc.Prog) {
 				return
 			}
 
+			// Increment or adjust len. Change to reslice too.
+			if isLenCall(x.Left) {
+				var delta *cc.Expr
+				op := cc.Add
+				switch x.Op {
+				case cc.PreInc, cc.PostInc:
+					delta = &cc.Expr{Op: cc.Number, Text: "1", XType: intType}
+				case cc.PreDec, cc.PostDec:
+					delta = &cc.Expr{Op: cc.Number, Text: "1", XType: intType}
+					op = cc.Sub
+				case cc.AddEq:
+					delta = x.Right
+				case cc.SubEq:
+					delta = x.Right
+					op = cc.Sub
+				}
+				if delta != nil {
+					arg := x.Left.List[0]
+					n := &cc.Expr{Op: op, Left: x.Left, Right: delta, XType: intType}
+					x.Op = cc.Eq
+					x.Left = arg
+					x.Right = &cc.Expr{
+						Op:   ExprSlice,
+						List: []*cc.Expr{arg, nil, n},
+					}
+					return
+				}
+			}
+
 			if x.Op == cc.Call {
 				// Rewrite call with args x, len(x) to drop len(x).
 				var out []*cc.Expr
@@ -1692,7 +1847,7 @@ func rewriteLen(cfg *Config, prog *cc.Prog) {
 				} else {
 					d := x.XDecl
 					if d.OuterType == nil {
-						fmt.Fprintf(os.Stderr, "found use of %s but missing type\n", k)
+						cfg.warnf(x.Span, "len", "found use of %s but missing type", k)
 						return
 					}
 					t := d.OuterType
@@ -1707,7 +1862,7 @@ func rewriteLen(cfg *Config, prog *cc.Prog) {
 						}
 					}
 					if other == nil {
-						fmt.Fprintf(os.Stderr, "found use of %s but cannot find field %s\n", k, name)
+						cfg.warnf(x.Span, "len", "found use of %s but cannot find field %s", k, name)
 						return
 					}
 					left := x.Left
@@ -1729,17 +1884,101 @@ func rewriteLen(cfg *Config, prog *cc.Prog) {
 		}
 	})
 
+	cc.Postorder(prog, func(x cc.Syntax) {
+		if x, ok := x.(*cc.Stmt); ok && (x.Op == cc.Block || x.Op == BlockNoBrace) {
+			x.Block = fuseAppends(x.Block)
+		}
+	})
+
 	cc.Postorder(prog, func(x cc.Syntax) {
 		switch x := x.(type) {
 		case *cc.Type:
-			out := x.Decls[:0]
+			// Types without fields include cc's builtin types,
+			// which are shared by all programs and must not be written.
+			var out []*cc.Decl
+			drop := false
 			for _, d := range x.Decls {
 				k := declKey(d)
 				if cfg.len[k] == "" && cfg.cap[k] == "" && !cfg.delete[k] {
 					out = append(out, d)
+				} else {
+					drop = true
 				}
 			}
-			x.Decls = out
+			if drop {
+				x.Decls = out
+			}
 		}
 	})
 }
+
+// fuseAppends rewrites the statements p[len(p)] = v; p = p[:len(p)+1]
+// in list, the translation of C's p[n++] = v, into p = append(p, v),
+// and returns the result. The first would index past the end of p.
+func fuseAppends(list []*cc.Stmt) []*cc.Stmt {
+	out := list[:0]
+	for i := 0; i < len(list); i++ {
+		s := list[i]
+		if i+1 < len(list) && isStoreAtLen(s) && isExtendByOne(list[i+1], s.Expr.Left.Left) {
+			slice := s.Expr.Left.Left
+			elem := &cc.Type{}
+			if t := slice.XType; t != nil && t.Def().Kind == Slice {
+				elem = t.Def().Base
+			}
+			s.Expr = &cc.Expr{
+				SyntaxInfo: s.Expr.SyntaxInfo,
+				Op:         cc.Eq,
+				Left:       slice,
+				Right: &cc.Expr{
+					Op: cc.Call,
+					Left: &cc.Expr{
+						Op:    cc.Name,
+						Text:  "append",
+						XType: &cc.Type{Kind: cc.Func, Base: slice.XType, Decls: []*cc.Decl{{Type: slice.XType}, {Type: elem}}},
+					},
+					List: []*cc.Expr{copyExpr(slice), s.Expr.Right},
+				},
+			}
+			i++
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// isStoreAtLen reports whether s is the statement p[len(p)] = v.
+func isStoreAtLen(s *cc.Stmt) bool {
+	if s.Op != cc.StmtExpr || s.Expr.Op != cc.Eq || s.Expr.Left.Op != cc.Index {
+		return false
+	}
+	index := s.Expr.Left
+	return isLenCall(index.Right) && sameSlice(index.Right.List[0], index.Left)
+}
+
+// isExtendByOne reports whether s is the statement p = p[:len(p)+1].
+func isExtendByOne(s *cc.Stmt, p *cc.Expr) bool {
+	if s.Op != cc.StmtExpr || len(s.Labels) > 0 || s.Expr.Op != cc.Eq || !sameSlice(s.Expr.Left, p) {
+		return false
+	}
+	x := s.Expr.Right
+	if x.Op != ExprSlice || !sameSlice(x.List[0], p) || x.List[1] != nil {
+		return false
+	}
+	n := x.List[2]
+	return n.Op == cc.Add && isLenCall(n.Left) && sameSlice(n.Left.List[0], p) && n.Right.Op == cc.Number && n.Right.Text == "1"
+}
+
+// sameSlice reports whether x and y denote the same variable,
+// treating s->f and s.f, as made by rewriteLen, alike.
+func sameSlice(x, y *cc.Expr) bool {
+	isField := func(x *cc.Expr) bool { return x.Op == cc.Arrow || x.Op == cc.Dot }
+	if isField(x) && isField(y) {
+		return x.Text == y.Text && x.Left.String() == y.Left.String()
+	}
+	return x.String() == y.String()
+}
+
+// isLenCall reports whether x is a call len(arg), as inserted by rewriteLen.
+func isLenCall(x *cc.Expr) bool {
+	return x != nil && x.Op == cc.Call && x.Left.Op == cc.Name && x.Left.Text == "len" && len(x.List) == 1
+}
@@ -0,0 +1,314 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// rewriteUnions translates C unions.
+//
+// By default a union becomes a struct holding raw storage large enough
+// for its largest member, along with one accessor method per member
+// returning a pointer into that storage, so that type punning through
+// the union keeps working: u.f becomes *u.f().
+//
+// The config directive "union U f" instead translates union U
+// as a struct holding only its member f. That is also the fallback
+// for unions that cannot use raw storage, such as unions holding
+// pointers, which the garbage collector must be able to see.
+func rewriteUnions(cfg *Config, prog *cc.Prog) {
+	var unions []*cc.Type
+	seen := make(map[*cc.Type]bool)
+	cc.Preorder(prog, func(x cc.Syntax) {
+		if t, ok := x.(*cc.Type); ok && t.Kind == cc.Union && t.Decls != nil && !seen[t] {
+			seen[t] = true
+			unions = append(unions, t)
+		}
+	})
+	if len(unions) == 0 {
+		return
+	}
+
+	nameTypes(cfg, prog, seen)
+
+	picked := make(map[*cc.Type]*cc.Decl)
+	for _, t := range unions {
+		if name := cfg.union[t.Tag]; name != "" {
+			d := unionMember(t, name)
+			if d == nil {
+				cfg.warnf(t.Span, "union", "union %s has no member %s", t.Tag, name)
+				continue
+			}
+			picked[t] = d
+			continue
+		}
+		for _, d := range t.Decls {
+			if hasPointers(d.Type) {
+				cfg.warnf(t.Span, "union", "union %s holds pointers; translating as member %s only (use 'union %s member' to choose)", t.Tag, t.Decls[0].Name, t.Tag)
+				picked[t] = t.Decls[0]
+				break
+			}
+			if _, _, ok := goTypeSize(d.Type, cfg.dataModel()); !ok || d.Name == "" || d.Bits != nil {
+				cfg.warnf(t.Span, "union", "cannot lay out union %s; translating as member %s only", t.Tag, t.Decls[0].Name)
+				picked[t] = t.Decls[0]
+				break
+			}
+		}
+	}
+
+	var access []*cc.Expr
+	cc.Preorder(prog, func(x cc.Syntax) {
+		switch x := x.(type) {
+		case *cc.Init:
+			t := x.XType.Def()
+			if t != nil && t.Kind == cc.Union && picked[t] == nil && len(x.Braced) > 0 {
+				cfg.warnf(x.Span, "union", "cannot translate initializer of union %s", t.Tag)
+			}
+
+		case *cc.Expr:
+			if x.Op != cc.Dot && x.Op != cc.Arrow || x.XDecl == nil {
+				return
+			}
+			t := x.XDecl.OuterType
+			if t == nil || t.Kind != cc.Union {
+				return
+			}
+			if d := picked[t]; d != nil {
+				if d != x.XDecl {
+					span := x.Span
+					if span.Start.Line == 0 {
+						span = t.Span
+					}
+					cfg.warnf(span, "union", "union %s translated as member %s only; cannot access %s", t.Tag, d.Name, x.XDecl.Name)
+				}
+				return
+			}
+			access = append(access, x)
+		}
+	})
+
+	// x.f becomes *x.f().
+	for _, x := range access {
+		fn := &cc.Expr{
+			SyntaxInfo: x.SyntaxInfo,
+			Op:         x.Op,
+			Left:       x.Left,
+			Text:       x.Text,
+			XDecl:      x.XDecl,
+		}
+		call := &cc.Expr{
+			SyntaxInfo: x.SyntaxInfo,
+			Op:         cc.Call,
+			Left:       fn,
+			XType:      &cc.Type{Kind: cc.Ptr, Base: x.XDecl.Type},
+		}
+		*x = cc.Expr{
+			SyntaxInfo: x.SyntaxInfo,
+			Op:         cc.Indir,
+			Left:       call,
+			XType:      x.XDecl.Type,
+		}
+	}
+
+	// &*x.f() is x.f().
+	cc.Postorder(prog, func(x cc.Syntax) {
+		if x, ok := x.(*cc.Expr); ok && x.Op == cc.Addr && x.Left.Op == cc.Indir && x.Left.Left.Op == cc.Call {
+			if fn := x.Left.Left.Left; (fn.Op == cc.Dot || fn.Op == cc.Arrow) && fn.XDecl != nil && fn.XDecl.OuterType != nil && fn.XDecl.OuterType.Kind == cc.Union {
+				*x = *x.Left.Left
+			}
+		}
+	})
+
+	// A union translated as one of its members is just a struct.
+	for t, d := range picked {
+		t.Kind = cc.Struct
+		t.Decls = []*cc.Decl{d}
+	}
+}
+
+// nameTypes gives every struct or union in types a tag,
+// since Go methods need a named type.
+// Types named only by a typedef take the typedef name.
+// Anonymous types used for a struct field or variable
+// are named after it, and declared at top level.
+func nameTypes(cfg *Config, prog *cc.Prog, types map[*cc.Type]bool) {
+	for _, d := range prog.Decls {
+		if d.Storage&cc.Typedef != 0 && d.Type != nil && (d.Type.Kind == cc.Struct || d.Type.Kind == cc.Union) && d.Type.Tag == "" {
+			d.Type.Tag = d.Name
+		}
+	}
+	declared := make(map[*cc.Type]bool)
+	for _, d := range prog.Decls {
+		if d.Name == "" || d.Storage&cc.Typedef != 0 {
+			declared[d.Type] = true
+		}
+	}
+	var hoisted []*cc.Decl
+	cc.Preorder(prog, func(x cc.Syntax) {
+		d, ok := x.(*cc.Decl)
+		if !ok || d.Type == nil || !types[d.Type] || declared[d.Type] {
+			return
+		}
+		t := d.Type
+		if t.Tag == "" {
+			if d.Name == "" {
+				return
+			}
+			t.Tag = d.Name + "_" + strings.ToLower(t.Kind.String())
+			if d.OuterType != nil && d.OuterType.Tag != "" {
+				t.Tag = d.OuterType.Tag + "_" + d.Name
+			}
+		}
+		declared[t] = true
+		hoisted = append(hoisted, &cc.Decl{
+			SyntaxInfo: cc.SyntaxInfo{Span: t.Span},
+			Type:       t,
+			GoPackage:  cfg.filePackage(t.Span.Start.File),
+		})
+	})
+	prog.Decls = append(prog.Decls, hoisted...)
+}
+
+// unionMemberType returns the Go type for t, the C type of a member
+// of a union. Integers and arrays of them get the sizes the data model
+// gives them, even C int, which elsewhere becomes the wider Go int,
+// so that the members overlay each other in the storage as in C.
+func unionMemberType(cfg *Config, t *cc.Type, cache map[*cc.Type]*cc.Type) *cc.Type {
+	m := cfg.dataModel()
+	switch def := t.Def(); {
+	case def == nil:
+	case def.Kind == cc.Array && def.Base.Def() != nil && def.Base.Def().Kind != cc.Char:
+		return &cc.Type{Kind: cc.Array, Width: def.Width, Base: unionMemberType(cfg, def.Base, cache)}
+	case def.Kind == cc.Enum && def.TypeDecl != nil:
+		// A named Go type.
+	case cc.Char <= def.Kind && def.Kind <= cc.Enum && def.Kind != cc.Float && def.Kind != cc.Double:
+		if m.IsSigned(def.Kind) {
+			return &cc.Type{Kind: goIntKind[m.KindSize(def.Kind)]}
+		}
+		return &cc.Type{Kind: goUintKind[m.KindSize(def.Kind)]}
+	}
+	return toGoType(cfg, nil, t, cache)
+}
+
+func (p *Printer) printUnionDecl(t *cc.Type) {
+	field, typ := unionStorage(t, p.model)
+	p.Print("type ", t.Tag, " struct {", Indent, Newline, field, " ", typ, Unindent, Newline, "}")
+	for _, d := range t.Decls {
+		p.Print(Newline, Newline, "func (u *", t.Tag, ") ", d.Name, "() *", d.Type, " {", Indent)
+		p.Print(Newline, "return (*", d.Type, ")(unsafe.Pointer(&u.", field, "))")
+		p.Print(Unindent, Newline, "}")
+	}
+	p.addImport("unsafe")
+}
+
+func unionMember(t *cc.Type, name string) *cc.Decl {
+	for _, d := range t.Decls {
+		if d.Name == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// hasPointers reports whether values of the Go type t contain pointers.
+func hasPointers(t *cc.Type) bool {
+	if t == nil {
+		return false
+	}
+	switch t.Kind {
+	case cc.Ptr, Slice, String, cc.Func:
+		return true
+	case cc.TypedefType:
+		return t.Base == nil || hasPointers(t.Base)
+	case cc.Array:
+		return hasPointers(t.Base)
+	case cc.Struct, cc.Union:
+		for _, d := range t.Decls {
+			if hasPointers(d.Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var goKindSize = map[cc.TypeKind]int64{
+	Bool:    1,
+	Int8:    1,
+	Uint8:   1,
+	Byte:    1,
+	Int16:   2,
+	Uint16:  2,
+	Int:     8,
+	Uint:    8,
+	Int32:   4,
+	Rune:    4,
+	Uint32:  4,
+	Uintptr: 8,
+	Int64:   8,
+	Uint64:  8,
+	Float32: 4,
+	Float64: 8,
+}
+
+// goTypeSize returns the size and alignment of the Go type t
+// on 64-bit systems. Array lengths are C constants evaluated under m.
+func goTypeSize(t *cc.Type, m *cc.Model) (size, align int64, ok bool) {
+	if t == nil {
+		return 0, 0, false
+	}
+	if size, ok := goKindSize[t.Kind]; ok {
+		return size, size, true
+	}
+	switch t.Kind {
+	case cc.TypedefType:
+		return goTypeSize(t.Base, m)
+
+	case cc.Array:
+		n, ok := cc.ConstInt(t.Width, m)
+		if !ok {
+			return 0, 0, false
+		}
+		size, align, ok := goTypeSize(t.Base, m)
+		return n * size, align, ok
+
+	case cc.Struct, cc.Union:
+		size, align = 0, 1
+		for _, d := range t.Decls {
+			dsize, dalign, ok := goTypeSize(d.Type, m)
+			if !ok {
+				return 0, 0, false
+			}
+			if dalign > align {
+				align = dalign
+			}
+			if t.Kind == cc.Union {
+				if dsize > size {
+					size = dsize
+				}
+				continue
+			}
+			size = (size+dalign-1)/dalign*dalign + dsize
+		}
+		return (size + align - 1) / align * align, align, true
+	}
+	return 0, 0, false
+}
+
+// unionStorage returns the name and type of the field holding
+// the raw storage for union t.
+func unionStorage(t *cc.Type, m *cc.Model) (field, typ string) {
+	field = "storage"
+	for unionMember(t, field) != nil {
+		field += "_"
+	}
+	size, align, _ := goTypeSize(t, m)
+	word := map[int64]string{1: "byte", 2: "uint16", 4: "uint32", 8: "uint64"}[align]
+	return field, fmt.Sprintf("[%d]%s", size/align, word)
+}
@@ -0,0 +1,105 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// verifyGoFiles type-checks the Go packages in cfg.output
+// and reports each error at the C code translated to the Go code
+// at fault.
+// Packages with files that do not parse have already been reported
+// by writeGoFiles and are left out.
+func verifyGoFiles(cfg *Config, prog *cc.Prog) {
+	module := cfg.modulePath()
+	fset := token.NewFileSet()
+	files := make(map[string][]*ast.File) // by import path
+	bad := make(map[string]bool)
+	var names []string
+	for name := range cfg.output {
+		if strings.HasSuffix(name, ".go") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pkg := importPath(module, path.Dir(name))
+		if path.Dir(name) == "." {
+			pkg = module
+		}
+		f, err := parser.ParseFile(fset, name, cfg.output[name], 0)
+		if err != nil {
+			bad[pkg] = true
+			continue
+		}
+		files[pkg] = append(files[pkg], f)
+	}
+
+	std := importer.ForCompiler(fset, "source", nil)
+	checked := make(map[string]*types.Package)
+	checking := make(map[string]bool)
+	var check func(pkg string) (*types.Package, error)
+	conf := types.Config{
+		Importer: importerFunc(func(pkg string) (*types.Package, error) {
+			if files[pkg] != nil || bad[pkg] {
+				return check(pkg)
+			}
+			return std.Import(pkg)
+		}),
+		Error: func(err error) {
+			e, ok := err.(types.Error)
+			if !ok {
+				cfg.errorf(cc.Span{}, "type", "%v", err)
+				return
+			}
+			// Leave //line directives out of the position.
+			pos := e.Fset.PositionFor(e.Pos, false)
+			span, ok := cfg.srcMaps[pos.Filename].lookup(pos.Offset)
+			if !ok {
+				span = cc.Span{Start: cc.Pos{File: pos.Filename, Line: pos.Line}}
+			}
+			cfg.errorf(span, "type", "%s", e.Msg)
+		},
+	}
+	check = func(pkg string) (*types.Package, error) {
+		if p := checked[pkg]; p != nil {
+			return p, nil
+		}
+		switch {
+		case bad[pkg]:
+			return nil, fmt.Errorf("%s does not parse", pkg)
+		case checking[pkg]:
+			return nil, fmt.Errorf("import cycle through %s", pkg)
+		}
+		checking[pkg] = true
+		p, _ := conf.Check(pkg, fset, files[pkg], nil)
+		checked[pkg] = p
+		return p, nil
+	}
+
+	var pkgs []string
+	for pkg := range files {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		check(pkg)
+	}
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
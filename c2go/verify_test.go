@@ -0,0 +1,104 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go_test
+
+import (
+	"testing"
+
+	. "github.com/hajimehoshi/cingo/c2go"
+)
+
+var verifyTests = []struct {
+	name string
+	src  string
+	want []string // type errors, as printed
+}{
+	{
+		name: "ok",
+		src: `
+int add(int x, int y)
+{
+	return x + y;
+}
+`,
+	},
+	{
+		name: "undefined",
+		src: `
+int report(int);
+
+int count(char *s, int c)
+{
+	int k;
+
+	k = 0;
+	for(; *s; s++)
+		if(*s == c)
+			k++;
+	report(k);
+	return k;
+}
+`,
+		want: []string{"x.c:12: error: undefined: report"},
+	},
+	{
+		name: "nested",
+		src: `
+int lookup(int);
+
+int sum(int *a, int n)
+{
+	int i, s;
+
+	s = 0;
+	for(i = 0; i < n; i++)
+		s += a[i] * 2;
+	return s + lookup(n);
+}
+`,
+		want: []string{"x.c:11: error: undefined: lookup"},
+	},
+	{
+		// The error is in the expression on the second line
+		// of the assignment, which Go prints on one line.
+		name: "conversion",
+		src: `
+int total(int **rows, int n)
+{
+	int i, t;
+
+	t = 0;
+	for(i = 0; i < n; i++)
+		if(rows[i] != 0)
+			t = t +
+				(int)rows[i];
+	return t;
+}
+`,
+		want: []string{"x.c:10: error: cannot convert rows[i] (variable of type *int) to type int"},
+	},
+}
+
+func TestVerify(t *testing.T) {
+	for _, tt := range verifyTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, diags := Translate(new(Config), []Input{{Name: "x.c", Data: []byte(tt.src)}})
+			var got []string
+			for _, d := range diags {
+				if d.Code == "type" {
+					got = append(got, d.String())
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("type errors:\n%q\nwant:\n%q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("type error %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// rewriteVtables translates the C structs of function pointers named
+// in "interface" config directives into Go interfaces.
+//
+// Such a struct is a table of operations, each taking the object
+// it operates on as its first argument. It becomes an interface with
+// one method per field, and pointers to it become interface values,
+// so that o->ops->read(o, buf, n) is a method call on the interface.
+// Each table initialized with functions becomes a value of a concrete
+// type, named after the table, whose methods call those functions.
+func rewriteVtables(cfg *Config, prog *cc.Prog) {
+	if len(cfg.iface) == 0 {
+		return
+	}
+
+	ifaces := make(map[*cc.Type]bool)
+	cc.Preorder(prog, func(x cc.Syntax) {
+		var t *cc.Type
+		switch x := x.(type) {
+		case *cc.Type:
+			if _, ok := cfg.iface[x.Tag]; ok && x.Kind == cc.Struct {
+				t = x
+			}
+		case *cc.Decl:
+			if _, ok := cfg.iface[x.Name]; ok && x.Storage&cc.Typedef != 0 && x.Type != nil && x.Type.Kind == cc.Struct {
+				t = x.Type
+				if t.Tag == "" {
+					t.Tag = x.Name
+				}
+			}
+		}
+		if t == nil || t.Decls == nil || ifaces[t] {
+			return
+		}
+		for _, d := range t.Decls {
+			if f := funcOf(d.Type); f == nil || len(f.Decls) == 0 {
+				cfg.warnf(d.Span, "interface", "cannot translate struct %s as an interface: field %s is not a function pointer taking an object", t.Tag, d.Name)
+				return
+			}
+		}
+		ifaces[t] = true
+	})
+	for name, span := range cfg.iface {
+		found := false
+		for t := range ifaces {
+			found = found || t.Tag == name
+		}
+		if !found {
+			cfg.warnf(span, "interface", "no struct %s of function pointers to translate as an interface", name)
+		}
+	}
+	if len(ifaces) == 0 {
+		return
+	}
+
+	for t := range ifaces {
+		t.Kind = Interface
+		for _, d := range t.Decls {
+			d.Type = funcOf(d.Type)
+		}
+	}
+
+	// Pointers to the tables become interface values.
+	// The pointer types may be shared with other syntax,
+	// so each use is pointed at the interface instead.
+	deref := func(t **cc.Type) {
+		if *t != nil && (*t).Kind == cc.Ptr && isInterface((*t).Base) {
+			*t = (*t).Base
+		}
+	}
+	cc.Preorder(prog, func(x cc.Syntax) {
+		switch x := x.(type) {
+		case *cc.Decl:
+			deref(&x.Type)
+		case *cc.Type:
+			deref(&x.Base)
+		case *cc.Expr:
+			deref(&x.Type)
+			deref(&x.XType)
+		case *cc.Init:
+			deref(&x.XType)
+		}
+	})
+
+	cc.Postorder(prog, func(x cc.Syntax) {
+		switch x := x.(type) {
+		case *cc.Decl:
+			if x.Init != nil && len(x.Init.Braced) > 0 && isInterface(x.Type) && x.CurFn != nil && x.Storage&cc.Static == 0 {
+				cfg.warnf(x.Span, "interface", "cannot translate local table %s of interface %s", x.Name, x.Type.Def().Tag)
+			}
+			// A Go method cannot be nil, so code checking
+			// for an unset entry would silently change meaning.
+			if x.Init != nil && len(x.Init.Braced) > 0 && isInterface(x.Type) {
+				entries := vtableEntries(x.Type.Def(), x.Init)
+				for _, d := range x.Type.Def().Decls {
+					if fn := entries[d]; fn == nil || fn.Op == cc.Name && fn.Text == "nil" || fn.Op == cc.Number && fn.Text == "0" {
+						cfg.errorf(x.Span, "interface", "table %s leaves method %s of interface %s unset", x.Name, d.Name, x.Type.Def().Tag)
+					}
+				}
+			}
+
+		case *cc.Expr:
+			switch x.Op {
+			case cc.Addr:
+				// &table is the table.
+				if isInterface(x.Left.XType) {
+					fixMerge(x, x.Left)
+				}
+
+			case cc.Indir:
+				if isInterface(x.XType) || x.Left.XType != nil && x.Left.XType.Def().Kind == cc.Ptr && isInterface(x.Left.XType.Def().Base) {
+					fixMerge(x, x.Left)
+				}
+
+			case cc.EqEq, cc.NotEq:
+				if isMethodExpr(x.Left) || isMethodExpr(x.Right) {
+					cfg.errorf(x.Span, "interface", "cannot compare interface method %v in Go", x)
+				}
+
+			case cc.Eq:
+				if isMethodExpr(x.Left) {
+					cfg.warnf(x.Span, "interface", "cannot assign to interface method %v in Go", x.Left)
+				}
+			}
+		}
+	})
+}
+
+// isMethodExpr reports whether x selects a method of an interface
+// translated from a struct of function pointers.
+func isMethodExpr(x *cc.Expr) bool {
+	x = unparen(x)
+	return x != nil && (x.Op == cc.Dot || x.Op == cc.Arrow) && x.XDecl != nil &&
+		x.XDecl.OuterType != nil && x.XDecl.OuterType.Kind == Interface
+}
+
+func isInterface(t *cc.Type) bool {
+	return t != nil && t.Def().Kind == Interface
+}
+
+func (p *Printer) printInterfaceDecl(t *cc.Type) {
+	p.Print("type ", t.Tag, " interface {", Indent)
+	for _, d := range t.Decls {
+		p.Print(Newline, d.Name)
+		p.printSignature(d.Type, nil)
+	}
+	p.Print(Unindent, Newline, "}")
+}
+
+// printSignature prints the parameters and results of the func type t,
+// naming the parameters by names if it is not nil.
+func (p *Printer) printSignature(t *cc.Type, names []string) {
+	p.Print("(")
+	for i, arg := range t.Decls {
+		if arg.Name == "" && arg.Type.Is(cc.Void) {
+			continue
+		}
+		if i > 0 {
+			p.Print(", ")
+		}
+		if names != nil {
+			p.Print(names[i], " ")
+		}
+		if arg.Name == "..." {
+			p.Print("...interface{}")
+			continue
+		}
+		p.Print(arg.Type)
+	}
+	p.Print(")")
+	if !t.Base.Is(cc.Void) {
+		p.Print(" ", t.Base)
+	}
+}
+
+// printVtableDecl prints the table decl, initialized with functions,
+// as a value of a concrete type implementing its interface.
+func (p *Printer) printVtableDecl(decl *cc.Decl) {
+	t := decl.Type.Def()
+	impl := decl.Name + "_" + t.Tag
+	p.Print("type ", impl, " struct{}")
+
+	entries := vtableEntries(t, decl.Init)
+	for _, d := range t.Decls {
+		f := d.Type
+		var names []string
+		for i, arg := range f.Decls {
+			name := arg.Name
+			if name == "" || name == "..." || name == "_" {
+				name = fmt.Sprint("a", i)
+			}
+			names = append(names, name)
+		}
+		p.Print(Newline, Newline, "func (", impl, ") ", d.Name)
+		p.printSignature(f, names)
+		p.Print(" {", Indent, Newline)
+		fn := entries[d]
+		if fn == nil || fn.Op == cc.Name && fn.Text == "nil" || fn.Op == cc.Number && fn.Text == "0" {
+			p.Print(fmt.Sprintf("panic(%q)", decl.Name+"."+d.Name+" not implemented"))
+		} else {
+			if !f.Base.Is(cc.Void) {
+				p.Print("return ")
+			}
+			p.Print(exprPrec{fn, precAddr}, "(")
+			for i, name := range names {
+				if f.Decls[i].Name == "" && f.Decls[i].Type.Is(cc.Void) {
+					continue
+				}
+				if i > 0 {
+					p.Print(", ")
+				}
+				p.Print(name)
+				if f.Decls[i].Name == "..." {
+					p.Print("...")
+				}
+			}
+			p.Print(")")
+		}
+		p.Print(Unindent, Newline, "}")
+	}
+
+	p.Print(Newline, Newline, "var ", decl.Name, " ", decl.Type, " = ", impl, "{}")
+}
+
+// vtableEntries returns the functions the braced initializer init
+// of a table of interface t stores in each of its fields.
+func vtableEntries(t *cc.Type, init *cc.Init) map[*cc.Decl]*cc.Expr {
+	entries := make(map[*cc.Decl]*cc.Expr)
+	i := 0
+	for _, b := range init.Braced {
+		if len(b.Prefix) > 0 && b.Prefix[0].Dot != "" {
+			for j, d := range t.Decls {
+				if d == b.Prefix[0].XDecl || d.Name == b.Prefix[0].Dot {
+					i = j
+				}
+			}
+		}
+		if i < len(t.Decls) {
+			entries[t.Decls[i]] = b.Expr
+		}
+		i++
+	}
+	return entries
+}
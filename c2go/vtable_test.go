@@ -0,0 +1,99 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package c2go_test
+
+import (
+	"testing"
+
+	. "github.com/hajimehoshi/cingo/c2go"
+)
+
+const vtableSrc = `
+typedef struct Obj Obj;
+struct ops {
+	int (*read)(Obj*);
+	void (*close)(Obj*);
+};
+struct Obj {
+	struct ops *ops;
+};
+static int obj_read(Obj *o) { return 0; }
+static void obj_close(Obj *o) {}
+`
+
+var vtableTests = []struct {
+	name string
+	cfg  string
+	src  string
+	want []string // interface diagnostics, as printed
+}{
+	{
+		name: "ok",
+		cfg:  "interface ops\n",
+		src: vtableSrc + `
+static struct ops objops = { obj_read, obj_close };
+`,
+	},
+	{
+		name: "no struct",
+		cfg:  "\ninterface ops nosuch\n",
+		src:  vtableSrc,
+		want: []string{"c2go.cfg:2: no struct nosuch of function pointers to translate as an interface"},
+	},
+	{
+		name: "unset",
+		cfg:  "interface ops\n",
+		src: vtableSrc + `
+static struct ops objops = { .read = obj_read };
+`,
+		want: []string{"x.c:13: error: table objops leaves method close of interface ops unset"},
+	},
+	{
+		name: "zero",
+		cfg:  "interface ops\n",
+		src: vtableSrc + `
+static struct ops objops = { obj_read, 0 };
+`,
+		want: []string{"x.c:13: error: table objops leaves method close of interface ops unset"},
+	},
+	{
+		name: "compare",
+		cfg:  "interface ops\n",
+		src: vtableSrc + `
+void shut(Obj *o)
+{
+	if(o->ops->close != 0)
+		o->ops->close(o);
+}
+`,
+		want: []string{"x.c:15: error: cannot compare interface method o->ops->close != nil in Go"},
+	},
+}
+
+func TestVtableDiagnostics(t *testing.T) {
+	for _, tt := range vtableTests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, diags := ParseConfig("c2go.cfg", []byte(tt.cfg))
+			for _, d := range diags {
+				t.Fatalf("%v", d)
+			}
+			_, diags = Translate(cfg, []Input{{Name: "x.c", Data: []byte(tt.src)}})
+			var got []string
+			for _, d := range diags {
+				if d.Code == "interface" {
+					got = append(got, d.String())
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("interface diagnostics:\n%q\nwant:\n%q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("diagnostic %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -44,6 +44,11 @@ type idecor struct {
 	i *Init
 }
 
+type sudecor struct {
+	d    func(*Type) (*Type, string)
+	bits *Expr
+}
+
 %}
 
 %union {
@@ -56,6 +61,8 @@ type idecor struct {
 	exprs []*Expr
 	idec idecor
 	idecs []idecor
+	sudec sudecor
+	sudecs []sudecor
 	init *Init
 	inits []*Init
 	label *Label
@@ -126,8 +133,9 @@ type idecor struct {
 %type	<decls>	prog xdecl topdecl
 %type	<decls>	sudecl sudecl_list
 %type	<decls> edecl_list
-%type	<decor>	decor sudecor
-%type	<decors>	sudecor_list sudecor_list_opt
+%type	<decor>	decor
+%type	<sudec>	sudecor
+%type	<sudecs>	sudecor_list sudecor_list_opt
 %type	<expr>	expr expr_opt cexpr cexpr_opt eqexpr eqexpr_opt
 %type	<exprs>	expr_list expr_list_opt
 %type	<idec>	idecor
@@ -183,7 +191,8 @@ type idecor struct {
 top:
 	startProg prog tokEOF
 	{
-		yylex.(*lexer).prog = &Prog{Decls: $2}
+		lx := yylex.(*lexer)
+		lx.prog = &Prog{Decls: append($2, lx.macroDecls()...)}
 		return 0
 	}
 |	startExpr cexpr tokEOF
@@ -200,11 +209,32 @@ prog:
 |	prog xdecl
 	{
 		$<span>$ = span($<span>1, $<span>2)
-		$$ = append($1, $2...)
+		$$ = append($1, yylex.(*lexer).macroDecls()...)
+		$$ = append($$, $2...)
 	}
 |	prog tokAUTOLIB '(' tokName ')'
 	{
 	}
+|	prog error ';'
+	{
+		// Skip a broken declaration, abandoning any scopes
+		// it opened, and keep parsing.
+		lx := yylex.(*lexer)
+		for lx.scope.Next != nil {
+			lx.popScope()
+		}
+		$<span>$ = $<span>1
+		$$ = $1
+	}
+|	prog error '}'
+	{
+		lx := yylex.(*lexer)
+		for lx.scope.Next != nil {
+			lx.popScope()
+		}
+		$<span>$ = $<span>1
+		$$ = $1
+	}
 
 cexpr:
 	expr_list
@@ -502,17 +532,33 @@ block1:
 		$<span>$ = span($<span>1, $<span>2)
 		$$ = append($1, $2)
 	}
+|	block1 error ';'
+	{
+		// Skip a broken statement and keep parsing the block.
+		$<span>$ = $<span>1
+		$$ = $1
+	}
 
 block:
-	'{'
+	blockstart block1 '}'
 	{
-		yylex.(*lexer).pushScope()
+		$<span>$ = span($<span>1, $<span>3)
+		yylex.(*lexer).popScope()
+		$$ = &Stmt{SyntaxInfo: SyntaxInfo{Span: $<span>$}, Op: Block, Block: $2}
 	}
-	block1 '}'
+|	blockstart block1 error '}'
 	{
+		// Skip the rest of a broken block.
 		$<span>$ = span($<span>1, $<span>4)
 		yylex.(*lexer).popScope()
-		$$ = &Stmt{SyntaxInfo: SyntaxInfo{Span: $<span>$}, Op: Block, Block: $3}
+		$$ = &Stmt{SyntaxInfo: SyntaxInfo{Span: $<span>$}, Op: Block, Block: $2}
+	}
+
+blockstart:
+	'{'
+	{
+		$<span>$ = $<span>1
+		yylex.(*lexer).pushScope()
 	}
 
 label:
@@ -1090,17 +1136,13 @@ sudecor:
 	decor
 	{
 		$<span>$ = $<span>1
-		$$ = $1
+		$$ = sudecor{$1, nil}
 	}
 |	tag_opt ':' expr
 	{
 		$<span>$ = span($<span>1, $<span>3)
 		name := $1
-		expr := $3
-		$$ = func(t *Type) (*Type, string) {
-			t.Width = expr
-			return t, name
-		}
+		$$ = sudecor{func(t *Type) (*Type, string) { return t, name }, $3}
 	}
 
 sudecl:
@@ -1108,9 +1150,9 @@ sudecl:
 	{
 		$<span>$ = span($<span>1, $<span>3)
 		$$ = nil
-		for _, decor := range $2 {
-			typ, name := decor($1)
-			$$ = append($$, &Decl{SyntaxInfo: SyntaxInfo{Span: $<span>$}, Name: name, Type: typ})
+		for _, sudec := range $2 {
+			typ, name := sudec.d($1)
+			$$ = append($$, &Decl{SyntaxInfo: SyntaxInfo{Span: $<span>$}, Name: name, Type: typ, Bits: sudec.bits})
 		}
 		if $2 == nil {
 			$$ = append($$, &Decl{SyntaxInfo: SyntaxInfo{Span: $<span>$}, Type: $1})
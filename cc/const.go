@@ -0,0 +1,551 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Evaluation of constant expressions.
+
+package cc
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// A Value is the value of a constant expression, with its C type
+// after the usual arithmetic conversions.
+type Value struct {
+	Kind  TypeKind // an integer kind, or Float or Double
+	Int   int64    // value of an integer constant; unsigned values hold their bits
+	Float float64  // value of a floating-point constant
+}
+
+// IsInt reports whether c is an integer constant.
+func (c Value) IsInt() bool {
+	return c.Kind != Float && c.Kind != Double
+}
+
+// Uint64 returns the bits of the integer constant c as a uint64.
+func (c Value) Uint64() uint64 {
+	return uint64(c.Int)
+}
+
+func (c Value) String() string {
+	switch {
+	case !c.IsInt():
+		return strconv.FormatFloat(c.Float, 'g', -1, 64)
+	case c.Kind == Ulonglong || c.Kind == Ulong && c.Int < 0:
+		return strconv.FormatUint(c.Uint64(), 10)
+	}
+	return strconv.FormatInt(c.Int, 10)
+}
+
+// Eval evaluates the constant expression x under the data model m,
+// or the one set by SetModel if m is nil.
+// It applies C's integer promotions and usual arithmetic conversions,
+// wraps unsigned results, and reports an error for signed overflow,
+// division by zero and expressions that are not constant.
+func Eval(x *Expr, m *Model) (Value, error) {
+	if m == nil {
+		m = defaults.model()
+	}
+	return m.eval(x)
+}
+
+func (m *Model) eval(x *Expr) (Value, error) {
+	if x == nil {
+		return Value{}, fmt.Errorf("missing constant expression")
+	}
+	switch x.Op {
+	case Number:
+		return m.number(x.Text)
+
+	case Name:
+		d := x.XDecl
+		if d != nil && d.Macro != nil && d.Init != nil {
+			return m.eval(d.Init.Expr)
+		}
+		if d != nil && d.OuterType != nil && d.OuterType.Kind == Enum {
+			if n, ok := EnumValue(d, m); ok {
+				return Value{Kind: Int, Int: n}, nil
+			}
+		}
+		return Value{}, fmt.Errorf("%s is not a constant", x.Text)
+
+	case Paren:
+		return m.eval(x.Left)
+
+	case SizeofType, SizeofExpr, Offsetof:
+		var n int64 = -1
+		switch x.Op {
+		case SizeofType:
+			n = x.Type.Size(m)
+		case SizeofExpr:
+			if x.Left.XType != nil {
+				n = x.Left.XType.Size(m)
+			}
+		case Offsetof:
+			n = x.Type.Offset(m, x.Left.Text)
+		}
+		if n < 0 {
+			return Value{}, fmt.Errorf("%v is not a constant", x)
+		}
+		return Value{Kind: m.SizeKind(), Int: n}, nil
+
+	case Cast:
+		c, err := m.eval(x.Left)
+		if err != nil {
+			return c, err
+		}
+		t := stripTypedef(x.Type)
+		if t == nil || !isInt(t) && t.Kind != Float && t.Kind != Double {
+			return Value{}, fmt.Errorf("cannot convert constant to %v", x.Type)
+		}
+		return m.convert(c, t.Kind)
+
+	case Plus, Minus, Twid:
+		c, err := m.eval(x.Left)
+		if err != nil {
+			return c, err
+		}
+		c, _ = m.convert(c, m.Promote(c.Kind))
+		switch {
+		case x.Op == Plus:
+			return c, nil
+		case x.Op == Minus && !c.IsInt():
+			return Value{Kind: c.Kind, Float: -c.Float}, nil
+		case x.Op == Minus:
+			return m.arith(Sub, Value{Kind: c.Kind}, c)
+		case !c.IsInt():
+			return Value{}, fmt.Errorf("invalid operand to ~: %v", x.Left)
+		}
+		return m.convert(Value{Kind: c.Kind, Int: ^c.Int}, c.Kind)
+
+	case Not:
+		c, err := m.eval(x.Left)
+		if err != nil {
+			return c, err
+		}
+		return boolValue(c.isZero()), nil
+
+	case AndAnd, OrOr:
+		l, err := m.eval(x.Left)
+		if err != nil {
+			return l, err
+		}
+		if x.Op == AndAnd && l.isZero() || x.Op == OrOr && !l.isZero() {
+			return boolValue(x.Op == OrOr), nil
+		}
+		r, err := m.eval(x.Right)
+		if err != nil {
+			return r, err
+		}
+		return boolValue(!r.isZero()), nil
+
+	case Cond:
+		cond, err := m.eval(x.List[0])
+		if err != nil {
+			return cond, err
+		}
+		y, z := x.List[1], x.List[2]
+		if cond.isZero() {
+			y, z = z, y
+		}
+		c, err := m.eval(y)
+		if err != nil {
+			return c, err
+		}
+		// The result has the type of both branches together,
+		// though only the chosen one is evaluated.
+		if other, err := m.eval(z); err == nil {
+			return m.convert(c, m.Common(c.Kind, other.Kind))
+		}
+		return c, nil
+
+	case Comma:
+		return m.eval(x.List[len(x.List)-1])
+
+	case Lsh, Rsh:
+		l, err := m.eval(x.Left)
+		if err != nil {
+			return l, err
+		}
+		r, err := m.eval(x.Right)
+		if err != nil {
+			return r, err
+		}
+		if !l.IsInt() || !r.IsInt() {
+			return Value{}, fmt.Errorf("invalid operands to shift: %v", x)
+		}
+		l, _ = m.convert(l, m.Promote(l.Kind))
+		return m.shift(x.Op, l, r)
+
+	case Add, Sub, Mul, Div, Mod, And, Or, Xor, EqEq, NotEq, Lt, LtEq, Gt, GtEq:
+		l, err := m.eval(x.Left)
+		if err != nil {
+			return l, err
+		}
+		r, err := m.eval(x.Right)
+		if err != nil {
+			return r, err
+		}
+		k := m.Common(l.Kind, r.Kind)
+		if l, err = m.convert(l, k); err != nil {
+			return l, err
+		}
+		if r, err = m.convert(r, k); err != nil {
+			return r, err
+		}
+		return m.arith(x.Op, l, r)
+	}
+	return Value{}, fmt.Errorf("%v is not a constant expression", x)
+}
+
+// StringValue returns the value of the string literal x:
+// its adjacent literals joined, with escape sequences decoded,
+// and without the terminating NUL.
+func StringValue(x *Expr) (string, error) {
+	if x == nil || x.Op != String {
+		return "", fmt.Errorf("%v is not a string literal", x)
+	}
+	var lx lexer
+	var str []string
+	for _, text := range x.Texts {
+		s, ok := lx.parseString(text)
+		if !ok {
+			return "", fmt.Errorf("invalid string literal %s", text)
+		}
+		str = append(str, s)
+	}
+	return strings.Join(str, ""), nil
+}
+
+// number returns the value of the numeric or character constant text,
+// with the type C gives it: the first of the types allowed by its
+// suffix and base in which its value fits.
+func (m *Model) number(text string) (Value, error) {
+	if text[0] == '\'' {
+		var lx lexer
+		b, ok := lx.parseChar(text)
+		if !ok {
+			return Value{}, fmt.Errorf("invalid character constant %s", text)
+		}
+		return Value{Kind: Int, Int: m.Truncate(Char, int64(b))}, nil
+	}
+
+	hex := strings.HasPrefix(strings.ToLower(text), "0x")
+	if strings.Contains(text, ".") || hex && strings.ContainsAny(text, "pP") || !hex && strings.ContainsAny(text, "eE") {
+		num := strings.TrimRight(text, "fFlL")
+		f, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return Value{}, fmt.Errorf("invalid floating point constant %s", text)
+		}
+		if s := text[len(num):]; s == "f" || s == "F" {
+			return Value{Kind: Float, Float: float64(float32(f))}, nil
+		}
+		return Value{Kind: Double, Float: f}, nil
+	}
+
+	num := strings.TrimRight(text, "uUlL")
+	n, err := strconv.ParseUint(num, 0, 64)
+	if err != nil {
+		return Value{}, fmt.Errorf("invalid integer constant %s", text)
+	}
+	suf := strings.ToUpper(text[len(num):])
+	decimal := num == "0" || num[0] != '0'
+	var kinds []TypeKind
+	switch {
+	case strings.Contains(suf, "U") && strings.Contains(suf, "LL"):
+		kinds = []TypeKind{Ulonglong}
+	case strings.Contains(suf, "U") && strings.Contains(suf, "L"):
+		kinds = []TypeKind{Ulong, Ulonglong}
+	case strings.Contains(suf, "U"):
+		kinds = []TypeKind{Uint, Ulong, Ulonglong}
+	case strings.Contains(suf, "LL") && decimal:
+		kinds = []TypeKind{Longlong}
+	case strings.Contains(suf, "LL"):
+		kinds = []TypeKind{Longlong, Ulonglong}
+	case strings.Contains(suf, "L") && decimal:
+		kinds = []TypeKind{Long, Longlong}
+	case strings.Contains(suf, "L"):
+		kinds = []TypeKind{Long, Ulong, Longlong, Ulonglong}
+	case decimal:
+		kinds = []TypeKind{Int, Long, Longlong}
+	default:
+		kinds = []TypeKind{Int, Uint, Long, Ulong, Longlong, Ulonglong}
+	}
+	for _, k := range kinds {
+		if m.fits(k, n) {
+			return Value{Kind: k, Int: int64(n)}, nil
+		}
+	}
+	return Value{}, fmt.Errorf("integer constant %s is too large for its type", text)
+}
+
+// fits reports whether the non-negative value n fits in the integer kind k.
+func (m *Model) fits(k TypeKind, n uint64) bool {
+	bits := uint(8 * m.KindSize(k))
+	if m.IsSigned(k) {
+		bits--
+	}
+	return bits >= 64 || n < 1<<bits
+}
+
+// SizeKind returns the kind of size_t, the type of sizeof and offsetof.
+func (m *Model) SizeKind() TypeKind {
+	if m.Long < m.Ptr {
+		return Ulonglong
+	}
+	return Ulong
+}
+
+// Promote returns the kind that values of kind k have
+// after C's integer promotions.
+func (m *Model) Promote(k TypeKind) TypeKind {
+	switch k {
+	case Char, Uchar, Short, Ushort, Enum:
+		if m.KindSize(k) < m.Int || m.IsSigned(k) {
+			return Int
+		}
+		return Uint
+	}
+	return k
+}
+
+var intRank = map[TypeKind]int{
+	Int:       1,
+	Uint:      1,
+	Long:      2,
+	Ulong:     2,
+	Longlong:  3,
+	Ulonglong: 3,
+}
+
+var unsignedKind = map[TypeKind]TypeKind{
+	Int:      Uint,
+	Long:     Ulong,
+	Longlong: Ulonglong,
+}
+
+// Common returns the kind that operands of kinds k1 and k2 are
+// converted to by C's usual arithmetic conversions.
+func (m *Model) Common(k1, k2 TypeKind) TypeKind {
+	if k1 == Double || k2 == Double {
+		return Double
+	}
+	if k1 == Float || k2 == Float {
+		return Float
+	}
+	k1, k2 = m.Promote(k1), m.Promote(k2)
+	if k1 == k2 {
+		return k1
+	}
+	s1, s2 := m.IsSigned(k1), m.IsSigned(k2)
+	if s1 == s2 {
+		if intRank[k1] > intRank[k2] {
+			return k1
+		}
+		return k2
+	}
+	if s1 {
+		k1, k2 = k2, k1
+	}
+	// Now k1 is unsigned and k2 signed.
+	switch {
+	case intRank[k1] >= intRank[k2]:
+		return k1
+	case m.KindSize(k2) > m.KindSize(k1):
+		return k2
+	}
+	return unsignedKind[k2]
+}
+
+// convert converts c to the kind k as C does,
+// wrapping integers and truncating floating-point values toward zero.
+func (m *Model) convert(c Value, k TypeKind) (Value, error) {
+	switch {
+	case k == Float || k == Double:
+		f := c.Float
+		if c.IsInt() {
+			f = float64(c.Int)
+			if !m.IsSigned(c.Kind) {
+				f = float64(c.Uint64())
+			}
+		}
+		if k == Float {
+			f = float64(float32(f))
+		}
+		return Value{Kind: k, Float: f}, nil
+
+	case !c.IsInt():
+		f := math.Trunc(c.Float)
+		var v *big.Int
+		if !math.IsInf(f, 0) && !math.IsNaN(f) {
+			v, _ = big.NewFloat(f).Int(nil)
+		}
+		if v == nil || !m.inRange(k, v) {
+			return Value{}, fmt.Errorf("constant %v overflows %v", c, k)
+		}
+		if v.IsInt64() {
+			return Value{Kind: k, Int: v.Int64()}, nil
+		}
+		return Value{Kind: k, Int: int64(v.Uint64())}, nil
+	}
+	return Value{Kind: k, Int: m.Truncate(k, c.Int)}, nil
+}
+
+// inRange reports whether v is a value of the integer kind k.
+func (m *Model) inRange(k TypeKind, v *big.Int) bool {
+	bits := uint(8 * m.KindSize(k))
+	min, max := new(big.Int), new(big.Int)
+	if m.IsSigned(k) {
+		min.Lsh(big.NewInt(1), bits-1).Neg(min)
+		max.Lsh(big.NewInt(1), bits-1)
+	} else {
+		max.Lsh(big.NewInt(1), bits)
+	}
+	return v.Cmp(min) >= 0 && v.Cmp(max) < 0
+}
+
+// bigInt returns the integer constant c as a big.Int.
+func (m *Model) bigInt(c Value) *big.Int {
+	if m.IsSigned(c.Kind) {
+		return big.NewInt(c.Int)
+	}
+	return new(big.Int).SetUint64(c.Uint64())
+}
+
+// arith applies the binary operator op to l and r,
+// which have the same kind.
+func (m *Model) arith(op ExprOp, l, r Value) (Value, error) {
+	k := l.Kind
+	if !l.IsInt() {
+		x, y := l.Float, r.Float
+		switch op {
+		case Add:
+			return Value{Kind: k, Float: x + y}, nil
+		case Sub:
+			return Value{Kind: k, Float: x - y}, nil
+		case Mul:
+			return Value{Kind: k, Float: x * y}, nil
+		case Div:
+			return Value{Kind: k, Float: x / y}, nil
+		case EqEq:
+			return boolValue(x == y), nil
+		case NotEq:
+			return boolValue(x != y), nil
+		case Lt:
+			return boolValue(x < y), nil
+		case LtEq:
+			return boolValue(x <= y), nil
+		case Gt:
+			return boolValue(x > y), nil
+		case GtEq:
+			return boolValue(x >= y), nil
+		}
+		return Value{}, fmt.Errorf("invalid floating-point operation %v", op)
+	}
+
+	x, y := m.bigInt(l), m.bigInt(r)
+	switch op {
+	case EqEq:
+		return boolValue(x.Cmp(y) == 0), nil
+	case NotEq:
+		return boolValue(x.Cmp(y) != 0), nil
+	case Lt:
+		return boolValue(x.Cmp(y) < 0), nil
+	case LtEq:
+		return boolValue(x.Cmp(y) <= 0), nil
+	case Gt:
+		return boolValue(x.Cmp(y) > 0), nil
+	case GtEq:
+		return boolValue(x.Cmp(y) >= 0), nil
+	case And:
+		return Value{Kind: k, Int: l.Int & r.Int}, nil
+	case Or:
+		return Value{Kind: k, Int: l.Int | r.Int}, nil
+	case Xor:
+		return Value{Kind: k, Int: m.Truncate(k, l.Int^r.Int)}, nil
+	}
+
+	z := new(big.Int)
+	switch op {
+	case Add:
+		z.Add(x, y)
+	case Sub:
+		z.Sub(x, y)
+	case Mul:
+		z.Mul(x, y)
+	case Div, Mod:
+		if y.Sign() == 0 {
+			return Value{}, fmt.Errorf("division by zero")
+		}
+		// C division truncates toward zero, as Quo and Rem do.
+		if op == Div {
+			z.Quo(x, y)
+		} else {
+			z.Rem(x, y)
+		}
+	}
+	if m.IsSigned(k) {
+		if !m.inRange(k, z) {
+			return Value{}, fmt.Errorf("constant %v overflows %v", z, k)
+		}
+		return Value{Kind: k, Int: z.Int64()}, nil
+	}
+	// Unsigned arithmetic wraps.
+	z.And(z, new(big.Int).SetUint64(math.MaxUint64))
+	return Value{Kind: k, Int: m.Truncate(k, int64(z.Uint64()))}, nil
+}
+
+// shift shifts the promoted integer constant l by r bits.
+// Shifting a signed value into its sign bit is allowed, as compilers do,
+// but shifting bits out of it is an overflow.
+func (m *Model) shift(op ExprOp, l, r Value) (Value, error) {
+	k := l.Kind
+	bits := 8 * m.KindSize(k)
+	n := r.Int
+	if m.IsSigned(r.Kind) && n < 0 || n >= bits {
+		return Value{}, fmt.Errorf("shift count %v out of range for %v", r, k)
+	}
+	if op == Rsh {
+		if m.IsSigned(k) {
+			return Value{Kind: k, Int: l.Int >> uint(n)}, nil
+		}
+		return Value{Kind: k, Int: int64(l.Uint64() >> uint(n))}, nil
+	}
+	z := new(big.Int).Lsh(m.bigInt(l), uint(n))
+	switch {
+	case !m.IsSigned(k):
+	case m.inRange(k, z):
+		return Value{Kind: k, Int: z.Int64()}, nil
+	case z.Sign() < 0 || !m.inRange(unsignedOf(k), z):
+		return Value{}, fmt.Errorf("constant %v << %v overflows %v", l, r, k)
+	}
+	z.And(z, new(big.Int).SetUint64(math.MaxUint64))
+	return Value{Kind: k, Int: m.Truncate(k, int64(z.Uint64()))}, nil
+}
+
+// unsignedOf returns the unsigned kind with the size of the integer kind k.
+func unsignedOf(k TypeKind) TypeKind {
+	if u, ok := unsignedKind[k]; ok {
+		return u
+	}
+	return k
+}
+
+func (c Value) isZero() bool {
+	if c.IsInt() {
+		return c.Int == 0
+	}
+	return c.Float == 0
+}
+
+func boolValue(b bool) Value {
+	if b {
+		return Value{Kind: Int, Int: 1}
+	}
+	return Value{Kind: Int}
+}
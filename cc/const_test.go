@@ -0,0 +1,151 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cc_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/hajimehoshi/cingo/cc"
+)
+
+var evalTests = []struct {
+	in   string
+	kind TypeKind
+	val  string
+	err  string
+}{
+	{in: "1 + 2 * 3", kind: Int, val: "7"},
+	{in: "7 / -2", kind: Int, val: "-3"},
+	{in: "7 % -2", kind: Int, val: "1"},
+	{in: "2147483647", kind: Int, val: "2147483647"},
+	{in: "2147483648", kind: Long, val: "2147483648"},
+	{in: "0x80000000", kind: Uint, val: "2147483648"},
+	{in: "0xffffffffffffffff", kind: Ulong, val: "18446744073709551615"},
+	{in: "10u", kind: Uint, val: "10"},
+	{in: "10LL", kind: Longlong, val: "10"},
+	{in: "'a'", kind: Int, val: "97"},
+	{in: "'\\377'", kind: Int, val: "-1"},
+	{in: "-1u", kind: Uint, val: "4294967295"},
+	{in: "-1 < 0u", kind: Int, val: "0"},
+	{in: "-1 < 0L", kind: Int, val: "1"},
+	{in: "-1L < 0u", kind: Int, val: "1"},
+	{in: "~0", kind: Int, val: "-1"},
+	{in: "~0u >> 28", kind: Uint, val: "15"},
+	{in: "-8 >> 1", kind: Int, val: "-4"},
+	{in: "1 << 31", kind: Int, val: "-2147483648"},
+	{in: "(char)300", kind: Char, val: "44"},
+	{in: "(unsigned char)-1 + 1", kind: Int, val: "256"},
+	{in: "sizeof(int) - 5", kind: Ulong, val: "18446744073709551615"},
+	{in: "1 ? 2 : 3u", kind: Uint, val: "2"},
+	{in: "0 && 1 / 0", kind: Int, val: "0"},
+	{in: "!5 || 3 == 3", kind: Int, val: "1"},
+	{in: "1.5 * 2", kind: Double, val: "3"},
+	{in: "1.5f", kind: Float, val: "1.5"},
+	{in: "(int)2.9", kind: Int, val: "2"},
+	{in: "2147483647 + 1", err: "overflows"},
+	{in: "-2147483647 - 2", err: "overflows"},
+	{in: "4294967295u + 1", kind: Uint, val: "0"},
+	{in: "1 << 32", err: "out of range"},
+	{in: "3 << 31", err: "overflows"},
+	{in: "1 / 0", err: "division by zero"},
+	{in: "1 % 0", err: "division by zero"},
+	{in: "x + 1", err: "not a constant"},
+}
+
+func TestEval(t *testing.T) {
+	for _, tt := range evalTests {
+		prog, err := Read("x.c", strings.NewReader("int x;\nlong y = "+tt.in+";\n"))
+		if err != nil {
+			t.Errorf("Read(%q): %v", tt.in, err)
+			continue
+		}
+		c, err := Eval(prog.Decls[len(prog.Decls)-1].Init.Expr, LP64)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Eval(%q) = %v, %v, want error containing %q", tt.in, c, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Eval(%q): %v", tt.in, err)
+			continue
+		}
+		if c.Kind != tt.kind || c.String() != tt.val {
+			t.Errorf("Eval(%q) = %v %v, want %v %v", tt.in, c.Kind, c, tt.kind, tt.val)
+		}
+	}
+}
+
+func TestEvalModel(t *testing.T) {
+	prog, err := Read("x.c", strings.NewReader("long y = 2147483648;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := prog.Decls[0].Init.Expr
+	if c, err := Eval(x, ILP32); err != nil || c.Kind != Longlong {
+		t.Errorf("Eval(2147483648, ILP32) = %v %v, %v, want longlong", c.Kind, c, err)
+	}
+	if c, err := Eval(x, LP64); err != nil || c.Kind != Long {
+		t.Errorf("Eval(2147483648, LP64) = %v %v, %v, want long", c.Kind, c, err)
+	}
+}
+
+func TestEnumValues(t *testing.T) {
+	prog, err := Read("x.c", strings.NewReader("enum { A = -2, B, C = 1 << 4, D };\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values []string
+	for _, d := range prog.Decls[0].Type.Decls {
+		if d.Value == nil {
+			t.Errorf("%s has no value", d.Name)
+			continue
+		}
+		values = append(values, d.Name+"="+d.Value.String())
+	}
+	if got, want := strings.Join(values, " "), "A=-2 B=-1 C=16 D=17"; got != want {
+		t.Errorf("values = %s, want %s", got, want)
+	}
+
+	_, err = Read("x.c", strings.NewReader("int n;\nenum { A = n };\n"))
+	if err == nil || !strings.Contains(err.Error(), "invalid value for enumerator A") {
+		t.Errorf("Read with non-constant enumerator: %v, want error", err)
+	}
+}
+
+func TestInferWidth(t *testing.T) {
+	tests := []struct {
+		in   string
+		size int64
+	}{
+		{"int x[] = {1, 2, 3};", 12},
+		{"char x[] = {'a', 'b'};", 2},
+		{"enum { N = 4 };\nlong x[] = {[N] = 1, [1] = 2};", 40},
+		{"char x[] = \"abc\";", 4},
+	}
+	for _, tt := range tests {
+		prog, err := Read("x.c", strings.NewReader(tt.in))
+		if err != nil {
+			t.Errorf("Read(%q): %v", tt.in, err)
+			continue
+		}
+		typ := prog.Decls[len(prog.Decls)-1].Type
+		if size := typ.Size(LP64); size != tt.size {
+			t.Errorf("Read(%q): size = %d, want %d", tt.in, size, tt.size)
+		}
+	}
+}
+
+func TestStringValue(t *testing.T) {
+	prog, err := Read("x.c", strings.NewReader(`char *s = "a\tb" "\x41\101\0c";`+"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := StringValue(prog.Decls[0].Init.Expr)
+	if want := "a\tbAA\x00c"; err != nil || s != want {
+		t.Errorf("StringValue = %q, %v, want %q", s, err, want)
+	}
+}
@@ -0,0 +1,1021 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// C preprocessor: macro definition and expansion, and conditional compilation.
+// C99 standard §6.10.
+
+package cc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A macro is a preprocessor macro defined by #define or by Define.
+type macro struct {
+	name     string
+	function bool     // function-like macro
+	params   []string // parameter names, for function-like macros
+	variadic bool     // last parameter is ... (named __VA_ARGS__)
+	body     []ppToken
+	span     Span
+	x        *Macro // translation, nil for predefined and empty macros
+}
+
+// A hdrMacro records a #define (m != nil) or #undef (m == nil)
+// seen while reading a header, so that it can be replayed
+// when the header is included again.
+type hdrMacro struct {
+	name string
+	m    *macro
+}
+
+type ppKind int
+
+const (
+	ppIdent ppKind = iota
+	ppNumber
+	ppString
+	ppChar
+	ppPunct
+)
+
+// A ppToken is a preprocessing token.
+type ppToken struct {
+	kind  ppKind
+	text  string
+	space bool            // preceded by white space
+	hide  map[string]bool // names of macros that must not expand this token
+}
+
+type predef struct {
+	name  string
+	value string
+	undef bool
+}
+
+// stdPredefs are the macros predefined by every C compiler.
+var stdPredefs = []predef{
+	{name: "__STDC__", value: "1"},
+	{name: "__STDC_VERSION__", value: "199901L"},
+}
+
+// initMacros resets the macro table to the predefined macros.
+func (lx *lexer) initMacros() {
+	lx.macros = make(map[string]*macro)
+	lx.macroNames = make(map[string]*macro)
+	var list []predef
+	list = append(list, lx.opts.model().predefs()...)
+	list = append(list, stdPredefs...)
+	if lx.opts != nil {
+		list = append(list, lx.opts.predefs...)
+	}
+	for _, p := range list {
+		if p.undef {
+			delete(lx.macros, p.name)
+			continue
+		}
+		lx.macros[p.name] = &macro{
+			name: p.name,
+			body: tokenize(p.value),
+			span: Span{Start: Pos{File: "<command-line>"}},
+		}
+	}
+}
+
+func isPPIdentStart(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || c == '_' || c >= 0x80
+}
+
+var ppPuncts = []string{
+	"...", "<<=", ">>=",
+	"->", "++", "--", "<<", ">>", "<=", ">=", "==", "!=", "&&", "||",
+	"*=", "/=", "%=", "+=", "-=", "&=", "^=", "|=", "##",
+}
+
+// tokenize splits s into preprocessing tokens.
+// Comments are treated as white space.
+func tokenize(s string) []ppToken {
+	var toks []ppToken
+	space := false
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case isspace(c) || c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			space = true
+			i++
+			continue
+		case c == '/' && i+1 < len(s) && s[i+1] == '*':
+			j := strings.Index(s[i+2:], "*/")
+			if j < 0 {
+				i = len(s)
+			} else {
+				i += 2 + j + 2
+			}
+			space = true
+			continue
+		case c == '/' && i+1 < len(s) && s[i+1] == '/':
+			j := strings.Index(s[i:], "\n")
+			if j < 0 {
+				i = len(s)
+			} else {
+				i += j
+			}
+			space = true
+			continue
+		}
+
+		start := i
+		kind := ppPunct
+		switch {
+		case c == 'L' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\''):
+			i++
+			fallthrough
+		case c == '"' || c == '\'':
+			q := s[i]
+			kind = ppString
+			if q == '\'' {
+				kind = ppChar
+			}
+			for i++; i < len(s) && s[i] != q && s[i] != '\n'; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+			if i < len(s) && s[i] == q {
+				i++
+			}
+		case '0' <= c && c <= '9' || c == '.' && i+1 < len(s) && '0' <= s[i+1] && s[i+1] <= '9':
+			kind = ppNumber
+			for i++; i < len(s); i++ {
+				if strings.IndexByte("eEpP", s[i-1]) >= 0 && (s[i] == '+' || s[i] == '-') {
+					continue
+				}
+				if !isalpha(s[i]) && s[i] != '.' {
+					break
+				}
+			}
+		case isPPIdentStart(c):
+			kind = ppIdent
+			for i++; i < len(s) && isalpha(s[i]); i++ {
+			}
+		default:
+			i++
+			for _, p := range ppPuncts {
+				if strings.HasPrefix(s[start:], p) {
+					i = start + len(p)
+					break
+				}
+			}
+		}
+		toks = append(toks, ppToken{kind: kind, text: s[start:i], space: space})
+		space = false
+	}
+	return toks
+}
+
+// joinTokens converts toks back into text suitable for the lexer.
+func joinTokens(toks []ppToken) string {
+	var b strings.Builder
+	for i, t := range toks {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(t.text)
+	}
+	return b.String()
+}
+
+// stringize implements the # operator.
+func stringize(toks []ppToken) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, t := range toks {
+		if i > 0 && t.space {
+			b.WriteByte(' ')
+		}
+		if t.kind == ppString || t.kind == ppChar {
+			for j := 0; j < len(t.text); j++ {
+				if t.text[j] == '"' || t.text[j] == '\\' {
+					b.WriteByte('\\')
+				}
+				b.WriteByte(t.text[j])
+			}
+			continue
+		}
+		b.WriteString(t.text)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// directive processes the preprocessing directive line,
+// which starts with #.
+func (lx *lexer) directive(line string) {
+	line = strings.Replace(line, "\\\n", "", -1)
+	toks := tokenize(strings.TrimPrefix(line, "#"))
+	if len(toks) == 0 {
+		return // null directive
+	}
+	name, args := toks[0].text, toks[1:]
+
+	// Conditionals are processed even inside skipped groups.
+	switch name {
+	case "if", "ifdef", "ifndef":
+		if lx.skipping() {
+			lx.ifs = append(lx.ifs, ifState{parentSkip: true})
+			return
+		}
+		var cond bool
+		switch name {
+		case "if":
+			cond = lx.ppCond(args)
+		case "ifdef", "ifndef":
+			if len(args) == 0 || args[0].kind != ppIdent {
+				lx.Errorf("#%s without macro name", name)
+				break
+			}
+			cond = lx.macros[args[0].text] != nil
+			if name == "ifndef" {
+				cond = !cond
+			}
+		}
+		lx.ifs = append(lx.ifs, ifState{active: cond, taken: cond})
+		return
+
+	case "elif":
+		if len(lx.ifs) == 0 {
+			lx.Errorf("#elif without #if")
+			return
+		}
+		s := &lx.ifs[len(lx.ifs)-1]
+		if s.sawElse {
+			lx.Errorf("#elif after #else")
+		}
+		s.active = false
+		if !s.parentSkip && !s.taken && lx.ppCond(args) {
+			s.active = true
+			s.taken = true
+		}
+		return
+
+	case "else":
+		if len(lx.ifs) == 0 {
+			lx.Errorf("#else without #if")
+			return
+		}
+		s := &lx.ifs[len(lx.ifs)-1]
+		if s.sawElse {
+			lx.Errorf("#else after #else")
+		}
+		s.sawElse = true
+		s.active = !s.parentSkip && !s.taken
+		s.taken = true
+		return
+
+	case "endif":
+		if len(lx.ifs) == 0 {
+			lx.Errorf("#endif without #if")
+			return
+		}
+		lx.ifs = lx.ifs[:len(lx.ifs)-1]
+		return
+	}
+
+	if lx.skipping() {
+		return
+	}
+
+	switch name {
+	case "include":
+		if len(args) > 0 && args[0].kind == ppIdent {
+			// #include MACRO
+			line = "#include " + joinTokens(lx.expand(args))
+		}
+		lx.pushInclude(line)
+
+	case "define":
+		lx.define(args)
+
+	case "undef":
+		if len(args) == 0 || args[0].kind != ppIdent {
+			lx.Errorf("#undef without macro name")
+			return
+		}
+		delete(lx.macros, args[0].text)
+		if hdr := lx.declSave; hdr != nil {
+			hdr.macros = append(hdr.macros, hdrMacro{name: args[0].text})
+		}
+
+	case "error":
+		lx.Errorf("#error %s", joinTokens(args))
+
+	default:
+		// #line, #pragma, #warning, #ident and friends are ignored.
+	}
+}
+
+// define processes the tokens following #define.
+func (lx *lexer) define(toks []ppToken) {
+	if len(toks) == 0 || toks[0].kind != ppIdent {
+		lx.Errorf("#define without macro name")
+		return
+	}
+	m := &macro{name: toks[0].text, span: lx.span()}
+	toks = toks[1:]
+	if len(toks) > 0 && toks[0].text == "(" && !toks[0].space {
+		m.function = true
+		toks = toks[1:]
+		for {
+			if len(toks) == 0 {
+				lx.Errorf("missing ) in parameter list of macro %s", m.name)
+				return
+			}
+			t := toks[0]
+			toks = toks[1:]
+			if t.text == ")" && len(m.params) == 0 {
+				break
+			}
+			switch {
+			case t.text == "...":
+				m.variadic = true
+				m.params = append(m.params, "__VA_ARGS__")
+			case t.kind == ppIdent:
+				m.params = append(m.params, t.text)
+			default:
+				lx.Errorf("invalid parameter %s in macro %s", t.text, m.name)
+				return
+			}
+			if len(toks) == 0 {
+				continue
+			}
+			if toks[0].text == ")" {
+				toks = toks[1:]
+				break
+			}
+			if toks[0].text != "," || m.variadic {
+				lx.Errorf("invalid parameter list in macro %s", m.name)
+				return
+			}
+			toks = toks[1:]
+		}
+	}
+	if len(toks) > 0 {
+		toks[0].space = false
+	}
+	m.body = toks
+	if old := lx.macroNames[m.name]; old != nil && old.sameAs(m) {
+		m = old // benign redefinition
+	} else {
+		lx.translateMacro(m)
+	}
+	lx.macros[m.name] = m
+	if hdr := lx.declSave; hdr != nil {
+		hdr.macros = append(hdr.macros, hdrMacro{name: m.name, m: m})
+	}
+}
+
+// sameAs reports whether m and n have the same parameters and replacement list.
+func (m *macro) sameAs(n *macro) bool {
+	if m.function != n.function || m.variadic != n.variadic || len(m.params) != len(n.params) || len(m.body) != len(n.body) {
+		return false
+	}
+	for i := range m.params {
+		if m.params[i] != n.params[i] {
+			return false
+		}
+	}
+	for i := range m.body {
+		if m.body[i].text != n.body[i].text || i > 0 && m.body[i].space != n.body[i].space {
+			return false
+		}
+	}
+	return true
+}
+
+// An ifState records the state of one level of #if nesting.
+type ifState struct {
+	active     bool // current group is being compiled
+	taken      bool // some group at this level has been (or must not be) compiled
+	sawElse    bool
+	parentSkip bool // enclosing group is being skipped
+}
+
+func (lx *lexer) skipping() bool {
+	return len(lx.ifs) > 0 && !lx.ifs[len(lx.ifs)-1].active
+}
+
+// skipGroup skips input lines until the next line that begins with #.
+func (lx *lexer) skipGroup() {
+	in := lx.input
+	i := 0
+	for i < len(in) {
+		j := i
+		for j < len(in) && (in[j] == ' ' || in[j] == '\t') {
+			j++
+		}
+		if j < len(in) && in[j] == '#' {
+			i = j
+			break
+		}
+		k := strings.IndexByte(in[j:], '\n')
+		if k < 0 {
+			i = len(in)
+			break
+		}
+		i = j + k + 1
+	}
+	lx.skip(i)
+}
+
+// expandName expands the macro invocation starting with the
+// identifier name, which has just been read from the input.
+// It reports whether name was a macro and the expansion
+// has been pushed onto the input stack.
+func (lx *lexer) expandName(name string, pos Pos) bool {
+	m := lx.macros[name]
+	if m == nil && name != "__FILE__" && name != "__LINE__" {
+		return false
+	}
+	toks := []ppToken{{kind: ppIdent, text: name}}
+	if m != nil && m.function {
+		args, ok := lx.readArgs()
+		if !ok {
+			return false
+		}
+		toks = append(toks, tokenize(args)...)
+	}
+	toks = lx.expand(toks)
+
+	// The expansion may end in the name of a function-like macro
+	// whose arguments follow in the input.
+	for len(toks) > 0 {
+		t := toks[len(toks)-1]
+		if m := lx.macros[t.text]; t.kind != ppIdent || m == nil || !m.function || t.hide[t.text] {
+			break
+		}
+		args, ok := lx.readArgs()
+		if !ok {
+			break
+		}
+		toks = lx.expand(append(toks, tokenize(args)...))
+	}
+
+	lx.pushed = append(lx.pushed, lx.lexInput)
+	str := joinTokens(toks) + "\n"
+	lx.lexInput = lexInput{
+		input:      str,
+		wholeInput: str,
+		file:       lx.file,
+		lineno:     lx.lineno,
+		declSave:   lx.declSave,
+		expansion:  true,
+		expandPos:  pos,
+		ifDepth:    len(lx.ifs),
+	}
+	return true
+}
+
+// readArgs reads the parenthesized argument list of a function-like
+// macro invocation from the input. If the next token is not (,
+// readArgs leaves the input unchanged and returns ok == false.
+func (lx *lexer) readArgs() (args string, ok bool) {
+	in := lx.input
+	i := 0
+	for i < len(in) && isspace(in[i]) {
+		i++
+	}
+	if i >= len(in) || in[i] != '(' {
+		return "", false
+	}
+	start := i
+	depth := 0
+	for ; i < len(in); i++ {
+		switch c := in[i]; c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				args = in[start : i+1]
+				lx.skip(i + 1)
+				return args, true
+			}
+		case '"', '\'':
+			for i++; i < len(in) && in[i] != c && in[i] != '\n'; i++ {
+				if in[i] == '\\' {
+					i++
+				}
+			}
+		case '/':
+			if i+1 < len(in) && in[i+1] == '*' {
+				if j := strings.Index(in[i+2:], "*/"); j >= 0 {
+					i += 2 + j + 1
+				}
+			}
+		}
+	}
+	lx.Errorf("unterminated argument list invoking macro")
+	return "", false
+}
+
+// expand macro-expands toks.
+// The algorithm is Dave Prosser's, as described in
+// https://www.spinellis.gr/blog/20060626/cpp.algo.pdf.
+func (lx *lexer) expand(toks []ppToken) []ppToken {
+	var out []ppToken
+	for len(toks) > 0 {
+		t := toks[0]
+		m := lx.macros[t.text]
+		if t.kind != ppIdent || t.hide[t.text] {
+			out = append(out, t)
+			toks = toks[1:]
+			continue
+		}
+		switch t.text {
+		case "__FILE__":
+			out = append(out, ppToken{kind: ppString, text: strconv.Quote(lx.pos().File), space: t.space})
+			toks = toks[1:]
+			continue
+		case "__LINE__":
+			out = append(out, ppToken{kind: ppNumber, text: fmt.Sprint(lx.pos().Line), space: t.space})
+			toks = toks[1:]
+			continue
+		}
+		if m == nil {
+			out = append(out, t)
+			toks = toks[1:]
+			continue
+		}
+
+		if m.x != nil {
+			m.x.used = true
+		}
+		if !m.function {
+			hide := addHide(t.hide, m.name)
+			repl := lx.subst(m, nil, hide)
+			if len(repl) > 0 {
+				repl[0].space = t.space
+			}
+			toks = append(repl, toks[1:]...)
+			continue
+		}
+
+		if len(toks) < 2 || toks[1].text != "(" {
+			out = append(out, t)
+			toks = toks[1:]
+			continue
+		}
+		args, rest, rparen, ok := lx.collectArgs(m, toks[2:])
+		if !ok {
+			return append(out, toks...)
+		}
+		hide := addHide(intersectHide(t.hide, rparen.hide), m.name)
+		repl := lx.subst(m, args, hide)
+		if len(repl) > 0 {
+			repl[0].space = t.space
+		}
+		toks = append(repl, rest...)
+	}
+	return out
+}
+
+// collectArgs collects the arguments of an invocation of m
+// from toks, which start just after the opening parenthesis.
+func (lx *lexer) collectArgs(m *macro, toks []ppToken) (args [][]ppToken, rest []ppToken, rparen ppToken, ok bool) {
+	depth := 0
+	var cur []ppToken
+	for i, t := range toks {
+		switch t.text {
+		case "(":
+			depth++
+		case ")":
+			if depth > 0 {
+				depth--
+				break
+			}
+			args = append(args, cur)
+			if len(args) == 1 && len(args[0]) == 0 && len(m.params) == 0 {
+				args = nil
+			}
+			if len(args) != len(m.params) && !(m.variadic && len(args) == len(m.params)-1) {
+				lx.Errorf("macro %s takes %d arguments, have %d", m.name, len(m.params), len(args))
+				return nil, nil, ppToken{}, false
+			}
+			return args, toks[i+1:], t, true
+		case ",":
+			if depth == 0 && !(m.variadic && len(args) == len(m.params)-1) {
+				args = append(args, cur)
+				cur = nil
+				continue
+			}
+		}
+		cur = append(cur, t)
+	}
+	lx.Errorf("unterminated argument list invoking macro %s", m.name)
+	return nil, nil, ppToken{}, false
+}
+
+// subst returns the replacement list of m with args substituted
+// for parameters, # and ## applied, and hide added to every token's hide set.
+func (lx *lexer) subst(m *macro, args [][]ppToken, hide map[string]bool) []ppToken {
+	arg := func(name string) ([]ppToken, bool) {
+		for i, p := range m.params {
+			if p == name {
+				if i < len(args) {
+					return args[i], true
+				}
+				return nil, true
+			}
+		}
+		return nil, false
+	}
+
+	var out []ppToken
+	body := m.body
+	for i := 0; i < len(body); i++ {
+		t := body[i]
+
+		// # param
+		if m.function && t.text == "#" && i+1 < len(body) {
+			if a, ok := arg(body[i+1].text); ok {
+				out = append(out, ppToken{kind: ppString, text: stringize(a), space: t.space})
+				i++
+				continue
+			}
+		}
+
+		// x ## y
+		if t.text == "##" && i+1 < len(body) {
+			rhs := []ppToken{body[i+1]}
+			if a, ok := arg(body[i+1].text); ok {
+				rhs = a
+			}
+			i++
+			if len(rhs) == 0 {
+				// GNU extension: , ## __VA_ARGS__ drops the comma
+				// if there are no variable arguments.
+				if body[i].text == "__VA_ARGS__" && len(out) > 0 && out[len(out)-1].text == "," {
+					out = out[:len(out)-1]
+				}
+				continue
+			}
+			if len(out) == 0 {
+				out = append(out, rhs...)
+				continue
+			}
+			lhs := out[len(out)-1]
+			pasted := tokenize(lhs.text + rhs[0].text)
+			if len(pasted) != 1 {
+				lx.Errorf("pasting %s and %s does not give a valid preprocessing token", lhs.text, rhs[0].text)
+			}
+			for j := range pasted {
+				pasted[j].space = false
+			}
+			if len(pasted) > 0 {
+				pasted[0].space = lhs.space
+			}
+			out = append(append(out[:len(out)-1], pasted...), rhs[1:]...)
+			continue
+		}
+
+		if a, ok := arg(t.text); ok && m.function {
+			if i+1 < len(body) && body[i+1].text == "##" {
+				// Unexpanded argument as the left operand of ##.
+				out = append(out, a...)
+			} else {
+				a = lx.expand(append([]ppToken(nil), a...))
+				out = append(out, a...)
+			}
+			if len(a) > 0 {
+				out[len(out)-len(a)].space = t.space
+			}
+			continue
+		}
+		out = append(out, t)
+	}
+
+	for i := range out {
+		out[i].hide = unionHide(out[i].hide, hide)
+	}
+	return out
+}
+
+func addHide(h map[string]bool, name string) map[string]bool {
+	n := make(map[string]bool, len(h)+1)
+	for k := range h {
+		n[k] = true
+	}
+	n[name] = true
+	return n
+}
+
+func intersectHide(a, b map[string]bool) map[string]bool {
+	n := make(map[string]bool)
+	for k := range a {
+		if b[k] {
+			n[k] = true
+		}
+	}
+	return n
+}
+
+func unionHide(a, b map[string]bool) map[string]bool {
+	if len(a) == 0 {
+		return b
+	}
+	n := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		n[k] = true
+	}
+	for k := range b {
+		n[k] = true
+	}
+	return n
+}
+
+// ppCond evaluates the controlling expression of #if or #elif.
+func (lx *lexer) ppCond(toks []ppToken) bool {
+	// Replace defined X and defined(X) before macro expansion.
+	var pre []ppToken
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		if t.kind != ppIdent || t.text != "defined" {
+			pre = append(pre, t)
+			continue
+		}
+		var name string
+		switch {
+		case i+1 < len(toks) && toks[i+1].kind == ppIdent:
+			name = toks[i+1].text
+			i++
+		case i+3 < len(toks) && toks[i+1].text == "(" && toks[i+2].kind == ppIdent && toks[i+3].text == ")":
+			name = toks[i+2].text
+			i += 3
+		default:
+			lx.Errorf("malformed defined in #if")
+			return false
+		}
+		val := "0"
+		if lx.macros[name] != nil {
+			val = "1"
+		}
+		pre = append(pre, ppToken{kind: ppNumber, text: val, space: t.space})
+	}
+
+	e := &ppEval{lx: lx, toks: lx.expand(pre)}
+	if len(e.toks) == 0 {
+		lx.Errorf("#if with no expression")
+		return false
+	}
+	v := e.cond()
+	if !e.failed && len(e.toks) > 0 {
+		lx.Errorf("unexpected %s in #if", e.toks[0].text)
+		return false
+	}
+	return !e.failed && v.n != 0
+}
+
+// A ppValue is the value of a preprocessor constant expression.
+// All arithmetic is done in intmax_t or uintmax_t (C99 §6.10.1p4).
+type ppValue struct {
+	n        int64
+	unsigned bool
+}
+
+type ppEval struct {
+	lx     *lexer
+	toks   []ppToken
+	failed bool
+
+	// skip counts the enclosing operands of &&, || and ?:
+	// that are not evaluated, whose errors of evaluation,
+	// such as division by zero, are not reported.
+	skip int
+}
+
+func (e *ppEval) errorf(format string, args ...interface{}) {
+	if !e.failed {
+		e.lx.Errorf(format, args...)
+	}
+	e.failed = true
+}
+
+func (e *ppEval) peek() string {
+	if len(e.toks) == 0 {
+		return ""
+	}
+	return e.toks[0].text
+}
+
+func (e *ppEval) next() ppToken {
+	if len(e.toks) == 0 {
+		e.errorf("unexpected end of #if expression")
+		return ppToken{}
+	}
+	t := e.toks[0]
+	e.toks = e.toks[1:]
+	return t
+}
+
+var ppPrec = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"|":  3,
+	"^":  4,
+	"&":  5,
+	"==": 6, "!=": 6,
+	"<": 7, ">": 7, "<=": 7, ">=": 7,
+	"<<": 8, ">>": 8,
+	"+": 9, "-": 9,
+	"*": 10, "/": 10, "%": 10,
+}
+
+func (e *ppEval) cond() ppValue {
+	c := e.binary(1)
+	if e.peek() != "?" {
+		return c
+	}
+	e.next()
+	if c.n == 0 {
+		e.skip++
+	}
+	x := e.cond()
+	if c.n == 0 {
+		e.skip--
+	}
+	if e.next().text != ":" {
+		e.errorf("missing : in #if expression")
+	}
+	if c.n != 0 {
+		e.skip++
+	}
+	y := e.cond()
+	if c.n != 0 {
+		e.skip--
+	}
+	if x.unsigned || y.unsigned {
+		x.unsigned, y.unsigned = true, true
+	}
+	if c.n != 0 {
+		return x
+	}
+	return y
+}
+
+func (e *ppEval) binary(prec int) ppValue {
+	x := e.unary()
+	for !e.failed {
+		op := e.peek()
+		p := ppPrec[op]
+		if p < prec || p == 0 {
+			return x
+		}
+		e.next()
+		skip := op == "&&" && x.n == 0 || op == "||" && x.n != 0
+		if skip {
+			e.skip++
+		}
+		y := e.binary(p + 1)
+		if skip {
+			e.skip--
+		}
+		x = e.apply(op, x, y)
+	}
+	return x
+}
+
+func b2v(b bool) ppValue {
+	if b {
+		return ppValue{n: 1}
+	}
+	return ppValue{}
+}
+
+func (e *ppEval) apply(op string, x, y ppValue) ppValue {
+	switch op {
+	case "||":
+		return b2v(x.n != 0 || y.n != 0)
+	case "&&":
+		return b2v(x.n != 0 && y.n != 0)
+	case "<<":
+		return ppValue{n: x.n << uint64(y.n), unsigned: x.unsigned}
+	case ">>":
+		if x.unsigned {
+			return ppValue{n: int64(uint64(x.n) >> uint64(y.n)), unsigned: true}
+		}
+		return ppValue{n: x.n >> uint64(y.n)}
+	}
+
+	u := x.unsigned || y.unsigned
+	a, b := x.n, y.n
+	switch op {
+	case "|":
+		return ppValue{n: a | b, unsigned: u}
+	case "^":
+		return ppValue{n: a ^ b, unsigned: u}
+	case "&":
+		return ppValue{n: a & b, unsigned: u}
+	case "==":
+		return b2v(a == b)
+	case "!=":
+		return b2v(a != b)
+	case "+":
+		return ppValue{n: a + b, unsigned: u}
+	case "-":
+		return ppValue{n: a - b, unsigned: u}
+	case "*":
+		return ppValue{n: a * b, unsigned: u}
+	case "/", "%":
+		if b == 0 {
+			if e.skip == 0 {
+				e.errorf("division by zero in #if")
+			}
+			return ppValue{}
+		}
+		if u {
+			if op == "/" {
+				return ppValue{n: int64(uint64(a) / uint64(b)), unsigned: true}
+			}
+			return ppValue{n: int64(uint64(a) % uint64(b)), unsigned: true}
+		}
+		if op == "/" {
+			return ppValue{n: a / b}
+		}
+		return ppValue{n: a % b}
+	}
+
+	// Relational operators.
+	var lt, gt bool
+	if u {
+		lt, gt = uint64(a) < uint64(b), uint64(a) > uint64(b)
+	} else {
+		lt, gt = a < b, a > b
+	}
+	switch op {
+	case "<":
+		return b2v(lt)
+	case ">":
+		return b2v(gt)
+	case "<=":
+		return b2v(!gt)
+	case ">=":
+		return b2v(!lt)
+	}
+	panic("unreachable")
+}
+
+func (e *ppEval) unary() ppValue {
+	t := e.next()
+	if e.failed {
+		return ppValue{}
+	}
+	switch t.kind {
+	case ppIdent:
+		// Identifiers remaining after macro expansion are 0.
+		return ppValue{}
+	case ppNumber:
+		return e.number(t.text)
+	case ppChar:
+		v, ok := e.lx.parseChar(strings.TrimPrefix(t.text, "L"))
+		if !ok {
+			e.failed = true
+		}
+		return ppValue{n: int64(int8(v))}
+	}
+	switch t.text {
+	case "(":
+		x := e.cond()
+		if e.next().text != ")" {
+			e.errorf("missing ) in #if expression")
+		}
+		return x
+	case "+":
+		return e.unary()
+	case "-":
+		x := e.unary()
+		x.n = -x.n
+		return x
+	case "~":
+		x := e.unary()
+		x.n = ^x.n
+		return x
+	case "!":
+		return b2v(e.unary().n == 0)
+	}
+	e.errorf("unexpected %s in #if", t.text)
+	return ppValue{}
+}
+
+func (e *ppEval) number(text string) ppValue {
+	num := strings.TrimRight(text, "uUlL")
+	suf := strings.ToUpper(text[len(num):])
+	n, err := strconv.ParseUint(num, 0, 64)
+	if err != nil {
+		e.errorf("invalid integer constant %s in #if", text)
+		return ppValue{}
+	}
+	return ppValue{n: int64(n), unsigned: strings.Contains(suf, "U") || int64(n) < 0}
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cc_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/hajimehoshi/cingo/cc"
+)
+
+var cppTests = []struct {
+	in  string
+	out string // initializer of the last declaration
+}{
+	{"#define N 10\n#define M N + 1\nint x = M;", "10 + 1"},
+	{"#define SQ(x) x * x\nint x = SQ(2 + 3);", "2 + 3 * 2 + 3"},
+	{"#define STR(x) #x\nchar *x = STR(a \"b\");", `"a \"b\""`},
+	{"#define CAT(a, b) a ## b\nint xy = 3;\nint x = CAT(x, y);", "xy"},
+	{"#define F(...) g(__VA_ARGS__)\nint g(int, int);\nint x = F(1, 2);", "g(1, 2)"},
+	{"#define F(fmt, ...) g(fmt, ## __VA_ARGS__)\nint g(int);\nint x = F(1);", "g(1)"},
+	{"#define f(x) x + f\nint f;\nint x = f(1);", "1 + f"},
+	{"#define N 1\n#undef N\n#ifndef N\nint x = 2;\n#endif", "2"},
+	{"#ifdef N\nint x = 1;\n#else\nint x = 2;\n#endif", "2"},
+	{"#define N 3\n#if N == 1\nint x = 1;\n#elif defined(N) && N > 2\nint x = 2;\n#else\nint x = 3;\n#endif", "2"},
+	{"#if 0\n#if 1\nint x = 1;\n#else\nint x = 2;\n#endif\n#else\nint x = 3;\n#endif", "3"},
+	{"#if -1 < 0u\nint x = 1;\n#else\nint x = 2;\n#endif", "2"},
+	{"#if __STDC__ && (1 << 3) == 8 ? 1 : 0\nint x = 1;\n#endif", "1"},
+	{"#if 0 && (1/0)\nint x = 1;\n#else\nint x = 2;\n#endif", "2"},
+	{"#if 1 || (1/0)\nint x = 1;\n#endif", "1"},
+	{"#if defined(X) && 100/X > 3\nint x = 1;\n#else\nint x = 2;\n#endif", "2"},
+	{"#define X 20\n#if defined(X) && 100/X > 3\nint x = 1;\n#endif", "1"},
+	{"#if 1 ? 2 : 1/0\nint x = 1;\n#endif", "1"},
+	{"#if 0 ? 1/0 : 1 || 1 % 0\nint x = 1;\n#endif", "1"},
+	{"\n\nint x = __LINE__;", "3"},
+}
+
+func TestPreprocess(t *testing.T) {
+	for _, tt := range cppTests {
+		prog, err := Read("x.c", strings.NewReader(tt.in))
+		if err != nil {
+			t.Errorf("Read(%q): %v", tt.in, err)
+			continue
+		}
+		d := prog.Decls[len(prog.Decls)-1]
+		if d.Init == nil || d.Init.Expr == nil {
+			t.Errorf("Read(%q): last declaration %s has no initializer", tt.in, d.Name)
+			continue
+		}
+		if out := d.Init.Expr.String(); out != tt.out {
+			t.Errorf("Read(%q): initializer = %#q, want %#q", tt.in, out, tt.out)
+		}
+	}
+}
+
+var cppErrorTests = []struct {
+	in  string
+	err string
+}{
+	{"#if 1\nint x;", "unterminated #if"},
+	{"#endif\nint x;", "#endif without #if"},
+	{"#error oops\nint x;", "#error oops"},
+	{"#define F(a) a + 1\nint x = F(1;", "unterminated"},
+	{"#if 1 && 1/0\nint x;\n#endif", "division by zero in #if"},
+	{"#if 0 || 1/0\nint x;\n#endif", "division by zero in #if"},
+}
+
+func TestPreprocessErrors(t *testing.T) {
+	for _, tt := range cppErrorTests {
+		_, err := Read("x.c", strings.NewReader(tt.in))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Read(%q) = %v, want error containing %q", tt.in, err, tt.err)
+		}
+	}
+}
+
+func TestDefine(t *testing.T) {
+	Define("CPP_TEST_N", "42")
+	Define("CPP_TEST_GONE", "1")
+	Undefine("CPP_TEST_GONE")
+	prog, err := Read("x.c", strings.NewReader("#ifndef CPP_TEST_GONE\nint x = CPP_TEST_N;\n#endif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := prog.Decls[0].Init.Expr.String(); out != "42" {
+		t.Errorf("initializer = %#q, want %#q", out, "42")
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cc
+
+import (
+	"fmt"
+	"sort"
+)
+
+// An Error is a syntax or type error in the C input.
+type Error struct {
+	Span Span
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Span, e.Msg)
+}
+
+// An ErrorList is a list of errors, in the order they were found.
+// ReadMany returns all the errors it finds as an ErrorList.
+type ErrorList []*Error
+
+// Add appends an error with the given span and message to the list.
+func (l *ErrorList) Add(span Span, msg string) {
+	*l = append(*l, &Error{Span: span, Msg: msg})
+}
+
+func (l ErrorList) Len() int      { return len(l) }
+func (l ErrorList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+
+func (l ErrorList) Less(i, j int) bool {
+	p, q := l[i].Span.Start, l[j].Span.Start
+	if p.File != q.File {
+		return p.File < q.File
+	}
+	if p.Line != q.Line {
+		return p.Line < q.Line
+	}
+	return p.Byte < q.Byte
+}
+
+// Sort sorts the list by file and position.
+func (l ErrorList) Sort() {
+	sort.Stable(l)
+}
+
+// Error returns the errors one per line.
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	s := ""
+	for i, e := range l {
+		if i > 0 {
+			s += "\n"
+		}
+		s += e.Error()
+	}
+	return s
+}
+
+// Err returns an error equivalent to the list,
+// or nil if the list is empty.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
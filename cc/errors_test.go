@@ -0,0 +1,95 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cc_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/hajimehoshi/cingo/cc"
+)
+
+func TestErrorList(t *testing.T) {
+	tests := []struct {
+		files []string
+		want  []string
+	}{
+		{
+			files: []string{`int f(void) {
+	int x;
+	x = ;
+	x = 1;
+	return x;
+}
+`},
+			want: []string{"a.c:3"},
+		},
+		{
+			files: []string{`int f(void) {
+	int x;
+	if (x { x = 2; }
+	return x;
+}
+int g(void) { return 1 }
+int y = ;
+int z;
+`},
+			want: []string{"a.c:3", "a.c:6", "a.c:7"},
+		},
+		{
+			files: []string{"int x = 1 +;\n", "int y;\nint z = ;\n"},
+			want:  []string{"a.c:1", "b.c:2"},
+		},
+		{
+			files: []string{"int f(void) {\n"},
+			want:  []string{"a.c:3"},
+		},
+		{
+			files: []string{"struct s { int a; };\nint x = 1;\nint *y = x + \"\";\nint f(struct s *p) {\n\treturn p->b;\n}\n"},
+			want:  []string{"a.c:3", "a.c:5"},
+		},
+	}
+	for _, tt := range tests {
+		var names []string
+		var readers []io.Reader
+		for i, f := range tt.files {
+			names = append(names, fmt.Sprintf("%c.c", 'a'+i))
+			readers = append(readers, strings.NewReader(f))
+		}
+		_, err := ReadMany(names, readers)
+		list, ok := err.(ErrorList)
+		if !ok {
+			t.Errorf("ReadMany(%q): got error %v (%T), want ErrorList", tt.files, err, err)
+			continue
+		}
+		var got []string
+		for _, e := range list {
+			got = append(got, e.Span.String())
+			if e.Msg == "" {
+				t.Errorf("ReadMany(%q): error at %v has no message", tt.files, e.Span)
+			}
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("ReadMany(%q): errors at %v, want %v\n%v", tt.files, got, tt.want, err)
+		}
+	}
+}
+
+func TestErrorListSort(t *testing.T) {
+	var list ErrorList
+	list.Add(Span{Start: Pos{File: "b.c", Line: 1}}, "one")
+	list.Add(Span{Start: Pos{File: "a.c", Line: 7}}, "two")
+	list.Add(Span{Start: Pos{File: "a.c", Line: 3}}, "three")
+	list.Sort()
+	want := "a.c:3: three\na.c:7: two\nb.c:1: one"
+	if got := list.Error(); got != want {
+		t.Errorf("sorted list = %q, want %q", got, want)
+	}
+	if ErrorList(nil).Err() != nil {
+		t.Errorf("empty list: Err() != nil")
+	}
+}
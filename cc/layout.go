@@ -0,0 +1,180 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Storage layout of C types.
+
+package cc
+
+// Size returns the size of t in bytes under the data model m,
+// or -1 if t is incomplete or its size is not a constant.
+func (t *Type) Size(m *Model) int64 {
+	size, _ := t.layout(m)
+	return size
+}
+
+// Align returns the alignment of t in bytes under the data model m,
+// or -1 if t is incomplete or its size is not a constant.
+func (t *Type) Align(m *Model) int64 {
+	_, align := t.layout(m)
+	return align
+}
+
+func (t *Type) layout(m *Model) (size, align int64) {
+	if t == nil {
+		return -1, -1
+	}
+	switch t.Kind {
+	case TypedefType:
+		return t.Base.layout(m)
+
+	case Array:
+		n, ok := ConstInt(t.Width, m)
+		if !ok {
+			return -1, -1
+		}
+		size, align := t.Base.layout(m)
+		if size < 0 {
+			return -1, -1
+		}
+		return n * size, align
+
+	case Struct, Union:
+		_, size, align = t.fields(m)
+		return size, align
+	}
+
+	size = m.KindSize(t.Kind)
+	return size, size
+}
+
+// fields lays out the struct or union t under m.
+// It returns the offset in bits of each of t.Decls,
+// along with the size and alignment of t in bytes.
+func (t *Type) fields(m *Model) (offsets []int64, size, align int64) {
+	if t.Decls == nil {
+		return nil, -1, -1
+	}
+	// Lay out in bits, for the sake of bit-fields.
+	var bits, end int64
+	align = 1
+	for _, d := range t.Decls {
+		dsize, dalign := d.Type.layout(m)
+		if dsize < 0 {
+			return nil, -1, -1
+		}
+		if t.Kind == Union {
+			bits = 0
+		}
+		if d.Bits != nil {
+			n, ok := ConstInt(d.Bits, m)
+			if !ok {
+				return nil, -1, -1
+			}
+			// A bit-field does not straddle a unit of its type,
+			// and one of zero width ends the current unit.
+			// Unnamed bit-fields do not affect alignment.
+			unit := 8 * dsize
+			if n == 0 || bits%unit+n > unit {
+				bits = roundUp(bits, unit)
+			}
+			offsets = append(offsets, bits)
+			bits += n
+			if d.Name == "" {
+				dalign = 1
+			}
+		} else {
+			bits = roundUp(bits, 8*dalign)
+			offsets = append(offsets, bits)
+			bits += 8 * dsize
+		}
+		if dalign > align {
+			align = dalign
+		}
+		if bits > end {
+			end = bits
+		}
+	}
+	return offsets, roundUp(roundUp(end, 8)/8, align), align
+}
+
+// Offset returns the offset in bytes of the field name
+// in the struct or union t under the data model m,
+// or -1 if there is no such field or the layout of t is unknown.
+// Fields of anonymous struct and union members are found too.
+// The offset of a bit-field is that of the byte holding its first bit.
+func (t *Type) Offset(m *Model, name string) int64 {
+	bits := t.BitOffset(m, name)
+	if bits < 0 {
+		return -1
+	}
+	return bits / 8
+}
+
+// BitOffset is like Offset but returns the offset in bits.
+func (t *Type) BitOffset(m *Model, name string) int64 {
+	t = stripTypedef(t)
+	if t == nil || t.Kind != Struct && t.Kind != Union {
+		return -1
+	}
+	offsets, size, _ := t.fields(m)
+	if size < 0 {
+		return -1
+	}
+	for i, d := range t.Decls {
+		if d.Name == name {
+			return offsets[i]
+		}
+		if d.Name == "" && d.Bits == nil {
+			if off := d.Type.BitOffset(m, name); off >= 0 {
+				return offsets[i] + off
+			}
+		}
+	}
+	return -1
+}
+
+func roundUp(n, align int64) int64 {
+	return (n + align - 1) / align * align
+}
+
+// ConstInt returns the value of the integer constant expression x
+// under the data model m, and reports whether x is one.
+// Unsigned values are returned as their bits.
+func ConstInt(x *Expr, m *Model) (int64, bool) {
+	c, err := Eval(x, m)
+	if err != nil || !c.IsInt() {
+		return 0, false
+	}
+	return c.Int, true
+}
+
+// EnumValue returns the value of the enumeration constant d
+// under the data model m, and reports whether it could be computed.
+// A constant without an initializer is one more than the constant before it.
+// If m is nil, the value computed when the program was read is returned.
+func EnumValue(d *Decl, m *Model) (int64, bool) {
+	t := d.OuterType
+	if t == nil || t.Kind != Enum {
+		return 0, false
+	}
+	if d.Value != nil && m == nil {
+		return d.Value.Int, true
+	}
+	v := int64(-1)
+	for _, c := range t.Decls {
+		if c.Init != nil {
+			n, err := Eval(c.Init.Expr, m)
+			if err != nil || !n.IsInt() {
+				return 0, false
+			}
+			v = n.Int
+		} else {
+			v++
+		}
+		if c == d {
+			return v, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,166 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cc_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/hajimehoshi/cingo/cc"
+)
+
+var layoutTests = []struct {
+	in    string
+	size  int64
+	align int64
+}{
+	{"int x;", 4, 4},
+	{"char *x;", 8, 8},
+	{"struct { char c; int i; } x;", 8, 4},
+	{"struct { char c; double d; char e; } x;", 24, 8},
+	{"union { char c; int i; } x;", 4, 4},
+	{"union { char c[5]; int i; } x;", 8, 4},
+	{"union { short s; struct { char a, b, c; } t; } x;", 4, 2},
+	{"#define N 3\nunion { long l[N + 1]; char c; } x;", 32, 8},
+	{"struct s *x; struct s y;", -1, -1},
+	{"struct { unsigned int a:3, b:5; } x;", 4, 4},
+	{"struct { char a:3, b:5; } x;", 1, 1},
+	{"struct { char a:3, b:6; } x;", 2, 1},
+	{"struct { char c; int a:30; } x;", 8, 4},
+	{"struct { char c; int a:24; } x;", 4, 4},
+	{"struct { char c; int :0; char d; } x;", 5, 1},
+	{"struct { long a:1; char c; } x;", 8, 8},
+	{"union { int a:3; char b[2]; } x;", 4, 4},
+	{"struct s { char c; double d; };\nchar x[sizeof(struct s)];", 16, 1},
+	{"long y[3];\nchar x[sizeof y + sizeof(y[0])];", 32, 1},
+	{"char x[sizeof \"abc\"];", 4, 1},
+	{"struct s { char c; short h; int i; };\nchar x[offsetof(struct s, i)];", 4, 1},
+}
+
+func TestLayout(t *testing.T) {
+	for _, tt := range layoutTests {
+		prog, err := Read("x.c", strings.NewReader(tt.in))
+		if err != nil {
+			t.Errorf("Read(%q): %v", tt.in, err)
+			continue
+		}
+		typ := prog.Decls[len(prog.Decls)-1].Type
+		if size, align := typ.Size(LP64), typ.Align(LP64); size != tt.size || align != tt.align {
+			t.Errorf("Read(%q): size, align = %d, %d, want %d, %d", tt.in, size, align, tt.size, tt.align)
+		}
+	}
+}
+
+func TestUnion(t *testing.T) {
+	prog, err := Read("x.c", strings.NewReader("union u { int i; float f; };\nunion u x = {1};\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k := prog.Decls[len(prog.Decls)-1].Type.Def().Kind; k != Union {
+		t.Errorf("kind = %v, want %v", k, Union)
+	}
+
+	_, err = Read("x.c", strings.NewReader("union u { int i; float f; };\nunion u x = {1, 2};\n"))
+	if err == nil || !strings.Contains(err.Error(), "more than one initializer element") {
+		t.Errorf("Read with two union initializers: %v, want error", err)
+	}
+}
+
+func TestBitField(t *testing.T) {
+	prog, err := Read("x.c", strings.NewReader("struct s { unsigned int a:3, b:5; int :0; };\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bits []string
+	for _, d := range prog.Decls[0].Type.Decls {
+		bits = append(bits, d.Name+":"+d.Bits.String())
+	}
+	if got, want := strings.Join(bits, " "), "a:3 b:5 :0"; got != want {
+		t.Errorf("bit-fields = %s, want %s", got, want)
+	}
+}
+
+var offsetTests = []struct {
+	field string
+	off   int64
+	bit   int64
+}{
+	{"c", 0, 0},
+	{"a", 1, 8},
+	{"b", 1, 11},
+	{"u", 8, 64},
+	{"i", -1, -1},
+	{"last", 16, 128},
+	{"nope", -1, -1},
+}
+
+func TestOffset(t *testing.T) {
+	prog, err := Read("x.c", strings.NewReader("struct s { char c; unsigned int a:3, b:7; union { int i; double d; } u; struct { char last; }; };\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ := prog.Decls[0].Type
+	for _, tt := range offsetTests {
+		if off := typ.Offset(LP64, tt.field); off != tt.off {
+			t.Errorf("Offset(%s) = %d, want %d", tt.field, off, tt.off)
+		}
+		if bit := typ.BitOffset(LP64, tt.field); bit != tt.bit {
+			t.Errorf("BitOffset(%s) = %d, want %d", tt.field, bit, tt.bit)
+		}
+	}
+}
+
+var bitFieldErrorTests = []struct {
+	in  string
+	err string
+}{
+	{"struct { float f:3; } x;", "non-integer type"},
+	{"struct { char c:9; } x;", "exceeds its type"},
+	{"struct { int a:0; } x;", "zero width"},
+	{"int n;\nstruct { int a:n; } x;", "non-constant width"},
+	{"struct s { int a:3; };\nint *f(struct s *p) { return &p->a; }", "address of bit-field"},
+	{"struct s { int a:3; };\nlong f(struct s *p) { return sizeof p->a; }", "size of bit-field"},
+}
+
+func TestBitFieldErrors(t *testing.T) {
+	for _, tt := range bitFieldErrorTests {
+		_, err := Read("x.c", strings.NewReader(tt.in))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Read(%q) = %v, want error containing %q", tt.in, err, tt.err)
+		}
+	}
+}
+
+func TestEnumValue(t *testing.T) {
+	prog, err := Read("x.c", strings.NewReader("#define N 4\nenum e { A, B = 5, C, D = B + N, E, F = sizeof(int) };\nchar x[E];\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var enum *Type
+	for _, d := range prog.Decls {
+		if d.Type != nil && d.Type.Kind == Enum {
+			enum = d.Type
+		}
+	}
+	if enum == nil {
+		t.Fatal("no enum declared")
+	}
+	var values []string
+	for _, d := range enum.Decls {
+		v, ok := EnumValue(d, LP64)
+		if !ok {
+			t.Errorf("EnumValue(%s) failed", d.Name)
+			continue
+		}
+		values = append(values, fmt.Sprintf("%s=%d", d.Name, v))
+	}
+	if got, want := strings.Join(values, " "), "A=0 B=5 C=6 D=9 E=10 F=4"; got != want {
+		t.Errorf("values = %s, want %s", got, want)
+	}
+	if size := prog.Decls[len(prog.Decls)-1].Type.Size(LP64); size != 10 {
+		t.Errorf("size of char[E] = %d, want 10", size)
+	}
+}
@@ -8,8 +8,6 @@ package cc
 
 import (
 	"fmt"
-	"io/ioutil"
-	"os"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -69,19 +67,32 @@ type lexer struct {
 	post     []Syntax
 	enumSeen map[interface{}]bool
 
+	// preprocessor state
+	macros map[string]*macro
+	ifs    []ifState
+
+	// macro translation state
+	macroNames   map[string]*macro // translated macros, by name
+	macroList    []*Macro          // all macros considered for translation
+	macroPending []*Decl           // declarations not yet added to the program
+
 	// type checking state
 	scope       *Scope
 	includeSeen map[string]*Header
+	structSeen  map[*Type]bool
+
+	opts *Options
 
 	// output
-	errors []string
+	errors ErrorList
 	prog   *Prog
 	expr   *Expr
 }
 
 type Header struct {
-	decls []*Decl
-	types []*Type
+	decls  []*Decl
+	types  []*Type
+	macros []hdrMacro
 }
 
 func (lx *lexer) parse() {
@@ -91,6 +102,9 @@ func (lx *lexer) parse() {
 	if lx.wholeInput == "" {
 		lx.wholeInput = lx.input
 	}
+	if lx.macros == nil {
+		lx.initMacros()
+	}
 	lx.scope = &Scope{}
 	yyParse(lx)
 }
@@ -103,6 +117,12 @@ type lexInput struct {
 	file       string
 	lineno     int
 	declSave   *Header
+	ifDepth    int  // len(lexer.ifs) at start of input
+	eof        bool // tokEOF has been returned
+
+	// for macro expansions
+	expansion bool
+	expandPos Pos // position of macro invocation
 }
 
 func (lx *lexer) pushInclude(includeLine string) {
@@ -138,6 +158,16 @@ func (lx *lexer) pushInclude(includeLine string) {
 		for _, typ := range hdr.types {
 			lx.pushType(typ)
 		}
+		for _, hm := range hdr.macros {
+			if hm.m == nil {
+				delete(lx.macros, hm.name)
+			} else {
+				lx.macros[hm.name] = hm.m
+				if hm.m.x != nil && hm.m.x.Decl != nil {
+					lx.macroNames[hm.name] = hm.m
+				}
+			}
+		}
 		return
 	}
 
@@ -159,6 +189,7 @@ func (lx *lexer) pushInclude(includeLine string) {
 		file:       file,
 		lineno:     1,
 		declSave:   hdr,
+		ifDepth:    len(lx.ifs),
 	}
 }
 
@@ -176,12 +207,6 @@ var stdMap = map[string]string{
 	"string.h": "",
 }
 
-var includes []string
-
-func AddInclude(dir string) {
-	includes = append(includes, dir)
-}
-
 func (lx *lexer) findInclude(name string, std bool) (string, []byte, error) {
 	if std {
 		if redir, ok := stdMap[name]; ok {
@@ -193,18 +218,21 @@ func (lx *lexer) findInclude(name string, std bool) (string, []byte, error) {
 	}
 	if !filepath.IsAbs(name) {
 		name1 := filepath.Join(filepath.Dir(lx.file), name)
-		if _, err := os.Stat(name1); err != nil {
-			for _, dir := range includes {
+		data, err := lx.opts.readFile(name1)
+		if err == nil {
+			return name1, data, nil
+		}
+		if lx.opts != nil {
+			for _, dir := range lx.opts.Includes {
 				name2 := filepath.Join(dir, name)
-				if _, err := os.Stat(name2); err == nil {
-					name1 = name2
-					break
+				if data, err := lx.opts.readFile(name2); err == nil {
+					return name2, data, nil
 				}
 			}
 		}
-		name = name1
+		return "", nil, err
 	}
-	data, err := ioutil.ReadFile(name)
+	data, err := lx.opts.readFile(name)
 	if err != nil {
 		return "", nil, err
 	}
@@ -212,6 +240,10 @@ func (lx *lexer) findInclude(name string, std bool) (string, []byte, error) {
 }
 
 func (lx *lexer) pop() bool {
+	if len(lx.ifs) > lx.ifDepth {
+		lx.Errorf("unterminated #if")
+		lx.ifs = lx.ifs[:lx.ifDepth]
+	}
 	if len(lx.pushed) == 0 {
 		return false
 	}
@@ -224,6 +256,9 @@ func (lx *lexer) pos() Pos {
 	if lx.forcePos.Line != 0 {
 		return lx.forcePos
 	}
+	if lx.expansion {
+		return lx.expandPos
+	}
 	return Pos{lx.file, lx.lineno, lx.byte}
 }
 func (lx *lexer) span() Span {
@@ -314,8 +349,21 @@ Restart:
 		if lx.pop() {
 			goto Restart
 		}
+		if lx.eof {
+			// Error recovery reached the end of the input
+			// without finding a place to resume parsing.
+			return 0
+		}
+		lx.eof = true
 		return tokEOF
 	}
+	if lx.skipping() && !lx.expansion {
+		lx.skipGroup()
+		if len(lx.input) == 0 {
+			goto Restart
+		}
+		in = lx.input
+	}
 	c := in[0]
 	if isspace(c) {
 		i := 1
@@ -338,9 +386,7 @@ Restart:
 		}
 		str := in[:i]
 		lx.skip(i)
-		if strings.HasPrefix(str, "#include") {
-			lx.pushInclude(str)
-		}
+		lx.directive(str)
 		goto Restart
 
 	case 'L':
@@ -449,11 +495,19 @@ Restart:
 			i++
 		}
 		lx.sym(i)
+		if !lx.expansion {
+			if d := lx.macroDecl(lx.tok); d != nil {
+				yy.str = lx.tok
+				yy.decl = d
+				return tokName
+			}
+			if lx.expandName(lx.tok, yy.span.Start) {
+				goto Restart
+			}
+		}
 		switch lx.tok {
 		case "Adr":
 			lx.tok = "Addr"
-		case "union":
-			lx.tok = "struct"
 		}
 		yy.str = lx.tok
 		if t := tokId[lx.tok]; t != 0 {
@@ -483,7 +537,7 @@ func (lx *lexer) Error(s string) {
 }
 
 func (lx *lexer) Errorf(format string, args ...interface{}) {
-	lx.errors = append(lx.errors, fmt.Sprintf("%s: %s", lx.span(), fmt.Sprintf(format, args...)))
+	lx.errors.Add(lx.span(), fmt.Sprintf(format, args...))
 }
 
 type Pos struct {
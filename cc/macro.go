@@ -0,0 +1,556 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Translation of simple macros into declarations.
+//
+// An object-like macro whose replacement list is a constant
+// expression becomes a constant declaration, and a side-effect-free
+// function-like macro called with consistently typed arguments
+// becomes a function declaration. Uses of such macros are not
+// expanded; instead they refer to the declaration. The exceptions
+// are uses where a name in the replacement list is shadowed, and
+// calls with an argument whose side effects the expansion would
+// repeat or leave out.
+// All other macros are expanded as usual.
+
+package cc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Macro describes a macro that was translated into a declaration,
+// or that could not be translated and was expanded at its uses.
+type Macro struct {
+	Name     string
+	Function bool     // function-like macro
+	Params   []string // parameter names, for function-like macros
+	Span     Span     // position of the #define
+
+	// Decl is the constant or function declaration
+	// for the macro, or nil if the macro was expanded.
+	Decl *Decl
+
+	// Expanded gives the reason the macro was
+	// expanded instead of translated.
+	Expanded string
+
+	used   bool        // expanded at least once
+	body   *Expr       // replacement list, for function-like macros
+	params []*Decl     // parameter declarations, for function-like macros
+	calls  []macroCall // calls of a function-like macro
+}
+
+// A macroCall records a call of a function-like macro along with
+// the expansion to use in its place if the macro cannot be translated.
+type macroCall struct {
+	call *Expr
+	exp  *Expr
+}
+
+// translateMacro decides whether the newly defined macro m
+// can be translated into a declaration and, if so, creates it.
+func (lx *lexer) translateMacro(m *macro) {
+	if len(m.body) == 0 {
+		return // nothing to translate or to complain about
+	}
+	x := &Macro{
+		Name:     m.name,
+		Function: m.function,
+		Params:   m.params,
+		Span:     m.span,
+	}
+	m.x = x
+	lx.macroList = append(lx.macroList, x)
+
+	if old := lx.macroNames[m.name]; old != nil {
+		x.Expanded = fmt.Sprintf("redefinition of macro translated at %s:%d", old.span.Start.File, old.span.Start.Line)
+		return
+	}
+	if m.variadic {
+		x.Expanded = "variadic macro"
+		return
+	}
+	for _, t := range m.body {
+		if t.text == "#" || t.text == "##" {
+			x.Expanded = "uses # or ##"
+			return
+		}
+	}
+	for _, p := range m.params {
+		if lx.macros[p] != nil {
+			x.Expanded = fmt.Sprintf("parameter %s is also a macro", p)
+			return
+		}
+	}
+
+	for _, p := range m.params {
+		x.params = append(x.params, &Decl{SyntaxInfo: SyntaxInfo{Span: m.span}, Name: p})
+	}
+	body, err := lx.parseMacroBody(m, x.params)
+	if err != nil {
+		x.Expanded = "replacement list is not an expression"
+		return
+	}
+	if !simpleMacroBody(body) {
+		x.Expanded = "replacement list is not parenthesized"
+		return
+	}
+	if why := macroBodyCheck(body, m.function); why != "" {
+		x.Expanded = why
+		return
+	}
+	if m.function {
+		if why := macroParamCheck(body, m, x.params); why != "" {
+			x.Expanded = why
+			return
+		}
+	}
+
+	d := &Decl{
+		SyntaxInfo: SyntaxInfo{Span: m.span},
+		Name:       m.name,
+		Macro:      x,
+	}
+	if m.function {
+		x.body = body
+	} else {
+		d.Init = &Init{SyntaxInfo: SyntaxInfo{Span: m.span}, Expr: body}
+	}
+	x.Decl = d
+	lx.macroNames[m.name] = m
+	lx.macroPending = append(lx.macroPending, d)
+}
+
+// macroDecls returns the declarations for macros defined
+// since the last call, to be inserted into the program.
+func (lx *lexer) macroDecls() []*Decl {
+	decls := lx.macroPending
+	lx.macroPending = nil
+	return decls
+}
+
+// macroDecl returns the declaration to use for the translated macro name
+// appearing in the input, or nil if name must be expanded.
+// A function-like macro is only a use of the macro when followed by (.
+func (lx *lexer) macroDecl(name string) *Decl {
+	m := lx.macros[name]
+	if m == nil || m.x == nil || m.x.Decl == nil {
+		return nil
+	}
+	if m.function && !strings.HasPrefix(strings.TrimLeft(lx.input, " \t\r\n"), "(") {
+		return nil
+	}
+	body := m.x.body
+	if !m.function {
+		body = m.x.Decl.Init.Expr
+	}
+	if lx.shadowed(body, m.x.params) {
+		return nil
+	}
+	return m.x.Decl
+}
+
+// shadowed reports whether a name other than a parameter in the
+// replacement list x of a translated macro is declared in a scope
+// enclosed by the file scope where the macro is used.
+// The declaration for the macro refers to the names in the file scope,
+// so a use where they mean something else must be expanded.
+func (lx *lexer) shadowed(x *Expr, params []*Decl) bool {
+	if x == nil {
+		return false
+	}
+	if x.Op == Name && x.XDecl != nil && x.XDecl.Macro == nil && !isParam(x.XDecl, params) {
+		for sc := lx.scope; sc != nil && sc.Next != nil; sc = sc.Next {
+			if d := sc.Decl[x.Text]; d != nil && d != x.XDecl {
+				return true
+			}
+		}
+	}
+	if lx.shadowed(x.Left, params) || lx.shadowed(x.Right, params) {
+		return true
+	}
+	for _, y := range x.List {
+		if lx.shadowed(y, params) {
+			return true
+		}
+	}
+	return false
+}
+
+func isParam(d *Decl, params []*Decl) bool {
+	for _, p := range params {
+		if d == p {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMacroBody parses the replacement list of m as an expression.
+// Names are resolved in the file scope, except that params
+// are declared in an enclosing scope of their own.
+func (lx *lexer) parseMacroBody(m *macro, params []*Decl) (*Expr, error) {
+	file := lx.scope
+	for file != nil && file.Next != nil {
+		file = file.Next
+	}
+	str := joinTokens(m.body) + "\n"
+	sub := &lexer{
+		start: startExpr,
+		lexInput: lexInput{
+			wholeInput: str,
+			input:      str,
+			file:       m.span.Start.File,
+			lineno:     m.span.Start.Line,
+		},
+		forcePos:    m.span.Start,
+		opts:        lx.opts,
+		macros:      lx.macros,
+		macroNames:  lx.macroNames,
+		includeSeen: lx.includeSeen,
+		scope:       &Scope{Next: file},
+	}
+	for _, d := range params {
+		sub.pushDecl(d)
+	}
+	yyParse(sub)
+	if sub.errors != nil {
+		return nil, sub.errors[0]
+	}
+	return sub.expr, nil
+}
+
+// simpleMacroBody reports whether x can stand for the macro
+// without changing the meaning of the surrounding expression:
+// a literal, a name, or a parenthesized expression, possibly
+// preceded by unary operators and casts.
+func simpleMacroBody(x *Expr) bool {
+	for {
+		switch x.Op {
+		case Number, Name, Paren:
+			return true
+		case Plus, Minus, Twid, Not, Cast:
+			x = x.Left
+		default:
+			return false
+		}
+	}
+}
+
+// macroBodyCheck returns a non-empty reason if the replacement list x
+// of a constant (or, if function is set, a function-like) macro
+// uses an operation that cannot be translated.
+// It returns the empty string if x is acceptable.
+func macroBodyCheck(x *Expr, function bool) string {
+	if x == nil {
+		return ""
+	}
+	switch x.Op {
+	case Number:
+		if strings.HasPrefix(x.Text, "L") {
+			return "uses wide character constant"
+		}
+	case Name:
+		switch d := x.XDecl; {
+		case d == nil:
+			return fmt.Sprintf("refers to undeclared name %s", x.Text)
+		case d.Macro != nil && !d.Macro.Function:
+			// another constant
+		case !function:
+			return fmt.Sprintf("refers to non-constant %s", x.Text)
+		case d.Storage&Typedef != 0:
+			return fmt.Sprintf("refers to type %s", x.Text)
+		}
+	case Cast:
+		if !function && !isArith(x.Type) {
+			return "converts to non-arithmetic type"
+		}
+	case Paren, Plus, Minus, Twid,
+		Add, Sub, Mul, Div, Mod, Lsh, Rsh, And, Or, Xor:
+		// ok
+	case Not, Lt, Gt, LtEq, GtEq, EqEq, NotEq, AndAnd, OrOr, Cond,
+		Dot, Arrow, Index, Indir:
+		if !function {
+			return fmt.Sprintf("uses %s operator in constant", x.Op)
+		}
+	default:
+		return fmt.Sprintf("uses %s operator", x.Op)
+	}
+	if why := macroBodyCheck(x.Left, function); why != "" {
+		return why
+	}
+	if why := macroBodyCheck(x.Right, function); why != "" {
+		return why
+	}
+	for _, y := range x.List {
+		if why := macroBodyCheck(y, function); why != "" {
+			return why
+		}
+	}
+	return ""
+}
+
+// macroParamCheck returns a non-empty reason if the parameters of the
+// function-like macro m are not used as plain parenthesized operands
+// in body, so that evaluating the arguments first would change the
+// meaning of the expansion.
+func macroParamCheck(body *Expr, m *macro, params []*Decl) string {
+	count := make(map[*Decl]int)
+	var check func(x, parent *Expr) string
+	check = func(x, parent *Expr) string {
+		if x == nil {
+			return ""
+		}
+		if x.Op == Name {
+			for _, p := range params {
+				if x.XDecl != p {
+					continue
+				}
+				count[p]++
+				if parent != nil && parent.Op != Paren && !(parent.Op == Index && parent.Right == x) {
+					return fmt.Sprintf("parameter %s is not parenthesized", p.Name)
+				}
+			}
+		}
+		if why := check(x.Left, x); why != "" {
+			return why
+		}
+		if why := check(x.Right, x); why != "" {
+			return why
+		}
+		for _, y := range x.List {
+			if why := check(y, x); why != "" {
+				return why
+			}
+		}
+		return ""
+	}
+	if why := check(body, nil); why != "" {
+		return why
+	}
+	for _, p := range params {
+		n := 0
+		for _, t := range m.body {
+			if t.kind == ppIdent && t.text == p.Name {
+				n++
+			}
+		}
+		if n != count[p] {
+			return fmt.Sprintf("parameter %s is not used as an operand", p.Name)
+		}
+	}
+	return ""
+}
+
+// typecheckMacroConst type checks the declaration of a constant macro.
+func (lx *lexer) typecheckMacroConst(decl *Decl) {
+	if decl.Type != nil {
+		return
+	}
+	x := decl.Init.Expr
+	lx.typecheckExpr(x)
+	decl.Type = x.XType
+	decl.Init.XType = x.XType
+}
+
+// typecheckMacroCall type checks x, a call of a function-like macro.
+// The first call fixes the parameter types; later calls with
+// different argument types force the macro to be expanded.
+func (lx *lexer) typecheckMacroCall(x *Expr) {
+	m := x.Left.XDecl.Macro
+	if len(x.List) != len(m.params) {
+		lx.Errorf("macro %s takes %d arguments, have %d", m.Name, len(m.params), len(x.List))
+		return
+	}
+
+	subst := make(map[*Decl]*Expr)
+	for i, p := range m.params {
+		subst[p] = x.List[i]
+	}
+	exp := copyExpr(m.body, subst)
+	lx.typecheckExpr(exp)
+	x.XType = exp.XType
+
+	// A function evaluates each argument once, the expansion
+	// as many times as the parameter is used, and only if the
+	// operators around the use evaluate it. The difference
+	// matters for arguments with side effects: expand the call.
+	for i, p := range m.params {
+		if n, cond := countUses(m.body, p); hasSideEffects(x.List[i]) && (n != 1 || cond) {
+			info := x.SyntaxInfo
+			*x = *exp
+			x.SyntaxInfo = info
+			m.used = true
+			return
+		}
+	}
+	m.calls = append(m.calls, macroCall{x, exp})
+	if m.Expanded != "" {
+		return
+	}
+
+	for i, p := range m.params {
+		t := x.List[i].XType
+		switch {
+		case t == nil:
+			m.Expanded = fmt.Sprintf("cannot determine type of argument for %s", p.Name)
+		case stripTypedef(t).Kind == Array || stripTypedef(t).Kind == Func:
+			m.Expanded = fmt.Sprintf("argument for %s has type %v", p.Name, t)
+		case p.Type != nil && p.Type.String() != t.String():
+			m.Expanded = fmt.Sprintf("argument for %s has inconsistent types %v and %v", p.Name, p.Type, t)
+		}
+		if m.Expanded != "" {
+			return
+		}
+	}
+	if m.Decl.Type != nil {
+		return
+	}
+
+	// First call: declare the function.
+	for i, p := range m.params {
+		p.Type = x.List[i].XType
+	}
+	lx.typecheckExpr(m.body)
+	if m.body.XType == nil || m.body.XType.Is(Void) {
+		m.Expanded = "replacement list has no value"
+		return
+	}
+	d := m.Decl
+	d.Type = &Type{
+		SyntaxInfo: SyntaxInfo{Span: m.Span},
+		Kind:       Func,
+		Base:       m.body.XType,
+		Decls:      m.params,
+	}
+	d.Body = &Stmt{
+		SyntaxInfo: SyntaxInfo{Span: m.Span},
+		Op:         Block,
+		Block: []*Stmt{
+			{SyntaxInfo: SyntaxInfo{Span: m.Span}, Op: Return, Expr: m.body},
+		},
+	}
+}
+
+// finishMacros removes the declarations of function-like macros that
+// were never called or could not be translated, expanding the calls
+// of the latter, and records the translated and expanded macros in prog.
+func (lx *lexer) finishMacros(prog *Prog) {
+	drop := make(map[*Decl]bool)
+	for _, m := range lx.macroList {
+		if m.Function && m.Decl != nil {
+			if len(m.calls) == 0 {
+				drop[m.Decl] = true
+				m.Decl = nil
+				if !m.used {
+					continue
+				}
+				if m.Expanded == "" {
+					m.Expanded = "arguments have side effects"
+				}
+			}
+			if m.Expanded != "" {
+				for _, c := range m.calls {
+					info := c.call.SyntaxInfo
+					*c.call = *c.exp
+					c.call.SyntaxInfo = info
+				}
+				drop[m.Decl] = true
+				m.Decl = nil
+				m.used = true
+			}
+		}
+		if m.Decl != nil || m.used {
+			prog.Macros = append(prog.Macros, m)
+		}
+	}
+	if len(drop) == 0 {
+		return
+	}
+	var out []*Decl
+	for _, d := range prog.Decls {
+		if !drop[d] {
+			out = append(out, d)
+		}
+	}
+	prog.Decls = out
+}
+
+// hasSideEffects reports whether evaluating x may have side effects:
+// whether it calls a function or assigns to a variable.
+func hasSideEffects(x *Expr) bool {
+	if x == nil {
+		return false
+	}
+	switch x.Op {
+	case Call, Eq, AddEq, SubEq, MulEq, DivEq, ModEq, LshEq, RshEq, AndEq, OrEq, XorEq,
+		PreInc, PreDec, PostInc, PostDec:
+		return true
+	}
+	if hasSideEffects(x.Left) || hasSideEffects(x.Right) {
+		return true
+	}
+	for _, y := range x.List {
+		if hasSideEffects(y) {
+			return true
+		}
+	}
+	return false
+}
+
+// countUses returns the number of uses of the parameter p in x
+// and whether any of them is only evaluated conditionally:
+// in the right operand of && or ||, or in a branch of ?:.
+func countUses(x *Expr, p *Decl) (n int, cond bool) {
+	if x == nil {
+		return 0, false
+	}
+	if x.Op == Name && x.XDecl == p {
+		return 1, false
+	}
+	switch x.Op {
+	case AndAnd, OrOr:
+		n, cond = countUses(x.Left, p)
+		nr, _ := countUses(x.Right, p)
+		return n + nr, cond || nr > 0
+	case Cond:
+		n, cond = countUses(x.List[0], p)
+		for _, y := range x.List[1:] {
+			ny, _ := countUses(y, p)
+			n += ny
+			cond = cond || ny > 0
+		}
+		return n, cond
+	}
+	for _, y := range append([]*Expr{x.Left, x.Right}, x.List...) {
+		ny, cy := countUses(y, p)
+		n += ny
+		cond = cond || cy
+	}
+	return n, cond
+}
+
+// copyExpr returns a deep copy of x in which every name
+// declared by a key of subst is replaced by a copy of its value.
+func copyExpr(x *Expr, subst map[*Decl]*Expr) *Expr {
+	if x == nil {
+		return nil
+	}
+	if x.Op == Name && subst[x.XDecl] != nil {
+		return copyExpr(subst[x.XDecl], nil)
+	}
+	y := new(Expr)
+	*y = *x
+	y.Left = copyExpr(x.Left, subst)
+	y.Right = copyExpr(x.Right, subst)
+	if x.List != nil {
+		y.List = make([]*Expr, len(x.List))
+		for i, z := range x.List {
+			y.List[i] = copyExpr(z, subst)
+		}
+	}
+	return y
+}
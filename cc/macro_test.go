@@ -0,0 +1,98 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cc_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/hajimehoshi/cingo/cc"
+)
+
+var macroTests = []struct {
+	in       string
+	out      string // initializer of the last declaration
+	expanded string // reason the macro was expanded, or "" if translated
+}{
+	{"#define N 42\nint x = N;", "N", ""},
+	{"#define N 1\n#define MASK (N << 3)\nint x = MASK;", "MASK", ""},
+	{"#define N (-1)\nint x = N;", "N", ""},
+	{"#define N 1 + 2\nint x = N;", "1 + 2", "replacement list is not parenthesized"},
+	{"int y;\n#define N (y + 1)\nint x = N;", "(y + 1)", "refers to non-constant y"},
+	{"#define N (1 < 2)\nint x = N;", "(1 < 2)", "uses Lt operator in constant"},
+	{"#define SQ(a) ((a) * (a))\nint x = SQ(2);", "SQ(2)", ""},
+	{"#define SQ(a) ((a) * (a))\nint x = SQ(2);\nlong y = 1;\nlong z = SQ(y);", "(((y)) * ((y)))", "argument for a has inconsistent types int and long"},
+	{"#define SQ(a) (a * a)\nint x = SQ(2);", "(2 * 2)", "parameter a is not parenthesized"},
+	{"int y;\n#define INC(a) ((a)++)\nint x = INC(y);", "(((y))++)", "uses PostInc operator"},
+	{"int g(int);\n#define G(a) (g(a))\nint x = G(1);", "(g(1))", "uses Call operator"},
+	{"int next(void);\n#define SQ(a) ((a) * (a))\nint x = SQ(next());", "(((next())) * ((next())))", "arguments have side effects"},
+	{"int next(void);\n#define SQ(a) ((a) * (a))\nint y = SQ(2);\nint x = SQ(next());", "(((next())) * ((next())))", ""},
+	{"int next(void);\n#define INC(a) ((a) + 1)\nint x = INC(next());", "INC(next())", ""},
+	{"int next(void);\n#define PICK(c, a) ((c) ? (a) : 0)\nint y = PICK(1, 2);\nint x = PICK(0, next());", "(((0)) ? ((next())) : 0)", ""},
+	{"int next(void);\n#define PICK(c, a) ((c) ? (a) : 0)\nint x = PICK(next(), 2);", "PICK(next(), 2)", ""},
+	{"int next(void);\n#define AND(a, b) ((a) && (b))\nint y = AND(1, 2);\nint x = AND(0, next());", "(((0)) && ((next())))", ""},
+}
+
+func TestMacro(t *testing.T) {
+	for _, tt := range macroTests {
+		prog, err := Read("x.c", strings.NewReader(tt.in))
+		if err != nil {
+			t.Errorf("Read(%q): %v", tt.in, err)
+			continue
+		}
+		d := prog.Decls[len(prog.Decls)-1]
+		if out := d.Init.Expr.String(); out != tt.out {
+			t.Errorf("Read(%q): initializer = %#q, want %#q", tt.in, out, tt.out)
+		}
+		if len(prog.Macros) == 0 {
+			t.Errorf("Read(%q): no macros recorded", tt.in)
+			continue
+		}
+		m := prog.Macros[len(prog.Macros)-1]
+		if m.Expanded != tt.expanded {
+			t.Errorf("Read(%q): macro %s expanded = %q, want %q", tt.in, m.Name, m.Expanded, tt.expanded)
+		}
+		if (m.Decl == nil) != (tt.expanded != "") {
+			t.Errorf("Read(%q): macro %s has decl %v", tt.in, m.Name, m.Decl)
+		}
+	}
+}
+
+func TestMacroDecls(t *testing.T) {
+	prog, err := Read("x.c", strings.NewReader("#define N 42\n#define SQ(a) ((a) * (a))\n#define UNUSED(a) ((a) + 1)\nint x = SQ(N);\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, d := range prog.Decls {
+		names = append(names, d.Name)
+	}
+	if got, want := strings.Join(names, " "), "N SQ x"; got != want {
+		t.Errorf("declarations = %s, want %s", got, want)
+	}
+	sq := prog.Decls[1]
+	if sq.Type == nil || sq.Type.String() != "func(a int) int" {
+		t.Errorf("SQ has type %v, want func(a int) int", sq.Type)
+	}
+}
+
+func TestMacroShadow(t *testing.T) {
+	prog, err := Read("x.c", strings.NewReader("int y;\n#define G(a) ((a) + y)\nint z = G(1);\nint f(void) { int y = 2; return G(1); }\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	z, f := prog.Decls[len(prog.Decls)-2], prog.Decls[len(prog.Decls)-1]
+	if got := z.Init.Expr.String(); got != "G(1)" {
+		t.Errorf("z = %s, want G(1)", got)
+	}
+	local := f.Body.Block[0].Decl
+	ret := f.Body.Block[1].Expr
+	if got := ret.String(); got != "(((1)) + y)" {
+		t.Fatalf("f returns %s, want expansion of G(1)", got)
+	}
+	if y := ret.Left.Right; y.XDecl != local {
+		t.Errorf("y in expansion of G(1) refers to %v, want local", y.XDecl)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Model is a C data model: the sizes in bytes of the integer
+// and pointer types on the target, and the signedness of plain char.
+type Model struct {
+	Name       string
+	CharSigned bool
+	Short      int64
+	Int        int64
+	Long       int64
+	LongLong   int64
+	Ptr        int64
+}
+
+// The common data models.
+var (
+	ILP32 = &Model{Name: "ILP32", CharSigned: true, Short: 2, Int: 4, Long: 4, LongLong: 8, Ptr: 4}
+	LP64  = &Model{Name: "LP64", CharSigned: true, Short: 2, Int: 4, Long: 8, LongLong: 8, Ptr: 8}
+	LLP64 = &Model{Name: "LLP64", CharSigned: true, Short: 2, Int: 4, Long: 4, LongLong: 8, Ptr: 8}
+)
+
+var models = []*Model{ILP32, LP64, LLP64}
+
+// ParseModel parses a data model description: the name of one of
+// the common models, optionally followed by comma-separated settings
+// overriding its char signedness or type sizes, as in "LP64,char=unsigned".
+// The keys are char (signed or unsigned), short, int, long, longlong and ptr.
+func ParseModel(s string) (*Model, error) {
+	f := strings.Split(s, ",")
+	var m Model
+	for _, base := range models {
+		if strings.EqualFold(base.Name, strings.TrimSpace(f[0])) {
+			m = *base
+		}
+	}
+	if m.Name == "" {
+		return nil, fmt.Errorf("unknown data model %q", f[0])
+	}
+	for _, setting := range f[1:] {
+		setting = strings.TrimSpace(setting)
+		i := strings.Index(setting, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid data model setting %q", setting)
+		}
+		key, value := setting[:i], setting[i+1:]
+		if key == "char" {
+			switch value {
+			case "signed":
+				m.CharSigned = true
+			case "unsigned":
+				m.CharSigned = false
+			default:
+				return nil, fmt.Errorf("invalid char signedness %q", value)
+			}
+			continue
+		}
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || size != 1 && size != 2 && size != 4 && size != 8 {
+			return nil, fmt.Errorf("invalid size %q for %s", value, key)
+		}
+		switch key {
+		case "short":
+			m.Short = size
+		case "int":
+			m.Int = size
+		case "long":
+			m.Long = size
+		case "longlong":
+			m.LongLong = size
+		case "ptr":
+			m.Ptr = size
+		default:
+			return nil, fmt.Errorf("unknown data model setting %q", key)
+		}
+	}
+	if len(f) > 1 {
+		m.Name = s
+	}
+	return &m, nil
+}
+
+func (m *Model) String() string {
+	return m.Name
+}
+
+// KindSize returns the size in bytes of values of the scalar kind k,
+// or -1 if k is not a scalar kind.
+func (m *Model) KindSize(k TypeKind) int64 {
+	switch k {
+	case Char, Uchar:
+		return 1
+	case Short, Ushort:
+		return m.Short
+	case Int, Uint, Enum:
+		return m.Int
+	case Long, Ulong:
+		return m.Long
+	case Longlong, Ulonglong:
+		return m.LongLong
+	case Float:
+		return 4
+	case Double:
+		return 8
+	case Ptr:
+		return m.Ptr
+	}
+	return -1
+}
+
+// IsSigned reports whether the integer kind k is signed.
+func (m *Model) IsSigned(k TypeKind) bool {
+	switch k {
+	case Char:
+		return m.CharSigned
+	case Short, Int, Long, Longlong, Enum:
+		return true
+	}
+	return false
+}
+
+// predefs returns the macros predefined for the target.
+func (m *Model) predefs() []predef {
+	var defs []predef
+	switch {
+	case m.Int == 4 && m.Long == 4 && m.Ptr == 4:
+		defs = append(defs, predef{name: "__ILP32__", value: "1"})
+	case m.Long == 8 && m.Ptr == 8:
+		defs = append(defs, predef{name: "__LP64__", value: "1"}, predef{name: "_LP64", value: "1"})
+	case m.Long == 4 && m.Ptr == 8:
+		defs = append(defs, predef{name: "_WIN64", value: "1"})
+	}
+	if !m.CharSigned {
+		defs = append(defs, predef{name: "__CHAR_UNSIGNED__", value: "1"})
+	}
+	for _, d := range []struct {
+		name string
+		size int64
+	}{
+		{"__SIZEOF_SHORT__", m.Short},
+		{"__SIZEOF_INT__", m.Int},
+		{"__SIZEOF_LONG__", m.Long},
+		{"__SIZEOF_LONG_LONG__", m.LongLong},
+		{"__SIZEOF_POINTER__", m.Ptr},
+	} {
+		defs = append(defs, predef{name: d.name, value: strconv.FormatInt(d.size, 10)})
+	}
+	return defs
+}
+
+// Truncate converts v to the integer kind k, wrapping as C does.
+func (m *Model) Truncate(k TypeKind, v int64) int64 {
+	size := m.KindSize(k)
+	if size <= 0 || size >= 8 {
+		return v
+	}
+	shift := uint(64 - 8*size)
+	if m.IsSigned(k) {
+		return v << shift >> shift
+	}
+	return int64(uint64(v) << shift >> shift)
+}
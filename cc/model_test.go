@@ -0,0 +1,79 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cc_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/hajimehoshi/cingo/cc"
+)
+
+var parseModelTests = []struct {
+	in  string
+	out Model
+	err string
+}{
+	{"LP64", *LP64, ""},
+	{"ilp32", *ILP32, ""},
+	{"LLP64,char=unsigned", Model{Name: "LLP64,char=unsigned", Short: 2, Int: 4, Long: 4, LongLong: 8, Ptr: 8}, ""},
+	{"LP64,int=8", Model{Name: "LP64,int=8", CharSigned: true, Short: 2, Int: 8, Long: 8, LongLong: 8, Ptr: 8}, ""},
+	{"LP32", Model{}, "unknown data model"},
+	{"LP64,char=maybe", Model{}, "invalid char signedness"},
+	{"LP64,long=3", Model{}, "invalid size"},
+	{"LP64,wchar=4", Model{}, "unknown data model setting"},
+}
+
+func TestParseModel(t *testing.T) {
+	for _, tt := range parseModelTests {
+		m, err := ParseModel(tt.in)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseModel(%q) = %v, want error containing %q", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseModel(%q): %v", tt.in, err)
+			continue
+		}
+		if *m != tt.out {
+			t.Errorf("ParseModel(%q) = %+v, want %+v", tt.in, *m, tt.out)
+		}
+	}
+}
+
+var modelTests = []struct {
+	model *Model
+	in    string
+	size  int64
+}{
+	{LP64, "long x;", 8},
+	{ILP32, "long x;", 4},
+	{LLP64, "long x;", 4},
+	{ILP32, "char *x;", 4},
+	{ILP32, "struct { char c; long *p; } x;", 8},
+	{LP64, "#ifdef __LP64__\nlong x;\n#else\nchar x;\n#endif", 8},
+	{ILP32, "#ifdef __LP64__\nlong x;\n#else\nchar x;\n#endif", 1},
+	{LLP64, "char x[__SIZEOF_POINTER__];", 8},
+	{ILP32, "char x[(unsigned char)257];", 1},
+	{ILP32, "char x[(unsigned int)-1 >> 28];", 15},
+}
+
+func TestModel(t *testing.T) {
+	defer SetModel(LP64)
+	for _, tt := range modelTests {
+		SetModel(tt.model)
+		prog, err := Read("x.c", strings.NewReader(tt.in))
+		if err != nil {
+			t.Errorf("Read(%q): %v", tt.in, err)
+			continue
+		}
+		typ := prog.Decls[len(prog.Decls)-1].Type
+		if size := typ.Size(tt.model); size != tt.size {
+			t.Errorf("%v: Read(%q): size = %d, want %d", tt.model, tt.in, size, tt.size)
+		}
+	}
+}
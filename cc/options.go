@@ -0,0 +1,96 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cc
+
+import (
+	"io"
+	"io/ioutil"
+)
+
+// Options control how C files are read.
+// Reading with distinct Options shares no state,
+// so that several programs can be read concurrently.
+type Options struct {
+	// Model is the data model assumed when parsing,
+	// which determines the predefined macros describing the target
+	// and the type sizes used in constant expressions.
+	// If nil, LP64 is assumed.
+	Model *Model
+
+	// Includes lists the directories searched for included files
+	// not found next to the file including them.
+	Includes []string
+
+	// ReadFile, if not nil, reads included files
+	// instead of ioutil.ReadFile.
+	ReadFile func(name string) ([]byte, error)
+
+	predefs []predef // command-line macro definitions, in order
+}
+
+// defaults are the Options used by the package-level
+// Read, ReadMany, AddInclude, Define, Undefine and SetModel.
+var defaults = &Options{Model: LP64}
+
+// AddInclude adds dir to the directories searched for included files.
+func (o *Options) AddInclude(dir string) {
+	o.Includes = append(o.Includes, dir)
+}
+
+// Define defines the object-like macro name with the given replacement text,
+// as if by a command-line -Dname=value option.
+func (o *Options) Define(name, value string) {
+	o.predefs = append(o.predefs, predef{name: name, value: value})
+}
+
+// Undefine removes any definition of the macro name,
+// as if by a command-line -Uname option.
+func (o *Options) Undefine(name string) {
+	o.predefs = append(o.predefs, predef{name: name, undef: true})
+}
+
+// Read is like ReadMany but reads a single file.
+func (o *Options) Read(name string, r io.Reader) (*Prog, error) {
+	return o.ReadMany([]string{name}, []io.Reader{r})
+}
+
+func (o *Options) model() *Model {
+	if o == nil || o.Model == nil {
+		return LP64
+	}
+	return o.Model
+}
+
+func (o *Options) readFile(name string) ([]byte, error) {
+	if o != nil && o.ReadFile != nil {
+		return o.ReadFile(name)
+	}
+	return ioutil.ReadFile(name)
+}
+
+// AddInclude adds dir to the directories searched for included files
+// by Read and ReadMany.
+func AddInclude(dir string) {
+	defaults.AddInclude(dir)
+}
+
+// Define defines the object-like macro name for Read and ReadMany,
+// as if by a command-line -Dname=value option.
+func Define(name, value string) {
+	defaults.Define(name, value)
+}
+
+// Undefine removes any definition of the macro name for Read and ReadMany,
+// as if by a command-line -Uname option.
+func Undefine(name string) {
+	defaults.Undefine(name)
+}
+
+// SetModel sets the data model assumed by Read and ReadMany,
+// and by Eval when given no model.
+// The default is LP64.
+func SetModel(m *Model) {
+	defaults.Model = m
+}
@@ -12,11 +12,22 @@ import (
 )
 
 func Read(name string, r io.Reader) (*Prog, error) {
-	return ReadMany([]string{name}, []io.Reader{r})
+	return defaults.Read(name, r)
 }
 
+// ReadMany parses and type-checks the named C files, read from readers,
+// with the options set by AddInclude, Define, Undefine and SetModel.
 func ReadMany(names []string, readers []io.Reader) (*Prog, error) {
-	lx := &lexer{}
+	return defaults.ReadMany(names, readers)
+}
+
+// ReadMany parses and type-checks the named C files, read from readers.
+// Parsing recovers from syntax errors at declaration and statement
+// boundaries and continues with the remaining files, so that the
+// returned error, an ErrorList, reports every syntax error found.
+// The program is type-checked only if it has no syntax errors.
+func (o *Options) ReadMany(names []string, readers []io.Reader) (*Prog, error) {
+	lx := &lexer{opts: o}
 	var prog *Prog
 	for i, name := range names {
 		if lx.includeSeen[name] != nil {
@@ -28,6 +39,8 @@ func ReadMany(names []string, readers []io.Reader) (*Prog, error) {
 			return nil, err
 		}
 		data = append(data, '\n')
+		lx.initMacros()
+		lx.ifs = nil
 		lx.start = startProg
 		lx.lexInput = lexInput{
 			input:  string(data),
@@ -35,8 +48,9 @@ func ReadMany(names []string, readers []io.Reader) (*Prog, error) {
 			lineno: 1,
 		}
 		lx.parse()
-		if lx.errors != nil {
-			return nil, fmt.Errorf("%v", lx.errors[0])
+		if lx.prog == nil {
+			// The parser gave up on this file.
+			continue
 		}
 		if prog == nil {
 			prog = lx.prog
@@ -58,11 +72,14 @@ func ReadMany(names []string, readers []io.Reader) (*Prog, error) {
 			}
 		}
 	}
+	if lx.errors != nil {
+		return nil, lx.errors
+	}
 	lx.prog = prog
 	lx.assignComments()
 	lx.typecheck(lx.prog)
 	if lx.errors != nil {
-		return nil, fmt.Errorf("%v", strings.Join(lx.errors, "\n"))
+		return nil, lx.errors
 	}
 
 	removeDuplicates(lx.prog)
@@ -88,7 +105,8 @@ func ParseExpr(str string) (*Expr, error) {
 
 type Prog struct {
 	SyntaxInfo
-	Decls []*Decl
+	Decls  []*Decl
+	Macros []*Macro // translated macros and expanded macros that could not be
 }
 
 // removeDuplicates drops the duplicated declarations
@@ -7,6 +7,7 @@ package cc_test
 import (
 	"fmt"
 	"strings"
+	"testing"
 
 	. "github.com/hajimehoshi/cingo/cc"
 )
@@ -82,3 +83,15 @@ int main() {
 	//       stmt: Return
 	//         expr: Number, 0
 }
+
+func TestFuncPtrCompare(t *testing.T) {
+	src := `typedef int (*fn)(int);
+int f(int x) { return x; }
+int g(fn p, int (*q)(int)) {
+	return p == f || f != q || p == &f || p == 0;
+}
+`
+	if _, err := Read("a.c", strings.NewReader(src)); err != nil {
+		t.Errorf("comparing functions and function pointers: %v", err)
+	}
+}
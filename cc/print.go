@@ -599,6 +599,9 @@ func (p *Printer) printDecl(x *Decl) {
 			}
 		}
 	}
+	if x.Bits != nil {
+		p.Print(" : ", x.Bits)
+	}
 	if x.Init != nil {
 		p.Print(" = ", x.Init)
 	}
@@ -21,6 +21,8 @@ type Type struct {
 	Width    *Expr
 	Name     string
 	TypeDecl *Decl
+
+	ImplicitWidth bool // Width was computed from the initializer
 }
 
 type TypeKind int
@@ -317,11 +319,15 @@ type Decl struct {
 	Storage Storage
 	Init    *Init
 	Body    *Stmt
+	Bits    *Expr // width of a bit-field, or nil
 
 	XOuter    *Decl
 	CurFn     *Decl
 	OuterType *Type
 	GoPackage string
+	Macro     *Macro // macro translated into this declaration
+	Method    bool   // function translated into a Go method on its first parameter
+	Value     *Value // value of an enumeration constant, if known
 }
 
 func (d *Decl) String() string {
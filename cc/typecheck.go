@@ -112,16 +112,47 @@ func (lx *lexer) typecheck(prog *Prog) {
 	for _, decl := range prog.Decls {
 		lx.typecheckDecl(decl)
 	}
+	lx.finishMacros(prog)
 }
 
 func (lx *lexer) typecheckDecl(decl *Decl) {
+	if decl.Macro != nil {
+		if !decl.Macro.Function {
+			lx.typecheckMacroConst(decl)
+		}
+		return
+	}
 	lx.typecheckType(decl.Type)
 	if decl.Init != nil {
 		lx.typecheckInit(decl.Type, decl.Init)
+		lx.inferWidth(decl)
 	}
 	lx.typecheckStmt(decl.Body)
 }
 
+// inferWidth gives the array decl declared without a width
+// the width of its braced initializer.
+func (lx *lexer) inferWidth(decl *Decl) {
+	t := decl.Type
+	if t == nil || t.Kind != Array || t.Width != nil || decl.Init.Braced == nil {
+		return
+	}
+	n := int64(len(decl.Init.Braced))
+	if n > 0 && len(decl.Init.Braced[0].Prefix) > 0 {
+		// The width is one more than the largest index.
+		n = 0
+		for _, elem := range decl.Init.Braced {
+			if pre := elem.Prefix[0]; pre.Index != nil {
+				if c, err := Eval(pre.Index, lx.opts.model()); err == nil && c.IsInt() && c.Int >= n {
+					n = c.Int + 1
+				}
+			}
+		}
+	}
+	t.Width = &Expr{SyntaxInfo: decl.Init.SyntaxInfo, Op: Number, Text: fmt.Sprint(n), XType: IntType}
+	t.ImplicitWidth = true
+}
+
 func (lx *lexer) typecheckStmt(stmt *Stmt) {
 	if stmt == nil {
 		return
@@ -191,15 +222,77 @@ func (lx *lexer) typecheckType(typ *Type) {
 	case Enum:
 		// Give enum type to the declared names.
 		// Perhaps should be done during parsing.
+		// Record their values, each one more than the last
+		// unless it has an initializer.
+		next, known := int64(0), true
 		for _, decl := range typ.Decls {
 			if decl.Init != nil {
 				lx.typecheckInit(typ, decl.Init)
+				c, err := Eval(decl.Init.Expr, lx.opts.model())
+				if err == nil && !c.IsInt() {
+					err = fmt.Errorf("%v is not an integer", decl.Init.Expr)
+				}
+				if err != nil {
+					lx.setSpan(decl.Span)
+					lx.Errorf("invalid value for enumerator %s: %v", decl.Name, err)
+				}
+				next, known = c.Int, err == nil
+			}
+			if known {
+				decl.Value = &Value{Kind: Int, Int: next}
 			}
+			next++
 			decl.Type = typ
+			decl.OuterType = typ
 		}
+
+	case Array:
+		lx.typecheckType(typ.Base)
+		lx.typecheckExpr(typ.Width)
+
+	case Struct, Union:
+		if lx.structSeen[typ] {
+			return
+		}
+		if lx.structSeen == nil {
+			lx.structSeen = make(map[*Type]bool)
+		}
+		lx.structSeen[typ] = true
+		for _, decl := range typ.Decls {
+			lx.typecheckType(decl.Type)
+			if decl.Bits != nil {
+				lx.typecheckBits(decl)
+			}
+		}
+	}
+}
+
+// typecheckBits checks the width of the bit-field decl.
+func (lx *lexer) typecheckBits(decl *Decl) {
+	lx.setSpan(decl.Span)
+	lx.typecheckExpr(decl.Bits)
+	if decl.Type == nil || !isInt(decl.Type) {
+		lx.Errorf("bit-field %s has non-integer type %v", decl.Name, decl.Type)
+		return
+	}
+	n, ok := ConstInt(decl.Bits, lx.opts.model())
+	switch {
+	case !ok:
+		lx.Errorf("bit-field %s has non-constant width %v", decl.Name, decl.Bits)
+	case n < 0:
+		lx.Errorf("bit-field %s has negative width %d", decl.Name, n)
+	case n == 0 && decl.Name != "":
+		lx.Errorf("named bit-field %s has zero width", decl.Name)
+	case n > 8*decl.Type.Size(lx.opts.model()):
+		lx.Errorf("width of bit-field %s (%d bits) exceeds its type %v", decl.Name, n, decl.Type)
 	}
 }
 
+// isBitField reports whether x refers to a bit-field.
+func isBitField(x *Expr) bool {
+	return (x.Op == Dot || x.Op == Arrow) && x.XDecl != nil && x.XDecl.Bits != nil
+}
+
 func (lx *lexer) typecheckInit(typ *Type, x *Init) {
 	// TODO: Type check initializers (ugh).
 
@@ -226,15 +319,16 @@ func (lx *lexer) typecheckInit(typ *Type, x *Init) {
 	}
 
 	switch typ.Kind {
-	case Array, Struct:
+	case Array, Struct, Union:
 		// ok
-	case Union:
-		// C allows this but we do not.
-		fallthrough
 	default:
 		lx.Errorf("cannot initialize type %v with braced initializer", typ)
 		return
 	}
+	if typ.Kind == Union && len(x.Braced) > 1 {
+		lx.Errorf("more than one initializer element for %v", typ)
+		return
+	}
 
 	// Keep our sanity: require that either all elements have prefixes or none do.
 	// This is not required by the C standard; it just makes this code more tractable.
@@ -257,14 +351,13 @@ func (lx *lexer) typecheckInit(typ *Type, x *Init) {
 	if n == 0 {
 		// Assign elements in order.
 		if typ.Kind == Array {
-			// TODO: Check against typ.Width and record new typ.Width if missing
 			for _, elem := range x.Braced {
 				lx.typecheckInit(typ.Base, elem)
 			}
 			return
 		}
 
-		// Struct
+		// Struct or union
 		if len(x.Braced) > len(typ.Decls) {
 			lx.Errorf("more initializer elements than struct fields in %v (%d > %d)", typ, len(x.Braced), len(typ.Decls))
 			return
@@ -287,14 +380,15 @@ func (lx *lexer) typecheckInit(typ *Type, x *Init) {
 				continue
 			}
 			lx.typecheckExpr(pre.Index)
-			// TODO: check that pre.Index is integer constant
-			// TODO: record width if needed
+			if c, err := Eval(pre.Index, lx.opts.model()); err != nil || !c.IsInt() {
+				lx.Errorf("array index %v in initializer is not an integer constant", pre.Index)
+			}
 			lx.typecheckInit(typ.Base, elem)
 		}
 		return
 	}
 
-	// Struct
+	// Struct or union
 	for _, elem := range x.Braced {
 		lx.setSpan(elem.Span)
 		pre := elem.Prefix[0]
@@ -312,6 +406,16 @@ func (lx *lexer) typecheckInit(typ *Type, x *Init) {
 	}
 }
 
+// kindType maps the integer kinds of constants to their types.
+var kindType = map[TypeKind]*Type{
+	Int:       IntType,
+	Uint:      UintType,
+	Long:      LongType,
+	Ulong:     UlongType,
+	Longlong:  LonglongType,
+	Ulonglong: UlonglongType,
+}
+
 func stripTypedef(t *Type) *Type {
 	if t != nil && t.Kind == TypedefType && t.Base != nil {
 		t = t.Base
@@ -348,6 +452,15 @@ func toPtr(t *Type) *Type {
 	return nil
 }
 
+// decayFunc returns the pointer type a function of type t
+// is converted to when used as a value, or t if it is not a function type.
+func decayFunc(t *Type) *Type {
+	if t.Is(Func) {
+		return &Type{Kind: Ptr, Base: t}
+	}
+	return t
+}
+
 func isArith(t *Type) bool {
 	t = stripTypedef(t)
 	return Char <= t.Kind && t.Kind <= Enum
@@ -688,6 +801,10 @@ func (lx *lexer) typecheckExpr(x *Expr) {
 		}
 
 	case Addr:
+		if isBitField(x.Left) {
+			lx.Errorf("cannot take address of bit-field %v", x.Left)
+			break
+		}
 		t := x.Left.XType
 		if t == nil {
 			break
@@ -765,6 +882,10 @@ func (lx *lexer) typecheckExpr(x *Expr) {
 		x.XType = d.Type
 
 	case Call:
+		if x.Left.Op == Name && x.Left.XDecl != nil && x.Left.XDecl.Macro != nil {
+			lx.typecheckMacroCall(x)
+			break
+		}
 		t := x.Left.XType
 		if t == nil {
 			lx.Errorf("no info for call of %v", x.Left)
@@ -874,7 +995,7 @@ func (lx *lexer) typecheckExpr(x *Expr) {
 
 	case EqEq, NotEq, Gt, GtEq, Lt, LtEq:
 		x.XType = BoolType
-		l, r := x.Left.XType, x.Right.XType
+		l, r := decayFunc(x.Left.XType), decayFunc(x.Right.XType)
 		if l == nil || r == nil {
 			break
 		}
@@ -977,6 +1098,9 @@ func (lx *lexer) typecheckExpr(x *Expr) {
 			lx.Errorf("undefined: %s", x.Text)
 			break
 		}
+		if x.XDecl.Macro != nil && !x.XDecl.Macro.Function {
+			lx.typecheckMacroConst(x.XDecl)
+		}
 		//	XXX this happens for enums
 		//	if x.XDecl.Type == nil {
 		//		lx.Errorf("missing type for defined variable: %s", x.Text)
@@ -1018,55 +1142,13 @@ func (lx *lexer) typecheckExpr(x *Expr) {
 			break
 		}
 
-		// integer
-		num = strings.TrimRight(num, "uUlL")
-		suf := x.Text[len(num):]
-		i, err := strconv.ParseUint(num, 0, 64)
+		// integer, typed as C types it under the data model
+		c, err := Eval(x, lx.opts.model())
 		if err != nil {
-			lx.Errorf("invalid integer constant %v", x.Text)
+			lx.Errorf("%v", err)
 			break
 		}
-		_ = i // TODO use this
-		has := strings.Contains
-		suf = strings.ToUpper(suf)
-		switch {
-		case has(suf, "U") && has(suf, "LL"):
-			x.XType = UlonglongType
-		case has(suf, "U") && has(suf, "L"):
-			if uint64(uint32(i)) == i {
-				x.XType = UlongType
-			} else {
-				x.XType = UlonglongType
-			}
-		case has(suf, "U"):
-			if uint64(uint32(i)) == i {
-				x.XType = UintType
-			} else {
-				x.XType = UlonglongType
-			}
-		case has(suf, "LL"):
-			if int64(i) >= 0 {
-				x.XType = LonglongType
-			} else {
-				lx.Errorf("integer constant %v overflows signed long long", x.Text)
-			}
-		case has(suf, "L"):
-			if int32(i) >= 0 && uint64(int32(i)) == i {
-				x.XType = LongType
-			} else if int64(i) >= 0 {
-				x.XType = LonglongType
-			} else {
-				lx.Errorf("integer constant %v overflows signed long long", x.Text)
-			}
-		default:
-			if int32(i) >= 0 && uint64(int32(i)) == i {
-				x.XType = IntType
-			} else if int64(i) >= 0 {
-				x.XType = LonglongType
-			} else {
-				lx.Errorf("integer constant %v overflows signed long long", x.Text)
-			}
-		}
+		x.XType = kindType[c.Kind]
 
 	case Offsetof:
 		x.XType = LongType
@@ -1076,6 +1158,10 @@ func (lx *lexer) typecheckExpr(x *Expr) {
 		d := structDot(stripTypedef(x.Type), x.Left.Text)
 		if d == nil {
 			lx.Errorf("unknown field %v.%v", x.Type, x.Left.Text)
+		} else if d.Bits != nil {
+			lx.Errorf("cannot take offset of bit-field %v.%v", x.Type, x.Left.Text)
+		} else {
+			x.Left.XDecl = d
 		}
 
 	case Paren:
@@ -1105,6 +1191,9 @@ func (lx *lexer) typecheckExpr(x *Expr) {
 		x.XType = t
 
 	case SizeofExpr:
+		if isBitField(x.Left) {
+			lx.Errorf("cannot take size of bit-field %v", x.Left)
+		}
 		x.XType = LongType
 
 	case SizeofType:
@@ -54,6 +54,7 @@ func walk(x Syntax, before, after func(Syntax, int), seen map[Syntax]struct{}, i
 
 	case *Decl:
 		walk(x.Type, before, after, seen, indent+1)
+		walk(x.Bits, before, after, seen, indent+1)
 		walk(x.Init, before, after, seen, indent+1)
 		walk(x.Body, before, after, seen, indent+1)
 
@@ -18,7 +18,12 @@ type idecor struct {
 	i *Init
 }
 
-//line cc.y:49
+type sudecor struct {
+	d    func(*Type) (*Type, string)
+	bits *Expr
+}
+
+//line cc.y:54
 type yySymType struct {
 	yys      int
 	abdecor  func(*Type) *Type
@@ -30,6 +35,8 @@ type yySymType struct {
 	exprs    []*Expr
 	idec     idecor
 	idecs    []idecor
+	sudec    sudecor
+	sudecs   []sudecor
 	init     *Init
 	inits    []*Init
 	label    *Label
@@ -221,9 +228,10 @@ var yyToknames = [...]string{
 	"startExpr",
 	"startProg",
 	"tokEOF",
-	"'}'",
 	"';'",
+	"'}'",
 }
+
 var yyStatenames = [...]string{}
 
 const yyEofCode = 1
@@ -231,385 +239,445 @@ const yyErrCode = 2
 const yyInitialStackSize = 16
 
 //line yacctab:1
-var yyExca = [...]int{
+var yyExca = [...]int16{
 	-1, 1,
 	1, -1,
 	-2, 0,
-	-1, 118,
-	52, 100,
-	101, 100,
-	-2, 180,
-	-1, 136,
-	51, 171,
-	-2, 145,
-	-1, 138,
-	51, 171,
-	-2, 150,
-	-1, 238,
-	101, 206,
-	-2, 170,
-	-1, 269,
-	65, 171,
-	-2, 91,
+	-1, 121,
+	52, 104,
+	100, 104,
+	-2, 184,
+	-1, 139,
+	51, 175,
+	-2, 149,
+	-1, 141,
+	51, 175,
+	-2, 154,
+	-1, 241,
+	100, 210,
+	-2, 174,
+	-1, 273,
+	65, 175,
+	-2, 95,
+	-1, 291,
+	4, 186,
+	7, 186,
+	8, 186,
+	10, 186,
+	11, 186,
+	14, 186,
+	15, 186,
+	16, 186,
+	23, 186,
+	24, 186,
+	25, 186,
+	28, 186,
+	30, 186,
+	31, 186,
+	32, 186,
+	34, 186,
+	39, 186,
+	44, 186,
+	47, 186,
+	48, 186,
+	51, 186,
+	70, 186,
+	79, 186,
+	80, 186,
+	81, 186,
+	85, 186,
+	86, 186,
+	87, 186,
+	92, 186,
+	94, 186,
+	95, 186,
+	100, 186,
+	-2, 0,
+	-1, 341,
+	4, 186,
+	7, 186,
+	8, 186,
+	10, 186,
+	11, 186,
+	14, 186,
+	15, 186,
+	16, 186,
+	23, 186,
+	24, 186,
+	25, 186,
+	28, 186,
+	30, 186,
+	31, 186,
+	32, 186,
+	34, 186,
+	39, 186,
+	44, 186,
+	47, 186,
+	48, 186,
+	51, 186,
+	70, 186,
+	79, 186,
+	80, 186,
+	81, 186,
+	85, 186,
+	86, 186,
+	87, 186,
+	92, 186,
+	94, 186,
+	95, 186,
+	100, 186,
+	-2, 0,
 }
 
 const yyPrivate = 57344
 
-const yyLast = 1452
-
-var yyAct = [...]int{
-
-	309, 7, 112, 120, 342, 302, 259, 31, 225, 266,
-	213, 279, 111, 240, 98, 99, 100, 101, 102, 103,
-	104, 105, 106, 193, 196, 219, 117, 343, 237, 49,
-	109, 5, 223, 123, 4, 217, 308, 133, 131, 377,
-	129, 136, 138, 375, 227, 368, 367, 32, 363, 356,
-	110, 354, 337, 336, 334, 289, 118, 288, 187, 305,
-	34, 296, 140, 141, 142, 143, 144, 145, 146, 147,
-	148, 149, 150, 151, 152, 153, 154, 155, 156, 157,
-	158, 130, 160, 161, 162, 163, 164, 165, 166, 167,
-	168, 169, 170, 127, 292, 135, 244, 59, 210, 174,
-	175, 249, 61, 62, 63, 64, 65, 159, 35, 295,
-	3, 2, 96, 92, 380, 91, 184, 94, 93, 95,
-	173, 124, 180, 96, 92, 374, 91, 124, 94, 93,
-	95, 125, 190, 366, 365, 110, 364, 125, 176, 177,
-	361, 128, 360, 134, 66, 67, 61, 62, 63, 64,
-	65, 195, 189, 284, 188, 235, 96, 92, 189, 91,
-	188, 94, 93, 95, 283, 252, 198, 197, 181, 189,
-	199, 188, 121, 215, 130, 205, 203, 179, 256, 183,
-	207, 178, 362, 122, 345, 344, 341, 339, 135, 257,
-	333, 224, 226, 135, 230, 332, 212, 299, 115, 114,
-	108, 221, 282, 6, 242, 211, 258, 207, 243, 204,
-	210, 195, 224, 238, 348, 347, 291, 232, 233, 31,
-	202, 216, 247, 208, 234, 221, 231, 274, 229, 290,
-	376, 271, 254, 280, 281, 253, 134, 206, 60, 128,
-	192, 134, 269, 246, 260, 250, 255, 226, 248, 238,
-	208, 201, 200, 186, 277, 232, 116, 261, 97, 63,
-	64, 65, 352, 267, 241, 263, 221, 96, 92, 57,
-	91, 124, 94, 93, 95, 268, 294, 33, 185, 285,
-	286, 125, 297, 301, 300, 293, 195, 270, 228, 287,
-	298, 1, 52, 304, 269, 172, 57, 247, 37, 226,
-	303, 11, 58, 194, 113, 230, 306, 132, 48, 56,
-	126, 233, 312, 137, 139, 267, 55, 278, 317, 311,
-	53, 313, 245, 338, 54, 335, 276, 119, 340, 58,
-	171, 346, 272, 273, 264, 265, 239, 236, 230, 318,
-	28, 26, 218, 191, 353, 29, 182, 0, 0, 0,
+const yyLast = 1389
+
+var yyAct = [...]int16{
+	307, 7, 113, 123, 348, 291, 262, 32, 228, 270,
+	216, 50, 283, 243, 99, 100, 101, 102, 103, 104,
+	105, 106, 107, 196, 199, 112, 120, 222, 349, 240,
+	110, 220, 5, 306, 230, 126, 4, 226, 136, 64,
+	65, 66, 139, 141, 134, 313, 132, 97, 93, 300,
+	92, 111, 95, 94, 96, 316, 317, 121, 296, 33,
+	117, 118, 247, 143, 144, 145, 146, 147, 148, 149,
+	150, 151, 152, 153, 154, 155, 156, 157, 158, 159,
+	160, 161, 35, 163, 164, 165, 166, 167, 168, 169,
+	170, 171, 172, 173, 133, 384, 36, 60, 299, 382,
+	177, 178, 375, 374, 370, 97, 93, 316, 92, 162,
+	95, 94, 96, 363, 361, 343, 130, 187, 138, 342,
+	340, 293, 176, 183, 292, 190, 3, 2, 387, 381,
+	131, 192, 137, 191, 238, 213, 198, 111, 252, 179,
+	180, 373, 372, 81, 82, 83, 84, 85, 86, 87,
+	88, 89, 90, 91, 80, 366, 79, 78, 77, 76,
+	75, 73, 74, 69, 70, 71, 72, 67, 68, 62,
+	63, 64, 65, 66, 202, 201, 200, 371, 368, 97,
+	93, 367, 92, 210, 95, 94, 96, 288, 192, 133,
+	191, 127, 287, 127, 227, 229, 198, 233, 255, 193,
+	218, 128, 208, 128, 206, 182, 181, 245, 214, 237,
+	210, 246, 184, 138, 6, 227, 211, 224, 138, 369,
+	235, 236, 32, 186, 351, 250, 219, 137, 232, 241,
+	131, 234, 137, 350, 347, 257, 192, 345, 191, 339,
+	338, 224, 124, 211, 259, 273, 215, 251, 253, 258,
+	229, 249, 116, 125, 303, 260, 115, 281, 235, 109,
+	265, 286, 261, 207, 213, 241, 271, 354, 353, 267,
+	295, 198, 205, 278, 294, 275, 256, 209, 61, 195,
+	298, 264, 224, 290, 289, 204, 203, 305, 304, 297,
+	284, 285, 189, 383, 233, 302, 53, 312, 273, 40,
+	58, 250, 119, 229, 311, 47, 98, 175, 114, 46,
+	314, 359, 244, 57, 42, 236, 43, 323, 58, 271,
+	56, 188, 41, 44, 54, 127, 301, 274, 55, 344,
+	45, 341, 48, 59, 346, 128, 231, 352, 263, 324,
+	1, 38, 11, 272, 233, 62, 63, 64, 65, 66,
+	360, 59, 34, 53, 197, 97, 93, 58, 92, 135,
+	95, 94, 96, 49, 318, 114, 282, 355, 356, 310,
+	57, 319, 378, 379, 380, 377, 362, 56, 248, 364,
+	365, 54, 140, 142, 386, 55, 129, 385, 388, 280,
+	59, 122, 174, 276, 277, 268, 269, 242, 376, 81,
+	82, 83, 84, 85, 86, 87, 88, 89, 90, 91,
+	80, 239, 79, 78, 77, 76, 75, 73, 74, 69,
+	70, 71, 72, 67, 68, 62, 63, 64, 65, 66,
+	29, 26, 221, 194, 30, 97, 93, 315, 92, 185,
+	95, 94, 96, 81, 82, 83, 84, 85, 86, 87,
+	88, 89, 90, 91, 80, 0, 79, 78, 77, 76,
+	75, 73, 74, 69, 70, 71, 72, 67, 68, 62,
+	63, 64, 65, 66, 0, 0, 0, 0, 0, 97,
+	93, 0, 92, 279, 95, 94, 96, 217, 81, 82,
+	83, 84, 85, 86, 87, 88, 89, 90, 91, 80,
+	0, 79, 78, 77, 76, 75, 73, 74, 69, 70,
+	71, 72, 67, 68, 62, 63, 64, 65, 66, 0,
+	0, 0, 0, 0, 97, 93, 0, 92, 0, 95,
+	94, 96, 81, 82, 83, 84, 85, 86, 87, 88,
+	89, 90, 91, 80, 0, 79, 78, 77, 76, 75,
+	73, 74, 69, 70, 71, 72, 67, 68, 62, 63,
+	64, 65, 66, 0, 0, 0, 0, 0, 97, 93,
+	0, 92, 0, 95, 94, 96, 325, 0, 0, 322,
+	321, 0, 326, 335, 0, 0, 327, 336, 328, 0,
+	0, 0, 0, 0, 0, 329, 330, 331, 0, 0,
+	10, 0, 337, 9, 21, 0, 332, 0, 0, 0,
+	0, 333, 0, 0, 0, 0, 23, 0, 0, 334,
+	24, 0, 0, 264, 75, 73, 74, 69, 70, 71,
+	72, 67, 68, 62, 63, 64, 65, 66, 0, 0,
+	0, 0, 13, 97, 93, 0, 92, 0, 95, 94,
+	96, 14, 15, 12, 0, 0, 0, 16, 17, 20,
+	0, 0, 0, 0, 22, 53, 19, 18, 40, 58,
+	0, 0, 320, 0, 47, 39, 0, 114, 46, 0,
+	0, 0, 57, 42, 10, 43, 8, 9, 21, 56,
+	0, 41, 44, 54, 51, 0, 37, 55, 52, 45,
+	23, 48, 59, 0, 24, 78, 77, 76, 75, 73,
+	74, 69, 70, 71, 72, 67, 68, 62, 63, 64,
+	65, 66, 0, 0, 0, 0, 13, 97, 93, 0,
+	92, 0, 95, 94, 96, 14, 15, 12, 0, 0,
+	0, 16, 17, 20, 0, 28, 0, 0, 22, 27,
+	19, 18, 53, 0, 0, 40, 58, 0, 0, 0,
+	0, 47, 39, 0, 31, 46, 0, 0, 0, 57,
+	42, 0, 43, 0, 0, 0, 56, 0, 41, 44,
+	54, 51, 0, 37, 55, 52, 45, 308, 48, 59,
+	0, 0, 0, 0, 53, 0, 0, 40, 58, 0,
+	0, 0, 0, 47, 39, 0, 114, 46, 0, 0,
+	0, 57, 42, 0, 43, 0, 0, 0, 56, 0,
+	41, 44, 54, 51, 0, 37, 55, 52, 45, 53,
+	48, 59, 40, 58, 0, 0, 0, 0, 47, 39,
+	0, 114, 46, 0, 254, 0, 57, 42, 0, 43,
+	0, 0, 0, 56, 0, 41, 44, 54, 51, 0,
+	37, 55, 52, 45, 28, 48, 59, 0, 27, 0,
+	0, 53, 0, 0, 40, 58, 0, 0, 0, 0,
+	47, 39, 0, 31, 46, 0, 309, 0, 57, 42,
+	0, 43, 0, 0, 0, 56, 0, 41, 44, 54,
+	51, 0, 37, 55, 52, 45, 0, 48, 59, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	349, 350, 0, 0, 0, 371, 372, 373, 370, 355,
-	0, 0, 357, 358, 0, 0, 0, 379, 0, 0,
-	378, 381, 0, 0, 0, 0, 0, 0, 0, 0,
-	369, 80, 81, 82, 83, 84, 85, 86, 87, 88,
-	89, 90, 79, 359, 78, 77, 76, 75, 74, 72,
-	73, 68, 69, 70, 71, 66, 67, 61, 62, 63,
-	64, 65, 0, 0, 0, 0, 0, 96, 92, 0,
-	91, 0, 94, 93, 95, 319, 0, 0, 316, 315,
-	0, 320, 329, 0, 0, 321, 330, 322, 0, 0,
-	0, 0, 0, 0, 323, 324, 325, 0, 0, 10,
-	0, 331, 9, 21, 0, 326, 0, 0, 0, 0,
-	327, 0, 0, 0, 0, 23, 0, 0, 328, 24,
-	0, 0, 260, 74, 72, 73, 68, 69, 70, 71,
-	66, 67, 61, 62, 63, 64, 65, 0, 0, 0,
-	0, 13, 96, 92, 0, 91, 0, 94, 93, 95,
-	14, 15, 12, 0, 0, 0, 16, 17, 20, 0,
-	0, 0, 0, 22, 0, 19, 18, 0, 0, 0,
-	0, 0, 314, 80, 81, 82, 83, 84, 85, 86,
-	87, 88, 89, 90, 79, 0, 78, 77, 76, 75,
-	74, 72, 73, 68, 69, 70, 71, 66, 67, 61,
-	62, 63, 64, 65, 0, 0, 0, 0, 0, 96,
-	92, 307, 91, 0, 94, 93, 95, 80, 81, 82,
-	83, 84, 85, 86, 87, 88, 89, 90, 79, 0,
-	78, 77, 76, 75, 74, 72, 73, 68, 69, 70,
-	71, 66, 67, 61, 62, 63, 64, 65, 0, 0,
-	0, 0, 0, 96, 92, 0, 91, 275, 94, 93,
-	95, 214, 80, 81, 82, 83, 84, 85, 86, 87,
-	88, 89, 90, 79, 0, 78, 77, 76, 75, 74,
-	72, 73, 68, 69, 70, 71, 66, 67, 61, 62,
-	63, 64, 65, 0, 0, 0, 0, 0, 96, 92,
-	0, 91, 0, 94, 93, 95, 80, 81, 82, 83,
-	84, 85, 86, 87, 88, 89, 90, 79, 0, 78,
-	77, 76, 75, 74, 72, 73, 68, 69, 70, 71,
-	66, 67, 61, 62, 63, 64, 65, 0, 0, 0,
-	0, 0, 96, 92, 0, 91, 0, 94, 93, 95,
-	52, 0, 0, 39, 57, 0, 0, 0, 0, 46,
-	38, 0, 113, 45, 0, 0, 0, 56, 41, 10,
-	42, 8, 9, 21, 55, 0, 40, 43, 53, 50,
-	0, 36, 54, 51, 44, 23, 47, 58, 0, 24,
-	77, 76, 75, 74, 72, 73, 68, 69, 70, 71,
-	66, 67, 61, 62, 63, 64, 65, 0, 0, 0,
-	0, 13, 96, 92, 0, 91, 0, 94, 93, 95,
-	14, 15, 12, 0, 0, 0, 16, 17, 20, 0,
-	0, 27, 0, 22, 52, 19, 18, 39, 57, 0,
-	0, 0, 0, 46, 38, 0, 30, 45, 0, 0,
-	0, 56, 41, 0, 42, 0, 0, 0, 55, 0,
-	40, 43, 53, 50, 0, 36, 54, 51, 44, 52,
-	47, 58, 39, 57, 0, 0, 0, 0, 46, 38,
-	0, 113, 45, 0, 0, 0, 56, 41, 0, 42,
-	0, 0, 0, 55, 0, 40, 43, 53, 50, 0,
-	36, 54, 51, 44, 52, 47, 58, 39, 57, 0,
-	0, 0, 0, 46, 38, 0, 113, 45, 0, 0,
-	0, 56, 41, 0, 42, 251, 0, 0, 55, 0,
-	40, 43, 53, 50, 0, 36, 54, 51, 44, 0,
-	47, 58, 27, 0, 0, 52, 0, 0, 39, 57,
-	0, 0, 0, 0, 46, 38, 0, 30, 45, 0,
-	310, 0, 56, 41, 0, 42, 0, 0, 0, 55,
-	0, 40, 43, 53, 50, 0, 36, 54, 51, 44,
-	0, 47, 58, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 79, 262, 78, 77, 76, 75,
-	74, 72, 73, 68, 69, 70, 71, 66, 67, 61,
-	62, 63, 64, 65, 0, 0, 0, 0, 0, 96,
-	92, 0, 91, 0, 94, 93, 95, 0, 0, 0,
-	0, 0, 0, 0, 0, 25, 76, 75, 74, 72,
-	73, 68, 69, 70, 71, 66, 67, 61, 62, 63,
-	64, 65, 0, 0, 0, 0, 0, 96, 92, 0,
-	91, 0, 94, 93, 95, 75, 74, 72, 73, 68,
-	69, 70, 71, 66, 67, 61, 62, 63, 64, 65,
-	0, 0, 0, 0, 0, 96, 92, 0, 91, 0,
-	94, 93, 95, 72, 73, 68, 69, 70, 71, 66,
-	67, 61, 62, 63, 64, 65, 10, 0, 8, 9,
-	21, 96, 92, 0, 91, 0, 94, 93, 95, 0,
-	0, 0, 23, 0, 0, 0, 24, 0, 0, 209,
-	0, 0, 73, 68, 69, 70, 71, 66, 67, 61,
-	62, 63, 64, 65, 0, 0, 0, 0, 13, 96,
-	92, 0, 91, 0, 94, 93, 95, 14, 15, 12,
-	0, 0, 0, 16, 17, 20, 0, 280, 281, 0,
-	22, 0, 19, 18, 68, 69, 70, 71, 66, 67,
-	61, 62, 63, 64, 65, 10, 0, 8, 9, 21,
-	96, 92, 0, 91, 0, 94, 93, 95, 0, 0,
-	0, 23, 0, 0, 0, 24, 0, 0, 209, 0,
-	0, 0, 10, 0, 8, 9, 21, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 13, 23, 0,
-	0, 0, 24, 0, 0, 0, 14, 15, 12, 0,
-	0, 0, 16, 17, 20, 0, 0, 0, 0, 22,
-	0, 19, 18, 0, 13, 0, 0, 10, 0, 8,
-	9, 21, 0, 14, 15, 12, 0, 0, 0, 16,
-	17, 20, 0, 23, 0, 0, 22, 24, 19, 18,
-	10, 0, 8, 9, 21, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 23, 0, 0, 13,
-	24, 0, 0, 209, 0, 0, 0, 0, 14, 15,
-	12, 0, 0, 0, 16, 17, 20, 0, 0, 0,
-	0, 107, 0, 19, 18, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 16, 17, 20,
-	0, 0, 0, 0, 22, 52, 19, 18, 39, 57,
-	0, 0, 0, 222, 46, 38, 0, 113, 45, 0,
-	0, 0, 56, 41, 0, 42, 220, 0, 0, 55,
-	0, 40, 43, 53, 50, 0, 36, 54, 51, 44,
-	351, 47, 58, 0, 52, 0, 0, 39, 57, 0,
-	0, 0, 0, 46, 38, 0, 113, 45, 0, 0,
-	0, 56, 41, 0, 42, 0, 0, 0, 55, 0,
-	40, 43, 53, 50, 0, 36, 54, 51, 44, 52,
-	47, 58, 39, 57, 0, 0, 0, 0, 46, 38,
-	0, 113, 45, 0, 0, 0, 56, 41, 0, 42,
-	0, 0, 0, 55, 0, 40, 43, 53, 50, 0,
-	36, 54, 51, 44, 52, 47, 58, 39, 57, 0,
-	0, 0, 0, 46, 0, 0, 113, 45, 0, 0,
-	0, 56, 41, 0, 42, 0, 0, 0, 55, 0,
-	40, 43, 53, 0, 0, 0, 54, 0, 44, 0,
-	47, 58,
+	80, 266, 79, 78, 77, 76, 75, 73, 74, 69,
+	70, 71, 72, 67, 68, 62, 63, 64, 65, 66,
+	0, 0, 0, 0, 0, 97, 93, 0, 92, 0,
+	95, 94, 96, 0, 0, 0, 0, 0, 0, 0,
+	0, 25, 77, 76, 75, 73, 74, 69, 70, 71,
+	72, 67, 68, 62, 63, 64, 65, 66, 0, 0,
+	0, 0, 0, 97, 93, 0, 92, 0, 95, 94,
+	96, 76, 75, 73, 74, 69, 70, 71, 72, 67,
+	68, 62, 63, 64, 65, 66, 0, 0, 0, 0,
+	0, 97, 93, 0, 92, 0, 95, 94, 96, 73,
+	74, 69, 70, 71, 72, 67, 68, 62, 63, 64,
+	65, 66, 10, 0, 8, 9, 21, 97, 93, 0,
+	92, 0, 95, 94, 96, 0, 0, 0, 23, 0,
+	0, 0, 24, 0, 0, 212, 0, 0, 74, 69,
+	70, 71, 72, 67, 68, 62, 63, 64, 65, 66,
+	0, 0, 0, 0, 13, 97, 93, 0, 92, 0,
+	95, 94, 96, 14, 15, 12, 0, 0, 0, 16,
+	17, 20, 0, 284, 285, 0, 22, 0, 19, 18,
+	69, 70, 71, 72, 67, 68, 62, 63, 64, 65,
+	66, 10, 0, 8, 9, 21, 97, 93, 0, 92,
+	0, 95, 94, 96, 0, 0, 0, 23, 0, 0,
+	0, 24, 0, 0, 212, 0, 0, 0, 10, 0,
+	8, 9, 21, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 13, 23, 0, 0, 0, 24, 0,
+	0, 0, 14, 15, 12, 0, 0, 0, 16, 17,
+	20, 0, 0, 0, 0, 22, 0, 19, 18, 0,
+	13, 0, 0, 10, 0, 8, 9, 21, 0, 14,
+	15, 12, 0, 0, 0, 16, 17, 20, 0, 23,
+	0, 0, 22, 24, 19, 18, 0, 0, 0, 67,
+	68, 62, 63, 64, 65, 66, 0, 0, 0, 0,
+	0, 97, 93, 0, 92, 13, 95, 94, 96, 0,
+	0, 0, 0, 0, 14, 15, 12, 0, 0, 0,
+	16, 17, 20, 0, 0, 0, 0, 108, 0, 19,
+	18, 10, 0, 8, 9, 21, 0, 0, 0, 357,
+	0, 0, 358, 0, 0, 0, 53, 23, 0, 40,
+	58, 24, 0, 0, 212, 47, 39, 0, 114, 46,
+	0, 0, 0, 57, 42, 0, 43, 0, 0, 0,
+	56, 0, 41, 44, 54, 51, 0, 37, 55, 52,
+	45, 0, 48, 59, 0, 0, 0, 0, 16, 17,
+	20, 0, 0, 0, 0, 22, 53, 19, 18, 40,
+	58, 0, 0, 0, 225, 47, 39, 0, 114, 46,
+	0, 0, 0, 57, 42, 0, 43, 223, 0, 0,
+	56, 0, 41, 44, 54, 51, 0, 37, 55, 52,
+	45, 53, 48, 59, 40, 58, 0, 0, 0, 0,
+	47, 39, 0, 114, 46, 0, 0, 0, 57, 42,
+	0, 43, 0, 0, 0, 56, 0, 41, 44, 54,
+	51, 0, 37, 55, 52, 45, 0, 48, 59,
 }
-var yyPact = [...]int{
 
-	13, -1000, -1000, 1144, 896, -2, 186, 613, -1000, -1000,
-	-1000, 210, 1144, 1144, 1144, 1144, 1144, 1144, 1144, 1144,
-	1189, 108, 701, 107, -1000, -1000, -1000, 106, -1000, -1000,
-	208, 91, 1370, 283, 1405, -1000, -1000, 241, 241, -1000,
+var yyPact = [...]int16{
+	29, -1000, -1000, 1110, 862, -2, 226, 479, -1000, -1000,
+	-1000, 258, 1110, 1110, 1110, 1110, 1110, 1110, 1110, 1110,
+	1155, 167, 656, 164, -1000, -1000, -1000, 160, -40, -1000,
+	-1000, 254, 161, 1342, 344, 287, -1000, -1000, 295, 295,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	1144, 1144, 1144, 1144, 1144, 1144, 1144, 1144, 1144, 1144,
-	1144, 1144, 1144, 1144, 1144, 1144, 1144, 1144, 1144, 1144,
-	1144, 1144, 1144, 1144, 1144, 1144, 1144, 1144, 1144, 1144,
-	1144, 1144, 1144, -1000, -1000, 241, 241, -1000, 34, 34,
-	34, 34, 34, 34, 34, 34, 34, 701, 1370, 88,
-	84, 87, -1000, -1000, 1144, 248, 202, -43, 79, 188,
-	-1000, 256, 91, -1000, -1000, -1000, 283, 1405, -1000, -1000,
-	283, -1000, 1405, -1000, -1000, -1000, -1000, 201, -1000, 200,
-	613, 178, 178, 34, 34, 34, 23, 23, 67, 67,
-	67, 67, 1020, 1061, 982, 413, 956, 928, 683, 155,
-	613, 613, 613, 613, 613, 613, 613, 613, 613, 613,
-	613, 83, 186, 118, -1000, -1000, 82, 185, 1117, -1000,
-	120, 256, 104, 87, 569, 80, -1000, -1000, 1296, 1144,
-	1117, 1370, 91, 91, 256, -1000, 62, -1000, -1000, -1000,
-	1370, 234, 1144, -1000, -1000, 1212, 1144, 34, -1000, -4,
-	1144, 87, 1296, 8, 1370, -1000, 785, 72, 183, -1000,
-	-1000, 97, -1000, 115, 613, -1000, 613, -1000, 193, -1000,
-	91, -1000, 79, 68, -1000, -1000, 855, -1000, 91, 179,
-	-1000, 174, 890, 524, -1000, 1038, 111, 120, 71, -1000,
-	60, -1000, -1000, 1296, 120, 68, 256, 97, -1000, -1000,
-	-1000, -44, -1000, -1000, -46, 177, -1000, 68, 151, -1000,
-	-6, 234, -1000, -1000, 1144, -1000, 9, -1000, 144, -1000,
-	241, 1144, -1000, -1000, -1000, -1000, 97, -1000, -1000, -1000,
-	91, 1144, -1000, -1000, 613, -1000, -41, 1117, -1000, -1000,
-	-1000, 480, 820, -1000, 613, -1000, -1000, -1000, -1000, -1000,
-	-1000, 431, -1000, -1000, -1000, 103, 98, -1000, -47, -1000,
-	-48, -49, -1000, 95, 241, 94, 1144, 93, 92, 1144,
-	150, 149, 1144, 1144, -1000, 1335, -1000, -1000, 215, 1144,
-	-50, 1144, -52, -1000, 1144, 1144, 338, -1000, -1000, 49,
-	47, -1000, 90, -53, -1000, 43, -1000, 41, 40, -1000,
-	-55, -56, 1144, 1144, -1000, -1000, -1000, -1000, -1000, 32,
-	-58, 180, -1000, -1000, -62, 1144, -1000, -1000, 21, -1000,
-	-1000, -1000,
+	-1000, 1110, 1110, 1110, 1110, 1110, 1110, 1110, 1110, 1110,
+	1110, 1110, 1110, 1110, 1110, 1110, 1110, 1110, 1110, 1110,
+	1110, 1110, 1110, 1110, 1110, 1110, 1110, 1110, 1110, 1110,
+	1110, 1110, 1110, 1110, -1000, -1000, 295, 295, -1000, 16,
+	16, 16, 16, 16, 16, 16, 16, 16, 656, 1342,
+	113, 112, 131, -1000, -1000, 1110, 291, -1000, -1000, 241,
+	25, 146, 227, -1000, 305, 161, -1000, -1000, -1000, 344,
+	287, -1000, -1000, 344, -1000, 287, -1000, -1000, -1000, -1000,
+	235, -1000, 234, 479, -42, -42, 16, 16, 16, 266,
+	266, 1132, 1132, 1132, 1132, 986, 1027, 948, 554, 922,
+	894, 638, 207, 479, 479, 479, 479, 479, 479, 479,
+	479, 479, 479, 479, 111, 226, 172, -1000, -1000, 109,
+	225, 1083, -1000, 174, 305, 154, 131, 435, 107, -1000,
+	-1000, 1307, 1110, 1083, 1342, 161, 161, 305, -1000, 41,
+	-1000, -1000, -1000, 1342, 282, 1110, -1000, -1000, 1223, 1110,
+	16, -1000, -39, 1110, 131, 1307, 45, 1342, -1000, 743,
+	105, 224, -1000, -1000, 163, -1000, 171, 479, -1000, 479,
+	-1000, 230, -1000, 161, -1000, 146, 98, -1000, -1000, 820,
+	-1000, 161, 223, -1000, 220, 856, 390, -1000, 1004, 170,
+	174, 99, -1000, 94, -1000, -1000, 1307, 174, 98, 305,
+	163, -1000, -1000, -1000, -1000, 24, -1000, -1000, 21, 222,
+	-1000, 98, 205, -1000, -43, 282, -1000, -1000, 1110, -1000,
+	-3, -1000, 201, -1000, 295, 1110, -1000, -1000, -1000, -1000,
+	163, 785, -1000, -1000, 161, 1110, -1000, -1000, 479, -1000,
+	-56, 1083, -1000, -1000, -1000, 346, -1000, -1000, -45, -1000,
+	572, -1000, 479, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, 148, 147, -1000, 20, -1000, 19, 15, -1000, 145,
+	295, 142, 1110, 141, 132, 1110, 203, 202, 1110, 1110,
+	-1000, 1257, -1000, -1000, 264, 1110, 14, 1110, 13, -1000,
+	1110, 1110, 90, -1000, -1000, 88, 85, 7, -1000, 127,
+	4, -1000, 84, -1000, 49, 48, -1000, 3, 2, 1110,
+	1110, -1000, -1000, -1000, -1000, -1000, 36, -1, 243, -1000,
+	-1000, -5, 1110, -1000, -1000, 35, -1000, -1000, -1000,
 }
-var yyPgo = [...]int{
-
-	0, 10, 346, 25, 345, 13, 36, 343, 342, 35,
-	34, 341, 340, 28, 337, 336, 24, 9, 335, 334,
-	1, 32, 27, 4, 333, 332, 203, 330, 33, 327,
-	26, 8, 326, 44, 322, 321, 319, 11, 317, 312,
-	6, 0, 5, 308, 29, 60, 108, 37, 3, 275,
-	47, 40, 307, 38, 303, 23, 301, 2, 298, 30,
-	12, 277, 291, 289, 288, 287, 282,
+
+var yyPgo = [...]int16{
+	0, 10, 439, 27, 434, 13, 33, 433, 432, 31,
+	36, 431, 430, 29, 411, 397, 24, 9, 396, 395,
+	1, 37, 28, 4, 394, 393, 214, 392, 35, 391,
+	26, 8, 389, 34, 378, 371, 369, 12, 366, 364,
+	6, 0, 5, 363, 11, 82, 96, 38, 3, 343,
+	59, 46, 359, 44, 354, 23, 342, 2, 341, 30,
+	25, 352, 340, 338, 336, 327, 326,
 }
-var yyR1 = [...]int{
 
-	0, 62, 62, 10, 10, 10, 22, 20, 20, 20,
+var yyR1 = [...]int8{
+	0, 62, 62, 10, 10, 10, 10, 10, 22, 20,
 	20, 20, 20, 20, 20, 20, 20, 20, 20, 20,
 	20, 20, 20, 20, 20, 20, 20, 20, 20, 20,
 	20, 20, 20, 20, 20, 20, 20, 20, 20, 20,
 	20, 20, 20, 20, 20, 20, 20, 20, 20, 20,
 	20, 20, 20, 20, 20, 20, 20, 20, 20, 20,
-	42, 42, 42, 63, 40, 35, 35, 35, 41, 39,
-	39, 39, 39, 39, 39, 39, 39, 39, 39, 39,
-	39, 39, 39, 39, 39, 1, 1, 1, 2, 2,
-	2, 16, 16, 16, 16, 16, 3, 3, 3, 3,
-	28, 28, 43, 43, 43, 43, 43, 43, 44, 44,
-	45, 45, 45, 45, 45, 45, 45, 45, 45, 46,
-	46, 47, 47, 61, 57, 57, 57, 57, 57, 60,
-	59, 6, 12, 11, 11, 11, 64, 4, 48, 48,
-	58, 58, 17, 17, 13, 61, 61, 37, 20, 20,
-	61, 61, 5, 24, 31, 31, 33, 33, 33, 34,
-	34, 32, 32, 37, 66, 66, 65, 65, 38, 38,
-	49, 49, 23, 23, 21, 21, 26, 26, 27, 27,
-	7, 7, 36, 36, 8, 8, 9, 9, 29, 29,
-	30, 30, 54, 54, 55, 55, 50, 50, 51, 51,
-	52, 52, 53, 53, 18, 18, 19, 19, 14, 14,
-	25, 25, 15, 15, 56, 56,
+	20, 20, 42, 42, 42, 42, 40, 40, 63, 35,
+	35, 35, 41, 39, 39, 39, 39, 39, 39, 39,
+	39, 39, 39, 39, 39, 39, 39, 39, 39, 1,
+	1, 1, 2, 2, 2, 16, 16, 16, 16, 16,
+	3, 3, 3, 3, 28, 28, 43, 43, 43, 43,
+	43, 43, 44, 44, 45, 45, 45, 45, 45, 45,
+	45, 45, 45, 46, 46, 47, 47, 61, 57, 57,
+	57, 57, 57, 60, 59, 6, 12, 11, 11, 11,
+	64, 4, 48, 48, 58, 58, 17, 17, 13, 61,
+	61, 37, 20, 20, 61, 61, 5, 24, 31, 31,
+	33, 33, 33, 34, 34, 32, 32, 37, 66, 66,
+	65, 65, 38, 38, 49, 49, 23, 23, 21, 21,
+	26, 26, 27, 27, 7, 7, 36, 36, 8, 8,
+	9, 9, 29, 29, 30, 30, 54, 54, 55, 55,
+	50, 50, 51, 51, 52, 52, 53, 53, 18, 18,
+	19, 19, 14, 14, 25, 25, 15, 15, 56, 56,
 }
-var yyR2 = [...]int{
 
-	0, 3, 3, 0, 2, 5, 1, 1, 1, 1,
-	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 3, 3, 3, 3, 3, 3, 5,
+var yyR2 = [...]int8{
+	0, 3, 3, 0, 2, 5, 3, 3, 1, 1,
+	1, 1, 1, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 2, 2, 2, 2, 2, 2, 2, 2, 2,
-	4, 6, 4, 4, 3, 4, 4, 2, 2, 6,
-	0, 2, 2, 0, 4, 3, 2, 2, 2, 1,
-	5, 5, 1, 2, 3, 2, 2, 7, 9, 3,
-	5, 7, 3, 5, 5, 0, 3, 1, 4, 4,
-	3, 1, 3, 3, 4, 4, 1, 2, 2, 1,
-	1, 3, 1, 1, 1, 1, 1, 1, 1, 1,
+	3, 5, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 2, 2, 2, 2, 2, 2, 2,
+	2, 2, 4, 6, 4, 4, 3, 4, 4, 2,
+	2, 6, 0, 2, 2, 3, 3, 4, 1, 3,
+	2, 2, 2, 1, 5, 5, 1, 2, 3, 2,
+	2, 7, 9, 3, 5, 7, 3, 5, 5, 0,
+	3, 1, 4, 4, 3, 1, 3, 3, 4, 4,
+	1, 2, 2, 1, 1, 3, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
-	1, 1, 1, 1, 1, 3, 3, 2, 2, 1,
-	2, 3, 3, 1, 1, 5, 0, 5, 1, 1,
-	1, 1, 1, 3, 3, 2, 5, 2, 3, 3,
-	2, 6, 2, 2, 1, 1, 2, 4, 5, 0,
-	3, 1, 3, 3, 0, 1, 0, 1, 1, 2,
-	0, 1, 0, 1, 0, 1, 1, 3, 0, 1,
-	0, 2, 0, 2, 1, 3, 0, 1, 1, 3,
-	0, 1, 1, 2, 0, 1, 1, 2, 0, 1,
-	1, 2, 0, 1, 1, 3, 0, 1, 1, 2,
-	0, 1, 1, 3, 1, 2,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 3,
+	3, 2, 2, 1, 2, 3, 3, 1, 1, 5,
+	0, 5, 1, 1, 1, 1, 1, 3, 3, 2,
+	5, 2, 3, 3, 2, 6, 2, 2, 1, 1,
+	2, 4, 5, 0, 3, 1, 3, 3, 0, 1,
+	0, 1, 1, 2, 0, 1, 0, 1, 0, 1,
+	1, 3, 0, 1, 0, 2, 0, 2, 1, 3,
+	0, 1, 1, 3, 0, 1, 1, 2, 0, 1,
+	1, 2, 0, 1, 1, 2, 0, 1, 1, 3,
+	0, 1, 1, 2, 0, 1, 1, 3, 1, 2,
 }
-var yyChk = [...]int{
 
+var yyChk = [...]int16{
 	-1000, -62, 98, 97, -10, -22, -26, -20, 30, 31,
 	28, -56, 81, 70, 79, 80, 85, 86, 95, 94,
-	87, 32, 92, 44, 48, 99, -11, 6, -12, -4,
-	21, -57, -50, -61, -45, -46, 40, -58, 19, 12,
-	35, 27, 29, 36, 43, 22, 18, 45, -43, -44,
-	38, 42, 9, 37, 41, 33, 26, 13, 46, 99,
-	52, 79, 80, 81, 82, 83, 77, 78, 73, 74,
-	75, 76, 71, 72, 70, 69, 68, 67, 66, 64,
-	53, 54, 55, 56, 57, 58, 59, 60, 61, 62,
-	63, 92, 90, 95, 94, 96, 89, 48, -20, -20,
-	-20, -20, -20, -20, -20, -20, -20, 92, 92, -59,
-	-22, -60, -57, 21, 92, 92, 48, -30, -16, -29,
-	-48, 81, 92, -28, 30, 40, -61, -45, -46, -51,
-	-50, -53, -52, -47, -46, -45, -48, -49, -48, -49,
-	-20, -20, -20, -20, -20, -20, -20, -20, -20, -20,
-	-20, -20, -20, -20, -20, -20, -20, -20, -20, -22,
+	87, 32, 92, 44, 48, 99, -11, 6, 2, -12,
+	-4, 21, -57, -50, -61, -45, -46, 40, -58, 19,
+	12, 35, 27, 29, 36, 43, 22, 18, 45, -43,
+	-44, 38, 42, 9, 37, 41, 33, 26, 13, 46,
+	99, 52, 79, 80, 81, 82, 83, 77, 78, 73,
+	74, 75, 76, 71, 72, 70, 69, 68, 67, 66,
+	64, 53, 54, 55, 56, 57, 58, 59, 60, 61,
+	62, 63, 92, 90, 95, 94, 96, 89, 48, -20,
+	-20, -20, -20, -20, -20, -20, -20, -20, 92, 92,
+	-59, -22, -60, -57, 21, 92, 92, 100, 101, 48,
+	-30, -16, -29, -48, 81, 92, -28, 30, 40, -61,
+	-45, -46, -51, -50, -53, -52, -47, -46, -45, -48,
+	-49, -48, -49, -20, -20, -20, -20, -20, -20, -20,
 	-20, -20, -20, -20, -20, -20, -20, -20, -20, -20,
-	-20, -27, -26, -22, -48, -48, -59, -59, 93, 93,
-	-1, 81, -2, 92, -20, 30, 51, 101, 92, 90,
-	53, -7, 52, -55, -54, -44, -16, -51, -53, -47,
-	51, 51, 65, 93, 91, 93, 52, -20, -33, 51,
-	90, -55, 92, -1, 52, 93, -10, -9, -8, -3,
-	30, -60, 17, -21, -20, -31, -20, -33, -64, -6,
-	-57, -28, -16, -16, -44, 93, -14, -13, -60, -15,
-	-5, 30, -20, -20, 100, -34, -21, -1, -9, 93,
-	-59, 100, 93, 52, -1, -16, 81, 92, 91, -40,
-	51, -30, 100, -13, -19, -18, -17, -16, -49, -48,
-	-65, 52, -25, -24, 53, 93, -32, -31, -38, -37,
-	89, 90, 91, 93, 93, -3, -55, -63, 101, 101,
-	52, 65, 100, -5, -20, 100, 52, -66, -37, 53,
-	-48, -20, -42, -17, -20, 100, -31, 91, -6, -41,
-	100, -36, -39, -35, 101, 8, 7, -40, -22, 4,
-	10, 14, 16, 23, 24, 25, 34, 39, 47, 11,
-	15, 30, 92, 92, 101, -42, 101, 101, -41, 92,
-	-48, 92, -23, -22, 92, 92, -20, 65, 65, -22,
-	-22, 5, 47, -23, 101, -22, 101, -22, -22, 65,
-	93, 93, 92, 101, 93, 93, 93, 101, 101, -22,
-	-23, -41, -41, -41, 93, 101, 50, 101, -23, -41,
-	93, -41,
+	-20, -20, -22, -20, -20, -20, -20, -20, -20, -20,
+	-20, -20, -20, -20, -27, -26, -22, -48, -48, -59,
+	-59, 93, 93, -1, 81, -2, 92, -20, 30, 51,
+	100, 92, 90, 53, -7, 52, -55, -54, -44, -16,
+	-51, -53, -47, 51, 51, 65, 93, 91, 93, 52,
+	-20, -33, 51, 90, -55, 92, -1, 52, 93, -10,
+	-9, -8, -3, 30, -60, 17, -21, -20, -31, -20,
+	-33, -64, -6, -57, -28, -16, -16, -44, 93, -14,
+	-13, -60, -15, -5, 30, -20, -20, 101, -34, -21,
+	-1, -9, 93, -59, 101, 93, 52, -1, -16, 81,
+	92, 91, -40, -63, 51, -30, 101, -13, -19, -18,
+	-17, -16, -49, -48, -65, 52, -25, -24, 53, 93,
+	-32, -31, -38, -37, 89, 90, 91, 93, 93, -3,
+	-55, -42, 100, 100, 52, 65, 101, -5, -20, 101,
+	52, -66, -37, 53, -48, -20, -6, -41, 2, 101,
+	-36, -17, -20, 101, -31, 91, 100, 101, -39, -35,
+	100, 8, 7, -40, -22, 4, 10, 14, 16, 23,
+	24, 25, 34, 39, 47, 11, 15, 30, 92, 92,
+	100, -42, 100, 100, -41, 92, -48, 92, -23, -22,
+	92, 92, -20, 65, 65, -22, -22, 2, 5, 47,
+	-23, 100, -22, 100, -22, -22, 65, 93, 93, 92,
+	100, 93, 93, 93, 100, 100, -22, -23, -41, -41,
+	-41, 93, 100, 50, 100, -23, -41, 93, -41,
 }
-var yyDef = [...]int{
-
-	0, -2, 3, 0, 0, 0, 6, 176, 7, 8,
-	9, 10, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 214, 1, 4, 0, 133, 134,
-	104, 190, 124, 198, 202, 196, 123, 170, 170, 110,
-	111, 112, 113, 114, 115, 116, 117, 118, 119, 120,
-	140, 141, 102, 103, 105, 106, 107, 108, 109, 2,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+
+var yyDef = [...]int16{
+	0, -2, 3, 0, 0, 0, 8, 180, 9, 10,
+	11, 12, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 218, 1, 4, 0, 0, 137,
+	138, 108, 194, 128, 202, 206, 200, 127, 174, 174,
+	114, 115, 116, 117, 118, 119, 120, 121, 122, 123,
+	124, 144, 145, 106, 107, 109, 110, 111, 112, 113,
+	2, 0, 0, 0, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 178, 0, 57, 58, 0, 0, 215, 41, 42,
-	43, 44, 45, 46, 47, 48, 49, 0, 0, 0,
-	0, 85, 129, 104, 0, 0, 0, 0, -2, 191,
-	91, 194, 0, 188, 138, 139, 198, 202, 197, 127,
-	199, 128, 203, 200, 121, 122, -2, 0, -2, 0,
-	177, 11, 12, 13, 14, 15, 16, 17, 18, 19,
-	20, 21, 22, 23, 24, 25, 26, 27, 28, 0,
-	30, 31, 32, 33, 34, 35, 36, 37, 38, 39,
-	40, 0, 179, 0, 148, 149, 0, 0, 0, 54,
-	130, 194, 87, 85, 0, 0, 3, 132, 186, 174,
-	0, 136, 0, 0, 195, 192, 0, 125, 126, 201,
-	0, 0, 0, 55, 56, 50, 0, 52, 53, 159,
-	174, 85, 186, 0, 0, 5, 0, 0, 187, 184,
-	96, 85, 99, 0, 175, 101, 154, 155, 0, 181,
-	190, 189, 100, 92, 193, 93, 0, 208, -2, 166,
-	212, 210, 29, 0, 156, 0, 0, 86, 0, 90,
-	0, 135, 94, 0, 97, 98, 194, 85, 95, 137,
-	63, 0, 146, 209, 0, 207, 204, 142, 0, -2,
-	0, 167, 152, 211, 0, 51, 0, 161, 164, 168,
-	0, 0, 89, 88, 59, 185, 85, 60, 131, 144,
-	170, 0, 151, 213, 153, 157, 160, 0, 169, 165,
-	147, 0, 182, 205, 143, 158, 162, 163, 61, 62,
-	64, 0, 68, 183, 69, 0, 0, 72, 0, 60,
-	0, 0, 182, 0, 0, 0, 172, 0, 0, 0,
-	0, 7, 0, 0, 73, 182, 75, 76, 0, 172,
-	0, 0, 0, 173, 0, 0, 0, 66, 67, 0,
-	0, 74, 0, 0, 79, 0, 82, 0, 0, 65,
-	0, 0, 0, 172, 182, 182, 182, 70, 71, 0,
-	0, 80, 83, 84, 0, 172, 182, 77, 0, 81,
-	182, 78,
+	0, 0, 182, 0, 59, 60, 0, 0, 219, 43,
+	44, 45, 46, 47, 48, 49, 50, 51, 0, 0,
+	0, 0, 89, 133, 108, 0, 0, 6, 7, 0,
+	0, -2, 195, 95, 198, 0, 192, 142, 143, 202,
+	206, 201, 131, 203, 132, 207, 204, 125, 126, -2,
+	0, -2, 0, 181, 13, 14, 15, 16, 17, 18,
+	19, 20, 21, 22, 23, 24, 25, 26, 27, 28,
+	29, 30, 0, 32, 33, 34, 35, 36, 37, 38,
+	39, 40, 41, 42, 0, 183, 0, 152, 153, 0,
+	0, 0, 56, 134, 198, 91, 89, 0, 0, 3,
+	136, 190, 178, 0, 140, 0, 0, 199, 196, 0,
+	129, 130, 205, 0, 0, 0, 57, 58, 52, 0,
+	54, 55, 163, 178, 89, 190, 0, 0, 5, 0,
+	0, 191, 188, 100, 89, 103, 0, 179, 105, 158,
+	159, 0, 185, 194, 193, 104, 96, 197, 97, 0,
+	212, -2, 170, 216, 214, 31, 0, 160, 0, 0,
+	90, 0, 94, 0, 139, 98, 0, 101, 102, 198,
+	89, 99, 141, 62, 68, 0, 150, 213, 0, 211,
+	208, 146, 0, -2, 0, 171, 156, 215, 0, 53,
+	0, 165, 168, 172, 0, 0, 93, 92, 61, 189,
+	89, -2, 135, 148, 174, 0, 155, 217, 157, 161,
+	164, 0, 173, 169, 151, 0, 63, 64, 0, 66,
+	0, 209, 147, 162, 166, 167, 65, 67, 72, 187,
+	73, 0, 0, 76, 0, 62, 0, 0, 186, 0,
+	0, 0, 176, 0, 0, 0, 0, 9, 0, 0,
+	77, -2, 79, 80, 0, 176, 0, 0, 0, 177,
+	0, 0, 0, 70, 71, 0, 0, 0, 78, 0,
+	0, 83, 0, 86, 0, 0, 69, 0, 0, 0,
+	176, 186, 186, 186, 74, 75, 0, 0, 84, 87,
+	88, 0, 176, 186, 81, 0, 85, 186, 82,
 }
-var yyTok1 = [...]int{
 
+var yyTok1 = [...]int8{
 	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 85, 3, 3, 3, 83, 70, 3,
 	92, 93, 81, 79, 52, 80, 89, 82, 3, 3,
-	3, 3, 3, 3, 3, 3, 3, 3, 65, 101,
+	3, 3, 3, 3, 3, 3, 3, 3, 65, 100,
 	73, 53, 74, 64, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 90, 3, 91, 69, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 51, 68, 100, 86,
+	3, 3, 3, 51, 68, 101, 86,
 }
-var yyTok2 = [...]int{
 
+var yyTok2 = [...]int8{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
 	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
@@ -619,7 +687,8 @@ var yyTok2 = [...]int{
 	67, 71, 72, 75, 76, 77, 78, 84, 87, 88,
 	94, 95, 96, 97, 98, 99,
 }
-var yyTok3 = [...]int{
+
+var yyTok3 = [...]int8{
 	0,
 }
 
@@ -701,9 +770,9 @@ func yyErrorMessage(state, lookAhead int) string {
 	expected := make([]int, 0, 4)
 
 	// Look for shiftable tokens.
-	base := yyPact[state]
+	base := int(yyPact[state])
 	for tok := TOKSTART; tok-1 < len(yyToknames); tok++ {
-		if n := base + tok; n >= 0 && n < yyLast && yyChk[yyAct[n]] == tok {
+		if n := base + tok; n >= 0 && n < yyLast && int(yyChk[int(yyAct[n])]) == tok {
 			if len(expected) == cap(expected) {
 				return res
 			}
@@ -713,13 +782,13 @@ func yyErrorMessage(state, lookAhead int) string {
 
 	if yyDef[state] == -2 {
 		i := 0
-		for yyExca[i] != -1 || yyExca[i+1] != state {
+		for yyExca[i] != -1 || int(yyExca[i+1]) != state {
 			i += 2
 		}
 
 		// Look for tokens that we accept or reduce.
 		for i += 2; yyExca[i] >= 0; i += 2 {
-			tok := yyExca[i]
+			tok := int(yyExca[i])
 			if tok < TOKSTART || yyExca[i+1] == 0 {
 				continue
 			}
@@ -750,30 +819,30 @@ func yylex1(lex yyLexer, lval *yySymType) (char, token int) {
 	token = 0
 	char = lex.Lex(lval)
 	if char <= 0 {
-		token = yyTok1[0]
+		token = int(yyTok1[0])
 		goto out
 	}
 	if char < len(yyTok1) {
-		token = yyTok1[char]
+		token = int(yyTok1[char])
 		goto out
 	}
 	if char >= yyPrivate {
 		if char < yyPrivate+len(yyTok2) {
-			token = yyTok2[char-yyPrivate]
+			token = int(yyTok2[char-yyPrivate])
 			goto out
 		}
 	}
 	for i := 0; i < len(yyTok3); i += 2 {
-		token = yyTok3[i+0]
+		token = int(yyTok3[i+0])
 		if token == char {
-			token = yyTok3[i+1]
+			token = int(yyTok3[i+1])
 			goto out
 		}
 	}
 
 out:
 	if token == 0 {
-		token = yyTok2[1] /* unknown char */
+		token = int(yyTok2[1]) /* unknown char */
 	}
 	if yyDebug >= 3 {
 		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(token), uint(char))
@@ -828,7 +897,7 @@ yystack:
 	yyS[yyp].yys = yystate
 
 yynewstate:
-	yyn = yyPact[yystate]
+	yyn = int(yyPact[yystate])
 	if yyn <= yyFlag {
 		goto yydefault /* simple state */
 	}
@@ -839,8 +908,8 @@ yynewstate:
 	if yyn < 0 || yyn >= yyLast {
 		goto yydefault
 	}
-	yyn = yyAct[yyn]
-	if yyChk[yyn] == yytoken { /* valid shift */
+	yyn = int(yyAct[yyn])
+	if int(yyChk[yyn]) == yytoken { /* valid shift */
 		yyrcvr.char = -1
 		yytoken = -1
 		yyVAL = yyrcvr.lval
@@ -853,7 +922,7 @@ yynewstate:
 
 yydefault:
 	/* default state action */
-	yyn = yyDef[yystate]
+	yyn = int(yyDef[yystate])
 	if yyn == -2 {
 		if yyrcvr.char < 0 {
 			yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
@@ -862,18 +931,18 @@ yydefault:
 		/* look through exception table */
 		xi := 0
 		for {
-			if yyExca[xi+0] == -1 && yyExca[xi+1] == yystate {
+			if yyExca[xi+0] == -1 && int(yyExca[xi+1]) == yystate {
 				break
 			}
 			xi += 2
 		}
 		for xi += 2; ; xi += 2 {
-			yyn = yyExca[xi+0]
+			yyn = int(yyExca[xi+0])
 			if yyn < 0 || yyn == yytoken {
 				break
 			}
 		}
-		yyn = yyExca[xi+1]
+		yyn = int(yyExca[xi+1])
 		if yyn < 0 {
 			goto ret0
 		}
@@ -895,10 +964,10 @@ yydefault:
 
 			/* find a state where "error" is a legal shift action */
 			for yyp >= 0 {
-				yyn = yyPact[yyS[yyp].yys] + yyErrCode
+				yyn = int(yyPact[yyS[yyp].yys]) + yyErrCode
 				if yyn >= 0 && yyn < yyLast {
-					yystate = yyAct[yyn] /* simulate a shift of "error" */
-					if yyChk[yystate] == yyErrCode {
+					yystate = int(yyAct[yyn]) /* simulate a shift of "error" */
+					if int(yyChk[yystate]) == yyErrCode {
 						goto yystack
 					}
 				}
@@ -934,7 +1003,7 @@ yydefault:
 	yypt := yyp
 	_ = yypt // guard against "declared and not used"
 
-	yyp -= yyR2[yyn]
+	yyp -= int(yyR2[yyn])
 	// yyp is now the index of $0. Perform the default action. Iff the
 	// reduced production is ε, $1 is possibly out of range.
 	if yyp+1 >= len(yyS) {
@@ -945,16 +1014,16 @@ yydefault:
 	yyVAL = yyS[yyp+1]
 
 	/* consult goto table to find next state */
-	yyn = yyR1[yyn]
-	yyg := yyPgo[yyn]
+	yyn = int(yyR1[yyn])
+	yyg := int(yyPgo[yyn])
 	yyj := yyg + yyS[yyp].yys + 1
 
 	if yyj >= yyLast {
-		yystate = yyAct[yyg]
+		yystate = int(yyAct[yyg])
 	} else {
-		yystate = yyAct[yyj]
-		if yyChk[yystate] != -yyn {
-			yystate = yyAct[yyg]
+		yystate = int(yyAct[yyj])
+		if int(yyChk[yystate]) != -yyn {
+			yystate = int(yyAct[yyg])
 		}
 	}
 	// dummy call; replaced with literal code
@@ -962,40 +1031,66 @@ yydefault:
 
 	case 1:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:185
+//line cc.y:193
 		{
-			yylex.(*lexer).prog = &Prog{Decls: yyDollar[2].decls}
+			lx := yylex.(*lexer)
+			lx.prog = &Prog{Decls: append(yyDollar[2].decls, lx.macroDecls()...)}
 			return 0
 		}
 	case 2:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:190
+//line cc.y:199
 		{
 			yylex.(*lexer).expr = yyDollar[2].expr
 			return 0
 		}
 	case 3:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:196
+//line cc.y:205
 		{
 			yyVAL.span = Span{}
 			yyVAL.decls = nil
 		}
 	case 4:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:201
+//line cc.y:210
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
-			yyVAL.decls = append(yyDollar[1].decls, yyDollar[2].decls...)
+			yyVAL.decls = append(yyDollar[1].decls, yylex.(*lexer).macroDecls()...)
+			yyVAL.decls = append(yyVAL.decls, yyDollar[2].decls...)
 		}
 	case 5:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line cc.y:206
+//line cc.y:216
 		{
 		}
 	case 6:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line cc.y:219
+		{
+			// Skip a broken declaration, abandoning any scopes
+			// it opened, and keep parsing.
+			lx := yylex.(*lexer)
+			for lx.scope.Next != nil {
+				lx.popScope()
+			}
+			yyVAL.span = yyDollar[1].span
+			yyVAL.decls = yyDollar[1].decls
+		}
+	case 7:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line cc.y:230
+		{
+			lx := yylex.(*lexer)
+			for lx.scope.Next != nil {
+				lx.popScope()
+			}
+			yyVAL.span = yyDollar[1].span
+			yyVAL.decls = yyDollar[1].decls
+		}
+	case 8:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:211
+//line cc.y:241
 		{
 			yyVAL.span = yyDollar[1].span
 			if len(yyDollar[1].exprs) == 1 {
@@ -1004,387 +1099,387 @@ yydefault:
 			}
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Comma, List: yyDollar[1].exprs}
 		}
-	case 7:
+	case 9:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:222
+//line cc.y:252
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Name, Text: yyDollar[1].str, XDecl: yyDollar[1].decl}
 		}
-	case 8:
+	case 10:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:227
+//line cc.y:257
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Number, Text: yyDollar[1].str}
 		}
-	case 9:
+	case 11:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:232
+//line cc.y:262
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Number, Text: yyDollar[1].str}
 		}
-	case 10:
+	case 12:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:237
+//line cc.y:267
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: String, Texts: yyDollar[1].strs}
 		}
-	case 11:
+	case 13:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:242
+//line cc.y:272
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Add, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 12:
+	case 14:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:247
+//line cc.y:277
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Sub, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 13:
+	case 15:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:252
+//line cc.y:282
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Mul, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 14:
+	case 16:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:257
+//line cc.y:287
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Div, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 15:
+	case 17:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:262
+//line cc.y:292
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Mod, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 16:
+	case 18:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:267
+//line cc.y:297
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Lsh, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 17:
+	case 19:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:272
+//line cc.y:302
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Rsh, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 18:
+	case 20:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:277
+//line cc.y:307
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Lt, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 19:
+	case 21:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:282
+//line cc.y:312
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Gt, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 20:
+	case 22:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:287
+//line cc.y:317
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: LtEq, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 21:
+	case 23:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:292
+//line cc.y:322
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: GtEq, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 22:
+	case 24:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:297
+//line cc.y:327
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: EqEq, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 23:
+	case 25:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:302
+//line cc.y:332
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: NotEq, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 24:
+	case 26:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:307
+//line cc.y:337
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: And, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 25:
+	case 27:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:312
+//line cc.y:342
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Xor, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 26:
+	case 28:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:317
+//line cc.y:347
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Or, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 27:
+	case 29:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:322
+//line cc.y:352
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: AndAnd, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 28:
+	case 30:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:327
+//line cc.y:357
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: OrOr, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 29:
+	case 31:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line cc.y:332
+//line cc.y:362
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[5].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Cond, List: []*Expr{yyDollar[1].expr, yyDollar[3].expr, yyDollar[5].expr}}
 		}
-	case 30:
+	case 32:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:337
+//line cc.y:367
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Eq, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 31:
+	case 33:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:342
+//line cc.y:372
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: AddEq, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 32:
+	case 34:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:347
+//line cc.y:377
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: SubEq, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 33:
+	case 35:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:352
+//line cc.y:382
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: MulEq, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 34:
+	case 36:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:357
+//line cc.y:387
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: DivEq, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 35:
+	case 37:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:362
+//line cc.y:392
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: ModEq, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 36:
+	case 38:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:367
+//line cc.y:397
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: LshEq, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 37:
+	case 39:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:372
+//line cc.y:402
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: RshEq, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 38:
+	case 40:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:377
+//line cc.y:407
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: AndEq, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 39:
+	case 41:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:382
+//line cc.y:412
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: XorEq, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 40:
+	case 42:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:387
+//line cc.y:417
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: OrEq, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 41:
+	case 43:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:392
+//line cc.y:422
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Indir, Left: yyDollar[2].expr}
 		}
-	case 42:
+	case 44:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:397
+//line cc.y:427
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Addr, Left: yyDollar[2].expr}
 		}
-	case 43:
+	case 45:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:402
+//line cc.y:432
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Plus, Left: yyDollar[2].expr}
 		}
-	case 44:
+	case 46:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:407
+//line cc.y:437
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Minus, Left: yyDollar[2].expr}
 		}
-	case 45:
+	case 47:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:412
+//line cc.y:442
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Not, Left: yyDollar[2].expr}
 		}
-	case 46:
+	case 48:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:417
+//line cc.y:447
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Twid, Left: yyDollar[2].expr}
 		}
-	case 47:
+	case 49:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:422
+//line cc.y:452
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: PreInc, Left: yyDollar[2].expr}
 		}
-	case 48:
+	case 50:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:427
+//line cc.y:457
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: PreDec, Left: yyDollar[2].expr}
 		}
-	case 49:
+	case 51:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:432
+//line cc.y:462
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: SizeofExpr, Left: yyDollar[2].expr}
 		}
-	case 50:
+	case 52:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line cc.y:437
+//line cc.y:467
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[4].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: SizeofType, Type: yyDollar[3].typ}
 		}
-	case 51:
+	case 53:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line cc.y:442
+//line cc.y:472
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[6].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Offsetof, Type: yyDollar[3].typ, Left: yyDollar[5].expr}
 		}
-	case 52:
+	case 54:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line cc.y:447
+//line cc.y:477
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[4].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Cast, Type: yyDollar[2].typ, Left: yyDollar[4].expr}
 		}
-	case 53:
+	case 55:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line cc.y:452
+//line cc.y:482
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[4].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: CastInit, Type: yyDollar[2].typ, Init: &Init{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Braced: yyDollar[4].inits}}
 		}
-	case 54:
+	case 56:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:457
+//line cc.y:487
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Paren, Left: yyDollar[2].expr}
 		}
-	case 55:
+	case 57:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line cc.y:462
+//line cc.y:492
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[4].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Call, Left: yyDollar[1].expr, List: yyDollar[3].exprs}
 		}
-	case 56:
+	case 58:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line cc.y:467
+//line cc.y:497
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[4].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Index, Left: yyDollar[1].expr, Right: yyDollar[3].expr}
 		}
-	case 57:
+	case 59:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:472
+//line cc.y:502
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: PostInc, Left: yyDollar[1].expr}
 		}
-	case 58:
+	case 60:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:477
+//line cc.y:507
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: PostDec, Left: yyDollar[1].expr}
 		}
-	case 59:
+	case 61:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line cc.y:482
+//line cc.y:512
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[6].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: VaArg, Left: yyDollar[3].expr, Type: yyDollar[5].typ}
 		}
-	case 60:
+	case 62:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:488
+//line cc.y:518
 		{
 			yyVAL.span = Span{}
 			yyVAL.stmts = nil
 		}
-	case 61:
+	case 63:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:493
+//line cc.y:523
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.stmts = yyDollar[1].stmts
@@ -1392,122 +1487,140 @@ yydefault:
 				yyVAL.stmts = append(yyVAL.stmts, &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: StmtDecl, Decl: d})
 			}
 		}
-	case 62:
+	case 64:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:501
+//line cc.y:531
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.stmts = append(yyDollar[1].stmts, yyDollar[2].stmt)
 		}
-	case 63:
-		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:508
+	case 65:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line cc.y:536
 		{
-			yylex.(*lexer).pushScope()
+			// Skip a broken statement and keep parsing the block.
+			yyVAL.span = yyDollar[1].span
+			yyVAL.stmts = yyDollar[1].stmts
 		}
-	case 64:
+	case 66:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line cc.y:544
+		{
+			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
+			yylex.(*lexer).popScope()
+			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Block, Block: yyDollar[2].stmts}
+		}
+	case 67:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line cc.y:512
+//line cc.y:550
 		{
+			// Skip the rest of a broken block.
 			yyVAL.span = span(yyDollar[1].span, yyDollar[4].span)
 			yylex.(*lexer).popScope()
-			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Block, Block: yyDollar[3].stmts}
+			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Block, Block: yyDollar[2].stmts}
 		}
-	case 65:
+	case 68:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line cc.y:559
+		{
+			yyVAL.span = yyDollar[1].span
+			yylex.(*lexer).pushScope()
+		}
+	case 69:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:520
+//line cc.y:566
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.label = &Label{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Case, Expr: yyDollar[2].expr}
 		}
-	case 66:
+	case 70:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:525
+//line cc.y:571
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.label = &Label{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Default}
 		}
-	case 67:
+	case 71:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:530
+//line cc.y:576
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.label = &Label{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: LabelName, Name: yyDollar[1].str}
 		}
-	case 68:
+	case 72:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:537
+//line cc.y:583
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.stmt = yyDollar[2].stmt
 			yyVAL.stmt.Labels = yyDollar[1].labels
 		}
-	case 69:
+	case 73:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:545
+//line cc.y:591
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Empty}
 		}
-	case 70:
+	case 74:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line cc.y:550
+//line cc.y:596
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Empty}
 		}
-	case 71:
+	case 75:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line cc.y:555
+//line cc.y:601
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Empty}
 		}
-	case 72:
+	case 76:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:560
+//line cc.y:606
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.stmt = yyDollar[1].stmt
 		}
-	case 73:
+	case 77:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:565
+//line cc.y:611
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: StmtExpr, Expr: yyDollar[1].expr}
 		}
-	case 74:
+	case 78:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:570
+//line cc.y:616
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: ARGBEGIN, Block: yyDollar[2].stmts}
 		}
-	case 75:
+	case 79:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:575
+//line cc.y:621
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Break}
 		}
-	case 76:
+	case 80:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:580
+//line cc.y:626
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Continue}
 		}
-	case 77:
+	case 81:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line cc.y:585
+//line cc.y:631
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[7].span)
 			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Do, Body: yyDollar[2].stmt, Expr: yyDollar[5].expr}
 		}
-	case 78:
+	case 82:
 		yyDollar = yyS[yypt-9 : yypt+1]
-		//line cc.y:590
+//line cc.y:636
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[9].span)
 			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span},
@@ -1518,58 +1631,58 @@ yydefault:
 				Body: yyDollar[9].stmt,
 			}
 		}
-	case 79:
+	case 83:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:601
+//line cc.y:647
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Goto, Text: yyDollar[2].str}
 		}
-	case 80:
+	case 84:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line cc.y:606
+//line cc.y:652
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[5].span)
 			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: If, Expr: yyDollar[3].expr, Body: yyDollar[5].stmt}
 		}
-	case 81:
+	case 85:
 		yyDollar = yyS[yypt-7 : yypt+1]
-		//line cc.y:611
+//line cc.y:657
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[7].span)
 			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: If, Expr: yyDollar[3].expr, Body: yyDollar[5].stmt, Else: yyDollar[7].stmt}
 		}
-	case 82:
+	case 86:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:616
+//line cc.y:662
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Return, Expr: yyDollar[2].expr}
 		}
-	case 83:
+	case 87:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line cc.y:621
+//line cc.y:667
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[5].span)
 			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Switch, Expr: yyDollar[3].expr, Body: yyDollar[5].stmt}
 		}
-	case 84:
+	case 88:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line cc.y:626
+//line cc.y:672
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[5].span)
 			yyVAL.stmt = &Stmt{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: While, Expr: yyDollar[3].expr, Body: yyDollar[5].stmt}
 		}
-	case 85:
+	case 89:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:633
+//line cc.y:679
 		{
 			yyVAL.span = Span{}
 			yyVAL.abdecor = func(t *Type) *Type { return t }
 		}
-	case 86:
+	case 90:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:638
+//line cc.y:684
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			_, q, _ := splitTypeWords(yyDollar[2].strs)
@@ -1578,16 +1691,16 @@ yydefault:
 				return abdecor(&Type{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Kind: Ptr, Base: t, Qual: q})
 			}
 		}
-	case 87:
+	case 91:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:647
+//line cc.y:693
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.abdecor = yyDollar[1].abdecor
 		}
-	case 88:
+	case 92:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line cc.y:654
+//line cc.y:700
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[4].span)
 			abdecor := yyDollar[1].abdecor
@@ -1611,9 +1724,9 @@ yydefault:
 				return abdecor(&Type{SyntaxInfo: SyntaxInfo{Span: span}, Kind: Func, Base: t, Decls: decls})
 			}
 		}
-	case 89:
+	case 93:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line cc.y:678
+//line cc.y:724
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[4].span)
 			abdecor := yyDollar[1].abdecor
@@ -1624,24 +1737,24 @@ yydefault:
 			}
 
 		}
-	case 90:
+	case 94:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:689
+//line cc.y:735
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.abdecor = yyDollar[2].abdecor
 		}
-	case 91:
+	case 95:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:697
+//line cc.y:743
 		{
 			yyVAL.span = yyDollar[1].span
 			name := yyDollar[1].str
 			yyVAL.decor = func(t *Type) (*Type, string) { return t, name }
 		}
-	case 92:
+	case 96:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:703
+//line cc.y:749
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			_, q, _ := splitTypeWords(yyDollar[2].strs)
@@ -1651,16 +1764,16 @@ yydefault:
 				return decor(&Type{SyntaxInfo: SyntaxInfo{Span: span}, Kind: Ptr, Base: t, Qual: q})
 			}
 		}
-	case 93:
+	case 97:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:713
+//line cc.y:759
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.decor = yyDollar[2].decor
 		}
-	case 94:
+	case 98:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line cc.y:718
+//line cc.y:764
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[4].span)
 			decor := yyDollar[1].decor
@@ -1670,9 +1783,9 @@ yydefault:
 				return decor(&Type{SyntaxInfo: SyntaxInfo{Span: span}, Kind: Func, Base: t, Decls: decls})
 			}
 		}
-	case 95:
+	case 99:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line cc.y:728
+//line cc.y:774
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[4].span)
 			decor := yyDollar[1].decor
@@ -1682,199 +1795,199 @@ yydefault:
 				return decor(&Type{SyntaxInfo: SyntaxInfo{Span: span}, Kind: Array, Base: t, Width: expr})
 			}
 		}
-	case 96:
+	case 100:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:741
+//line cc.y:787
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.decl = &Decl{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Name: yyDollar[1].str}
 		}
-	case 97:
+	case 101:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:746
+//line cc.y:792
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.decl = &Decl{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Type: yyDollar[2].abdecor(yyDollar[1].typ)}
 		}
-	case 98:
+	case 102:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:751
+//line cc.y:797
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			typ, name := yyDollar[2].decor(yyDollar[1].typ)
 			yyVAL.decl = &Decl{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Name: name, Type: typ}
 		}
-	case 99:
+	case 103:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:757
+//line cc.y:803
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.decl = &Decl{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Name: "..."}
 		}
-	case 100:
+	case 104:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:765
+//line cc.y:811
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.idec = idecor{yyDollar[1].decor, nil}
 		}
-	case 101:
+	case 105:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:770
+//line cc.y:816
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.idec = idecor{yyDollar[1].decor, yyDollar[3].init}
 		}
-	case 102:
+	case 106:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:778
+//line cc.y:824
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 103:
+	case 107:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:783
+//line cc.y:829
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 104:
+	case 108:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:788
+//line cc.y:834
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 105:
+	case 109:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:793
+//line cc.y:839
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 106:
+	case 110:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:798
+//line cc.y:844
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 107:
+	case 111:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:803
+//line cc.y:849
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 108:
+	case 112:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:811
+//line cc.y:857
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 109:
+	case 113:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:816
+//line cc.y:862
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 110:
+	case 114:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:824
+//line cc.y:870
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 111:
+	case 115:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:829
+//line cc.y:875
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 112:
+	case 116:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:834
+//line cc.y:880
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 113:
+	case 117:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:839
+//line cc.y:885
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 114:
+	case 118:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:844
+//line cc.y:890
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 115:
+	case 119:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:849
+//line cc.y:895
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 116:
+	case 120:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:854
+//line cc.y:900
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 117:
+	case 121:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:859
+//line cc.y:905
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 118:
+	case 122:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:864
+//line cc.y:910
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 119:
+	case 123:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:871
+//line cc.y:917
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 120:
+	case 124:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:876
+//line cc.y:922
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 121:
+	case 125:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:883
+//line cc.y:929
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 122:
+	case 126:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:888
+//line cc.y:934
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 123:
+	case 127:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:896
+//line cc.y:942
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.typ = yyDollar[1].typ
@@ -1882,41 +1995,41 @@ yydefault:
 				yyVAL.typ = &Type{Kind: TypedefType, Name: yyDollar[1].str}
 			}
 		}
-	case 124:
+	case 128:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:912
+//line cc.y:958
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.tc.c, yyVAL.tc.q, yyVAL.tc.t = splitTypeWords(append(yyDollar[1].strs, "int"))
 		}
-	case 125:
+	case 129:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:917
+//line cc.y:963
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.tc.c, yyVAL.tc.q, _ = splitTypeWords(append(yyDollar[1].strs, yyDollar[3].strs...))
 			yyVAL.tc.t = yyDollar[2].typ
 		}
-	case 126:
+	case 130:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:923
+//line cc.y:969
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyDollar[1].strs = append(yyDollar[1].strs, yyDollar[2].str)
 			yyDollar[1].strs = append(yyDollar[1].strs, yyDollar[3].strs...)
 			yyVAL.tc.c, yyVAL.tc.q, yyVAL.tc.t = splitTypeWords(yyDollar[1].strs)
 		}
-	case 127:
+	case 131:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:930
+//line cc.y:976
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.tc.c, yyVAL.tc.q, _ = splitTypeWords(yyDollar[2].strs)
 			yyVAL.tc.t = yyDollar[1].typ
 		}
-	case 128:
+	case 132:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:936
+//line cc.y:982
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			var ts []string
@@ -1924,9 +2037,9 @@ yydefault:
 			ts = append(ts, yyDollar[2].strs...)
 			yyVAL.tc.c, yyVAL.tc.q, yyVAL.tc.t = splitTypeWords(ts)
 		}
-	case 129:
+	case 133:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:947
+//line cc.y:993
 		{
 			yyVAL.span = yyDollar[1].span
 			if yyDollar[1].tc.c != 0 {
@@ -1937,16 +2050,16 @@ yydefault:
 			}
 			yyVAL.typ = yyDollar[1].tc.t
 		}
-	case 130:
+	case 134:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:960
+//line cc.y:1006
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.typ = yyDollar[2].abdecor(yyDollar[1].typ)
 		}
-	case 131:
+	case 135:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:968
+//line cc.y:1014
 		{
 			lx := yylex.(*lexer)
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
@@ -1964,9 +2077,9 @@ yydefault:
 				yyVAL.decls = append(yyVAL.decls, d)
 			}
 		}
-	case 132:
+	case 136:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:988
+//line cc.y:1034
 		{
 			lx := yylex.(*lexer)
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
@@ -1992,29 +2105,29 @@ yydefault:
 				yyVAL.decls = append(yyVAL.decls, d)
 			}
 		}
-	case 133:
+	case 137:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1016
+//line cc.y:1062
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.decls = yyDollar[1].decls
 		}
-	case 134:
+	case 138:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1021
+//line cc.y:1067
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.decls = []*Decl{yyDollar[1].decl}
 		}
-	case 135:
+	case 139:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line cc.y:1026
+//line cc.y:1072
 		{
 			yyVAL.decls = yyDollar[4].decls
 		}
-	case 136:
+	case 140:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:1032
+//line cc.y:1078
 		{
 			lx := yylex.(*lexer)
 			typ, name := yyDollar[2].decor(yyDollar[1].tc.t)
@@ -2035,9 +2148,9 @@ yydefault:
 				lx.pushDecl(decl)
 			}
 		}
-	case 137:
+	case 141:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line cc.y:1053
+//line cc.y:1099
 		{
 			yylex.(*lexer).popScope()
 			yyVAL.span = span(yyDollar[1].span, yyDollar[5].span)
@@ -2048,119 +2161,115 @@ yydefault:
 			}
 			yyVAL.decl.Body = yyDollar[5].stmt
 		}
-	case 138:
+	case 142:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1066
+//line cc.y:1112
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 139:
+	case 143:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1071
+//line cc.y:1117
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 140:
+	case 144:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1079
+//line cc.y:1125
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.tk = Struct
 		}
-	case 141:
+	case 145:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1084
+//line cc.y:1130
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.tk = Union
 		}
-	case 142:
+	case 146:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1091
+//line cc.y:1137
 		{
 			yyVAL.span = yyDollar[1].span
-			yyVAL.decor = yyDollar[1].decor
+			yyVAL.sudec = sudecor{yyDollar[1].decor, nil}
 		}
-	case 143:
+	case 147:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:1096
+//line cc.y:1142
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			name := yyDollar[1].str
-			expr := yyDollar[3].expr
-			yyVAL.decor = func(t *Type) (*Type, string) {
-				t.Width = expr
-				return t, name
-			}
+			yyVAL.sudec = sudecor{func(t *Type) (*Type, string) { return t, name }, yyDollar[3].expr}
 		}
-	case 144:
+	case 148:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:1108
+//line cc.y:1150
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.decls = nil
-			for _, decor := range yyDollar[2].decors {
-				typ, name := decor(yyDollar[1].typ)
-				yyVAL.decls = append(yyVAL.decls, &Decl{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Name: name, Type: typ})
+			for _, sudec := range yyDollar[2].sudecs {
+				typ, name := sudec.d(yyDollar[1].typ)
+				yyVAL.decls = append(yyVAL.decls, &Decl{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Name: name, Type: typ, Bits: sudec.bits})
 			}
-			if yyDollar[2].decors == nil {
+			if yyDollar[2].sudecs == nil {
 				yyVAL.decls = append(yyVAL.decls, &Decl{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Type: yyDollar[1].typ})
 			}
 		}
-	case 145:
+	case 149:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:1122
+//line cc.y:1164
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.typ = yylex.(*lexer).pushType(&Type{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Kind: yyDollar[1].tk, Tag: yyDollar[2].str})
 		}
-	case 146:
+	case 150:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line cc.y:1127
+//line cc.y:1169
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[5].span)
 			yyVAL.typ = yylex.(*lexer).pushType(&Type{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Kind: yyDollar[1].tk, Tag: yyDollar[2].str, Decls: yyDollar[4].decls})
 		}
-	case 147:
+	case 151:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:1134
+//line cc.y:1176
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.prefix = &Prefix{Span: yyVAL.span, Dot: yyDollar[2].str}
 		}
-	case 148:
+	case 152:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:1141
+//line cc.y:1183
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Arrow, Left: yyDollar[1].expr, Text: yyDollar[3].str}
 		}
-	case 149:
+	case 153:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:1146
+//line cc.y:1188
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.expr = &Expr{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Op: Dot, Left: yyDollar[1].expr, Text: yyDollar[3].str}
 		}
-	case 150:
+	case 154:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:1154
+//line cc.y:1196
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.typ = yylex.(*lexer).pushType(&Type{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Kind: Enum, Tag: yyDollar[2].str})
 		}
-	case 151:
+	case 155:
 		yyDollar = yyS[yypt-6 : yypt+1]
-		//line cc.y:1159
+//line cc.y:1201
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[6].span)
 			yyVAL.typ = yylex.(*lexer).pushType(&Type{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Kind: Enum, Tag: yyDollar[2].str, Decls: yyDollar[4].decls})
 		}
-	case 152:
+	case 156:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:1166
+//line cc.y:1208
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			var x *Init
@@ -2170,441 +2279,441 @@ yydefault:
 			yyVAL.decl = &Decl{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Name: yyDollar[1].str, Init: x}
 			yylex.(*lexer).pushDecl(yyVAL.decl)
 		}
-	case 153:
+	case 157:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:1178
+//line cc.y:1220
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.expr = yyDollar[2].expr
 		}
-	case 154:
+	case 158:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1186
+//line cc.y:1228
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.init = &Init{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Expr: yyDollar[1].expr}
 		}
-	case 155:
+	case 159:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1191
+//line cc.y:1233
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.init = &Init{SyntaxInfo: SyntaxInfo{Span: yyVAL.span}, Braced: yyDollar[1].inits}
 		}
-	case 156:
+	case 160:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:1198
+//line cc.y:1240
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.inits = []*Init{}
 		}
-	case 157:
+	case 161:
 		yyDollar = yyS[yypt-4 : yypt+1]
-		//line cc.y:1203
+//line cc.y:1245
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[4].span)
 			yyVAL.inits = append(yyDollar[2].inits, yyDollar[3].init)
 		}
-	case 158:
+	case 162:
 		yyDollar = yyS[yypt-5 : yypt+1]
-		//line cc.y:1208
+//line cc.y:1250
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[5].span)
 			yyVAL.inits = append(yyDollar[2].inits, yyDollar[3].init)
 		}
-	case 159:
+	case 163:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1214
+//line cc.y:1256
 		{
 			yyVAL.span = Span{}
 			yyVAL.inits = nil
 		}
-	case 160:
+	case 164:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:1219
+//line cc.y:1261
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.inits = append(yyDollar[1].inits, yyDollar[2].init)
 		}
-	case 161:
+	case 165:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1226
+//line cc.y:1268
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.init = yyDollar[1].init
 		}
-	case 162:
+	case 166:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:1231
+//line cc.y:1273
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.init = yyDollar[3].init
 			yyVAL.init.Prefix = yyDollar[1].prefixes
 		}
-	case 163:
+	case 167:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:1239
+//line cc.y:1281
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.prefix = &Prefix{Span: yyVAL.span, Index: yyDollar[2].expr}
 		}
-	case 164:
+	case 168:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1245
+//line cc.y:1287
 		{
 			yyVAL.span = Span{}
 		}
-	case 165:
+	case 169:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1249
+//line cc.y:1291
 		{
 			yyVAL.span = yyDollar[1].span
 		}
-	case 166:
+	case 170:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1254
+//line cc.y:1296
 		{
 			yyVAL.span = Span{}
 		}
-	case 167:
+	case 171:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1258
+//line cc.y:1300
 		{
 			yyVAL.span = yyDollar[1].span
 		}
-	case 168:
+	case 172:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1267
+//line cc.y:1309
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.prefixes = []*Prefix{yyDollar[1].prefix}
 		}
-	case 169:
+	case 173:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:1272
+//line cc.y:1314
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.prefixes = append(yyDollar[1].prefixes, yyDollar[2].prefix)
 		}
-	case 170:
+	case 174:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1278
+//line cc.y:1320
 		{
 			yyVAL.span = Span{}
 			yyVAL.str = ""
 		}
-	case 171:
+	case 175:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1283
+//line cc.y:1325
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.str = yyDollar[1].str
 		}
-	case 172:
+	case 176:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1289
+//line cc.y:1331
 		{
 			yyVAL.span = Span{}
 			yyVAL.expr = nil
 		}
-	case 173:
+	case 177:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1294
+//line cc.y:1336
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.expr = yyDollar[1].expr
 		}
-	case 174:
+	case 178:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1300
+//line cc.y:1342
 		{
 			yyVAL.span = Span{}
 			yyVAL.expr = nil
 		}
-	case 175:
+	case 179:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1305
+//line cc.y:1347
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.expr = yyDollar[1].expr
 		}
-	case 176:
+	case 180:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1312
+//line cc.y:1354
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.exprs = []*Expr{yyDollar[1].expr}
 		}
-	case 177:
+	case 181:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:1317
+//line cc.y:1359
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.exprs = append(yyDollar[1].exprs, yyDollar[3].expr)
 		}
-	case 178:
+	case 182:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1323
+//line cc.y:1365
 		{
 			yyVAL.span = Span{}
 			yyVAL.exprs = nil
 		}
-	case 179:
+	case 183:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1328
+//line cc.y:1370
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.exprs = yyDollar[1].exprs
 		}
-	case 180:
+	case 184:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1334
+//line cc.y:1376
 		{
 			yyVAL.span = Span{}
 			yyVAL.decls = nil
 		}
-	case 181:
+	case 185:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:1339
+//line cc.y:1381
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.decls = append(yyDollar[1].decls, yyDollar[2].decls...)
 		}
-	case 182:
+	case 186:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1345
+//line cc.y:1387
 		{
 			yyVAL.span = Span{}
 			yyVAL.labels = nil
 		}
-	case 183:
+	case 187:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:1350
+//line cc.y:1392
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.labels = append(yyDollar[1].labels, yyDollar[2].label)
 		}
-	case 184:
+	case 188:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1357
+//line cc.y:1399
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.decls = []*Decl{yyDollar[1].decl}
 		}
-	case 185:
+	case 189:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:1362
+//line cc.y:1404
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.decls = append(yyDollar[1].decls, yyDollar[3].decl)
 		}
-	case 186:
+	case 190:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1368
+//line cc.y:1410
 		{
 			yyVAL.span = Span{}
 			yyVAL.decls = nil
 		}
-	case 187:
+	case 191:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1373
+//line cc.y:1415
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.decls = yyDollar[1].decls
 		}
-	case 188:
+	case 192:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1380
+//line cc.y:1422
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.idecs = []idecor{yyDollar[1].idec}
 		}
-	case 189:
+	case 193:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:1385
+//line cc.y:1427
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.idecs = append(yyDollar[1].idecs, yyDollar[3].idec)
 		}
-	case 190:
+	case 194:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1391
+//line cc.y:1433
 		{
 			yyVAL.span = Span{}
 			yyVAL.idecs = nil
 		}
-	case 191:
+	case 195:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1396
+//line cc.y:1438
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.idecs = yyDollar[1].idecs
 		}
-	case 192:
+	case 196:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1403
+//line cc.y:1445
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.strs = []string{yyDollar[1].str}
 		}
-	case 193:
+	case 197:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:1408
+//line cc.y:1450
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.strs = append(yyDollar[1].strs, yyDollar[2].str)
 		}
-	case 194:
+	case 198:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1414
+//line cc.y:1456
 		{
 			yyVAL.span = Span{}
 			yyVAL.strs = nil
 		}
-	case 195:
+	case 199:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1419
+//line cc.y:1461
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.strs = yyDollar[1].strs
 		}
-	case 196:
+	case 200:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1426
+//line cc.y:1468
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.strs = []string{yyDollar[1].str}
 		}
-	case 197:
+	case 201:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:1431
+//line cc.y:1473
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.strs = append(yyDollar[1].strs, yyDollar[2].str)
 		}
-	case 198:
+	case 202:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1437
+//line cc.y:1479
 		{
 			yyVAL.span = Span{}
 			yyVAL.strs = nil
 		}
-	case 199:
+	case 203:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1442
+//line cc.y:1484
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.strs = yyDollar[1].strs
 		}
-	case 200:
+	case 204:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1449
+//line cc.y:1491
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.strs = []string{yyDollar[1].str}
 		}
-	case 201:
+	case 205:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:1454
+//line cc.y:1496
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.strs = append(yyDollar[1].strs, yyDollar[2].str)
 		}
-	case 202:
+	case 206:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1460
+//line cc.y:1502
 		{
 			yyVAL.span = Span{}
 			yyVAL.strs = nil
 		}
-	case 203:
+	case 207:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1465
+//line cc.y:1507
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.strs = yyDollar[1].strs
 		}
-	case 204:
+	case 208:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1472
+//line cc.y:1514
 		{
 			yyVAL.span = yyDollar[1].span
-			yyVAL.decors = nil
-			yyVAL.decors = append(yyVAL.decors, yyDollar[1].decor)
+			yyVAL.sudecs = nil
+			yyVAL.sudecs = append(yyVAL.sudecs, yyDollar[1].sudec)
 		}
-	case 205:
+	case 209:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:1478
+//line cc.y:1520
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
-			yyVAL.decors = append(yyDollar[1].decors, yyDollar[3].decor)
+			yyVAL.sudecs = append(yyDollar[1].sudecs, yyDollar[3].sudec)
 		}
-	case 206:
+	case 210:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1484
+//line cc.y:1526
 		{
 			yyVAL.span = Span{}
-			yyVAL.decors = nil
+			yyVAL.sudecs = nil
 		}
-	case 207:
+	case 211:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1489
+//line cc.y:1531
 		{
 			yyVAL.span = yyDollar[1].span
-			yyVAL.decors = yyDollar[1].decors
+			yyVAL.sudecs = yyDollar[1].sudecs
 		}
-	case 208:
+	case 212:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1496
+//line cc.y:1538
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.decls = yyDollar[1].decls
 		}
-	case 209:
+	case 213:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:1501
+//line cc.y:1543
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.decls = append(yyDollar[1].decls, yyDollar[2].decls...)
 		}
-	case 210:
+	case 214:
 		yyDollar = yyS[yypt-0 : yypt+1]
-		//line cc.y:1507
+//line cc.y:1549
 		{
 			yyVAL.span = Span{}
 			yyVAL.expr = nil
 		}
-	case 211:
+	case 215:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1512
+//line cc.y:1554
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.expr = yyDollar[1].expr
 		}
-	case 212:
+	case 216:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1519
+//line cc.y:1561
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.decls = []*Decl{yyDollar[1].decl}
 		}
-	case 213:
+	case 217:
 		yyDollar = yyS[yypt-3 : yypt+1]
-		//line cc.y:1524
+//line cc.y:1566
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[3].span)
 			yyVAL.decls = append(yyDollar[1].decls, yyDollar[3].decl)
 		}
-	case 214:
+	case 218:
 		yyDollar = yyS[yypt-1 : yypt+1]
-		//line cc.y:1531
+//line cc.y:1573
 		{
 			yyVAL.span = yyDollar[1].span
 			yyVAL.strs = []string{yyDollar[1].str}
 		}
-	case 215:
+	case 219:
 		yyDollar = yyS[yypt-2 : yypt+1]
-		//line cc.y:1536
+//line cc.y:1578
 		{
 			yyVAL.span = span(yyDollar[1].span, yyDollar[2].span)
 			yyVAL.strs = append(yyDollar[1].strs, yyDollar[2].str)
@@ -0,0 +1,453 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package interp
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// An lvalue is the location of an object.
+type lvalue struct {
+	addr uint64
+	t    *cc.Type
+	bits int64 // width of a bit-field, or 0
+	off  int64 // offset in bits of a bit-field from addr
+}
+
+// load returns the value of type t stored at addr.
+// Arrays and functions are converted to pointers to them.
+func (mc *machine) load(addr uint64, t *cc.Type) value {
+	d := t.Def()
+	switch k := d.Kind; {
+	case k == cc.Array:
+		return ptrValue(d.Base, addr)
+	case k == cc.Func:
+		return ptrValue(d, addr)
+	case k == cc.Struct || k == cc.Union:
+		b := mc.mem.bytes(addr, mc.sizeof(d))
+		return value{t: d, b: append([]byte(nil), b...)}
+	case k == cc.Float:
+		return value{t: d, f: float64(math.Float32frombits(uint32(mc.mem.loadUint(addr, 4))))}
+	case k == cc.Double:
+		return value{t: d, f: math.Float64frombits(mc.mem.loadUint(addr, 8))}
+	case isInt(k) || k == cc.Ptr:
+		return value{t: d, i: mc.extend(k, mc.mem.loadUint(addr, mc.model.KindSize(k)))}
+	}
+	panic(&Error{Msg: "cannot load value of type " + t.String()})
+}
+
+// store stores v at addr.
+func (mc *machine) store(addr uint64, v value) {
+	switch k := v.t.Kind; {
+	case k == cc.Struct || k == cc.Union:
+		copy(mc.mem.bytes(addr, int64(len(v.b))), v.b)
+	case k == cc.Float:
+		mc.mem.storeUint(addr, 4, uint64(math.Float32bits(float32(v.f))))
+	case k == cc.Double:
+		mc.mem.storeUint(addr, 8, math.Float64bits(v.f))
+	case isInt(k) || k == cc.Ptr:
+		mc.mem.storeUint(addr, mc.model.KindSize(k), v.i)
+	default:
+		panic(&Error{Msg: "cannot store value of type " + v.t.String()})
+	}
+}
+
+func (mc *machine) loadLvalue(l lvalue) value {
+	if l.bits == 0 {
+		return mc.load(l.addr, l.t)
+	}
+	k := l.t.Def().Kind
+	n := (l.off + l.bits + 7) / 8
+	var bits uint64
+	for i, b := range mc.mem.bytes(l.addr, n) {
+		bits |= uint64(b) << uint(8*i)
+	}
+	bits = bits >> uint(l.off) & (1<<uint(l.bits) - 1)
+	if mc.signed(k) && bits&(1<<uint(l.bits-1)) != 0 {
+		bits |= ^uint64(0) << uint(l.bits)
+	}
+	return mc.intValue(k, bits)
+}
+
+// storeLvalue converts v to the type of l, stores it and returns it.
+func (mc *machine) storeLvalue(l lvalue, v value) value {
+	v = mc.convert(v, l.t)
+	if l.bits == 0 {
+		mc.store(l.addr, v)
+		return v
+	}
+	n := (l.off + l.bits + 7) / 8
+	b := mc.mem.bytes(l.addr, n)
+	var bits uint64
+	for i := range b {
+		bits |= uint64(b[i]) << uint(8*i)
+	}
+	mask := (uint64(1)<<uint(l.bits) - 1) << uint(l.off)
+	bits = bits&^mask | v.i<<uint(l.off)&mask
+	for i := range b {
+		b[i] = byte(bits >> uint(8*i))
+	}
+	return mc.loadLvalue(l)
+}
+
+// fieldLvalue returns the lvalue of field of the struct or union
+// of type t at addr.
+func (mc *machine) fieldLvalue(addr uint64, t *cc.Type, field *cc.Decl) lvalue {
+	off := t.BitOffset(mc.model, field.Name)
+	if off < 0 {
+		panic(&Error{Msg: "unknown field " + field.Name})
+	}
+	if field.Bits == nil {
+		return lvalue{addr: addr + uint64(off/8), t: field.Type}
+	}
+	n, _ := cc.ConstInt(field.Bits, mc.model)
+	return lvalue{addr: addr + uint64(off/8), t: field.Type, bits: n, off: off % 8}
+}
+
+// lvalue returns the location designated by x.
+func (mc *machine) lvalue(x *cc.Expr) lvalue {
+	switch x.Op {
+	case cc.Paren:
+		return mc.lvalue(x.Left)
+
+	case cc.Name:
+		d := x.XDecl
+		if mc.frame != nil {
+			if addr, ok := mc.frame.vars[d]; ok {
+				return lvalue{addr: addr, t: d.Type}
+			}
+		}
+		if addr, ok := mc.globals[d]; ok {
+			return lvalue{addr: addr, t: d.Type}
+		}
+		if g := mc.byName[x.Text]; g != nil {
+			if addr, ok := mc.globals[g]; ok {
+				return lvalue{addr: addr, t: g.Type}
+			}
+		}
+		panic(&Error{Msg: "undefined: " + x.Text})
+
+	case cc.Indir:
+		p := mc.eval(x.Left)
+		return lvalue{addr: p.i, t: p.t.Base}
+
+	case cc.Index:
+		p, i := mc.eval(x.Left), mc.eval(x.Right)
+		if !isPtr(p.t.Kind) {
+			p, i = i, p
+		}
+		p = mc.ptrAdd(p, i, 1)
+		return lvalue{addr: p.i, t: p.t.Base}
+
+	case cc.Dot:
+		l := mc.lvalue(x.Left)
+		return mc.fieldLvalue(l.addr, l.t.Def(), x.XDecl)
+
+	case cc.Arrow:
+		p := mc.eval(x.Left)
+		return mc.fieldLvalue(p.i, p.t.Base.Def(), x.XDecl)
+
+	case cc.String:
+		return lvalue{addr: mc.stringAddr(x), t: x.XType}
+	}
+
+	// A struct or union value, such as the result of a call,
+	// is stored in a temporary object.
+	v := mc.eval(x)
+	if v.t.Kind != cc.Struct && v.t.Kind != cc.Union {
+		panic(&Error{Msg: "invalid lvalue " + x.String()})
+	}
+	addr := mc.temp(x, int64(len(v.b)), "temporary")
+	mc.store(addr, v)
+	return lvalue{addr: addr, t: v.t}
+}
+
+// temp returns the address of the object holding the value of x
+// in the current call, which is reused each time x is evaluated.
+func (mc *machine) temp(x *cc.Expr, size int64, name string) uint64 {
+	if mc.frame == nil {
+		return mc.mem.alloc(size, name).addr
+	}
+	addr, ok := mc.frame.temps[x]
+	if !ok {
+		addr = mc.mem.push(size, name).addr
+		mc.frame.temps[x] = addr
+	}
+	return addr
+}
+
+// ptrAdd returns the pointer p advanced by i times sign elements.
+func (mc *machine) ptrAdd(p, i value, sign int64) value {
+	size := mc.sizeof(p.t.Base)
+	n := int64(i.i)
+	if !mc.signed(i.t.Kind) && i.t.Kind != cc.Enum {
+		n = int64(mc.extend(cc.Ulonglong, i.i))
+	}
+	return value{t: &cc.Type{Kind: cc.Ptr, Base: p.t.Base}, i: mc.extend(cc.Ptr, p.i+uint64(sign*n*size))}
+}
+
+func (mc *machine) stringValue(x *cc.Expr) []byte {
+	s, err := cc.StringValue(x)
+	if err != nil {
+		panic(&Error{Msg: err.Error()})
+	}
+	return append([]byte(s), 0)
+}
+
+// stringAddr returns the address of the string literal x.
+func (mc *machine) stringAddr(x *cc.Expr) uint64 {
+	addr, ok := mc.strings[x]
+	if !ok {
+		s := mc.stringValue(x)
+		obj := mc.mem.alloc(int64(len(s)), "string")
+		copy(obj.data, s)
+		addr = obj.addr
+		mc.strings[x] = addr
+	}
+	return addr
+}
+
+// eval returns the value of x.
+func (mc *machine) eval(x *cc.Expr) value {
+	switch x.Op {
+	default:
+		panic(&Error{Msg: "unsupported expression " + x.Op.String()})
+
+	case cc.Paren:
+		return mc.eval(x.Left)
+
+	case cc.Number:
+		c, err := cc.Eval(x, mc.model)
+		if err != nil {
+			panic(&Error{Msg: err.Error()})
+		}
+		if c.IsInt() {
+			return mc.intValue(c.Kind, uint64(c.Int))
+		}
+		return value{t: kindTypes[c.Kind], f: c.Float}
+
+	case cc.String:
+		return ptrValue(x.XType.Def().Base, mc.stringAddr(x))
+
+	case cc.Name:
+		d := x.XDecl
+		if d == nil {
+			panic(&Error{Msg: "undefined: " + x.Text})
+		}
+		if d.OuterType != nil && d.OuterType.Kind == cc.Enum {
+			n, ok := cc.EnumValue(d, mc.model)
+			if !ok {
+				panic(&Error{Msg: "invalid enumeration constant " + d.Name})
+			}
+			return mc.intValue(cc.Int, uint64(n))
+		}
+		if d.Type.Is(cc.Func) {
+			return ptrValue(d.Type.Def(), mc.funcAddr(d))
+		}
+		return mc.loadLvalue(mc.lvalue(x))
+
+	case cc.Indir, cc.Index, cc.Dot, cc.Arrow:
+		return mc.loadLvalue(mc.lvalue(x))
+
+	case cc.Addr:
+		if x.Left.Op == cc.Name && x.Left.XDecl != nil && x.Left.XDecl.Type.Is(cc.Func) {
+			return mc.eval(x.Left)
+		}
+		l := mc.lvalue(x.Left)
+		if l.bits != 0 {
+			panic(&Error{Msg: "cannot take address of bit-field"})
+		}
+		return ptrValue(l.t.Def(), l.addr)
+
+	case cc.SizeofExpr:
+		return mc.intValue(mc.model.SizeKind(), uint64(mc.sizeof(x.Left.XType)))
+
+	case cc.SizeofType:
+		return mc.intValue(mc.model.SizeKind(), uint64(mc.sizeof(x.Type)))
+
+	case cc.Offsetof:
+		return mc.intValue(mc.model.SizeKind(), uint64(x.Type.Offset(mc.model, x.Left.Text)))
+
+	case cc.Cast:
+		return mc.convert(mc.eval(x.Left), x.Type)
+
+	case cc.CastInit:
+		addr := mc.temp(x, mc.sizeof(x.Type), "compound literal")
+		b := mc.mem.bytes(addr, mc.sizeof(x.Type))
+		for i := range b {
+			b[i] = 0
+		}
+		mc.initialize(addr, x.Type, x.Init)
+		return mc.load(addr, x.Type)
+
+	case cc.Comma:
+		var v value
+		for _, y := range x.List {
+			v = mc.eval(y)
+		}
+		return v
+
+	case cc.Cond:
+		l := x.List[1]
+		if !truth(mc.eval(x.List[0])) {
+			l = x.List[2]
+		}
+		v := mc.eval(l)
+		if t := x.XType; t != nil && isArithKind(t.Def().Kind) && isArithKind(v.t.Kind) {
+			v = mc.convert(v, t)
+		}
+		return v
+
+	case cc.AndAnd:
+		return mc.boolValue(truth(mc.eval(x.Left)) && truth(mc.eval(x.Right)))
+
+	case cc.OrOr:
+		return mc.boolValue(truth(mc.eval(x.Left)) || truth(mc.eval(x.Right)))
+
+	case cc.Not:
+		return mc.boolValue(!truth(mc.eval(x.Left)))
+
+	case cc.Plus:
+		return mc.promote(mc.eval(x.Left))
+
+	case cc.Minus:
+		v := mc.promote(mc.eval(x.Left))
+		if isFloat(v.t.Kind) {
+			return value{t: v.t, f: -v.f}
+		}
+		return mc.intValue(v.t.Kind, -v.i)
+
+	case cc.Twid:
+		v := mc.promote(mc.eval(x.Left))
+		return mc.intValue(v.t.Kind, ^v.i)
+
+	case cc.Add, cc.Sub, cc.Mul, cc.Div, cc.Mod, cc.And, cc.Or, cc.Xor, cc.Lsh, cc.Rsh,
+		cc.EqEq, cc.NotEq, cc.Lt, cc.LtEq, cc.Gt, cc.GtEq:
+		return mc.binary(x.Op, mc.eval(x.Left), mc.eval(x.Right))
+
+	case cc.Eq:
+		l := mc.lvalue(x.Left)
+		return mc.storeLvalue(l, mc.eval(x.Right))
+
+	case cc.AddEq, cc.SubEq, cc.MulEq, cc.DivEq, cc.ModEq, cc.AndEq, cc.OrEq, cc.XorEq, cc.LshEq, cc.RshEq:
+		l := mc.lvalue(x.Left)
+		v := mc.binary(assignOps[x.Op], mc.loadLvalue(l), mc.eval(x.Right))
+		return mc.storeLvalue(l, v)
+
+	case cc.PreInc, cc.PreDec, cc.PostInc, cc.PostDec:
+		l := mc.lvalue(x.Left)
+		old := mc.loadLvalue(l)
+		op := cc.Add
+		if x.Op == cc.PreDec || x.Op == cc.PostDec {
+			op = cc.Sub
+		}
+		v := mc.storeLvalue(l, mc.binary(op, old, value{t: intType, i: 1}))
+		if x.Op == cc.PostInc || x.Op == cc.PostDec {
+			return old
+		}
+		return v
+
+	case cc.Call:
+		return mc.evalCall(x)
+
+	case cc.VaArg:
+		f := mc.frame
+		if len(f.varargs) == 0 {
+			panic(&Error{Msg: "va_arg with no arguments left"})
+		}
+		v := f.varargs[0]
+		f.varargs = f.varargs[1:]
+		return mc.convert(v, x.Type)
+	}
+}
+
+var assignOps = map[cc.ExprOp]cc.ExprOp{
+	cc.AddEq: cc.Add,
+	cc.SubEq: cc.Sub,
+	cc.MulEq: cc.Mul,
+	cc.DivEq: cc.Div,
+	cc.ModEq: cc.Mod,
+	cc.AndEq: cc.And,
+	cc.OrEq:  cc.Or,
+	cc.XorEq: cc.Xor,
+	cc.LshEq: cc.Lsh,
+	cc.RshEq: cc.Rsh,
+}
+
+func isArithKind(k cc.TypeKind) bool {
+	return isInt(k) || isFloat(k)
+}
+
+// binary applies the binary operator op to l and r,
+// which may be pointers.
+func (mc *machine) binary(op cc.ExprOp, l, r value) value {
+	lp, rp := isPtr(l.t.Kind), isPtr(r.t.Kind)
+	switch {
+	case !lp && !rp:
+		return mc.arith(op, l, r)
+	case op == cc.Add && lp && !rp:
+		return mc.ptrAdd(l, r, 1)
+	case op == cc.Add && rp && !lp:
+		return mc.ptrAdd(r, l, 1)
+	case op == cc.Sub && lp && !rp:
+		return mc.ptrAdd(l, r, -1)
+	case op == cc.Sub:
+		size := mc.sizeof(l.t.Base)
+		return mc.intValue(cc.Long, uint64(int64(l.i-r.i)/size))
+	}
+	// Comparison of pointers, or of a pointer and an integer.
+	x, y := l.i, r.i
+	switch op {
+	case cc.EqEq:
+		return mc.boolValue(x == y)
+	case cc.NotEq:
+		return mc.boolValue(x != y)
+	case cc.Lt:
+		return mc.boolValue(x < y)
+	case cc.LtEq:
+		return mc.boolValue(x <= y)
+	case cc.Gt:
+		return mc.boolValue(x > y)
+	case cc.GtEq:
+		return mc.boolValue(x >= y)
+	}
+	panic(&Error{Msg: "invalid operator " + op.String() + " for pointers"})
+}
+
+// evalCall evaluates the call x.
+func (mc *machine) evalCall(x *cc.Expr) value {
+	mc.step(x)
+	var fn *cc.Decl
+	var libcName string
+	if x.Left.Op == cc.Name && x.Left.XDecl != nil && x.Left.XDecl.Type.Is(cc.Func) {
+		fn = x.Left.XDecl
+	} else {
+		p := mc.eval(x.Left)
+		obj, off := mc.mem.object(p.i, 0)
+		if off != 0 || obj.fn == nil && obj.libc == "" {
+			panic(&Error{Msg: "call of non-function"})
+		}
+		fn, libcName = obj.fn, obj.libc
+	}
+
+	var params []*cc.Decl
+	if fn != nil {
+		params = fn.Type.Def().Decls
+	}
+	var args []value
+	for i, arg := range x.List {
+		v := mc.eval(arg)
+		if i >= len(params) || params[i].Name == "..." {
+			v = mc.promoteArg(v)
+		}
+		args = append(args, v)
+	}
+	if fn == nil {
+		return mc.callLibc(libc[libcName], x.XType, args)
+	}
+	return mc.call(fn, args)
+}
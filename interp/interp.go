@@ -0,0 +1,323 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package interp executes type-checked C programs read by package cc.
+//
+// It is a reference for the translations made by c2go: a program can be
+// run by the interpreter and as translated to Go, and their output and
+// exit status compared. Integers have the widths of the data model,
+// pointers address byte-addressed objects and are checked on each access,
+// and a small part of the C library is provided.
+package interp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// Options control how a program is run.
+type Options struct {
+	// Model is the data model giving the sizes of types.
+	// If nil, LP64 is assumed.
+	Model *cc.Model
+
+	// Args are the arguments passed to main, starting with the program name.
+	Args []string
+
+	// Stdin and Stdout are the standard input and output of the program.
+	// If nil, the program reads nothing and its output is discarded.
+	Stdin  io.Reader
+	Stdout io.Writer
+
+	// MaxSteps, if positive, is the number of statements and calls
+	// executed before the program is stopped with an error.
+	MaxSteps int64
+}
+
+// An Error is a runtime error in the C program,
+// such as an invalid memory access or a division by zero.
+type Error struct {
+	Span cc.Span
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	if e.Span.Start.File == "" {
+		return e.Msg
+	}
+	return fmt.Sprintf("%s: %s", e.Span, e.Msg)
+}
+
+// exit is the panic value of a call to exit.
+type exit int
+
+// maxDepth is the depth of calls at which the program is stopped.
+const maxDepth = 10000
+
+type machine struct {
+	opts   *Options
+	model  *cc.Model
+	mem    *memory
+	stdin  *bufio.Reader
+	stdout *bufio.Writer
+	steps  int64
+	depth  int
+
+	span    cc.Span                // span of the syntax being executed
+	globals map[*cc.Decl]uint64    // addresses of static variables
+	byName  map[string]*cc.Decl    // top-level declarations
+	funcs   map[*cc.Decl]uint64    // addresses of functions
+	libcs   map[string]uint64      // addresses of library functions
+	strings map[*cc.Expr]uint64    // addresses of string literals
+	labels  map[*cc.Decl]*fnLabels // labels of the functions called
+	frame   *frame
+}
+
+// A frame holds the state of a function call.
+type frame struct {
+	fn      *cc.Decl
+	vars    map[*cc.Decl]uint64 // addresses of the local variables
+	temps   map[*cc.Expr]uint64 // addresses of compound literals and temporaries
+	labels  *fnLabels
+	ret     value     // result, after a return statement
+	target  *cc.Label // label to go to, after a goto statement
+	varargs []value   // arguments passed in place of ...
+}
+
+// Run runs the main function of prog and returns its exit status,
+// the result of main or the argument of exit.
+// If the program does something undefined that the interpreter
+// detects, Run stops it and returns an error, usually an *Error.
+func Run(prog *cc.Prog, opts *Options) (status int, err error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	mc := &machine{
+		opts:    opts,
+		model:   opts.Model,
+		mem:     newMemory(),
+		globals: make(map[*cc.Decl]uint64),
+		byName:  make(map[string]*cc.Decl),
+		funcs:   make(map[*cc.Decl]uint64),
+		libcs:   make(map[string]uint64),
+		strings: make(map[*cc.Expr]uint64),
+		labels:  make(map[*cc.Decl]*fnLabels),
+	}
+	if mc.model == nil {
+		mc.model = cc.LP64
+	}
+	stdin, stdout := opts.Stdin, opts.Stdout
+	if stdin == nil {
+		stdin = eofReader{}
+	}
+	if stdout == nil {
+		stdout = ioutil.Discard
+	}
+	mc.stdin = bufio.NewReader(stdin)
+	mc.stdout = bufio.NewWriter(stdout)
+	defer func() {
+		if ferr := mc.stdout.Flush(); err == nil {
+			err = ferr
+		}
+	}()
+	defer func() {
+		switch e := recover().(type) {
+		case nil:
+		case exit:
+			status = int(e)
+		case *Error:
+			if e.Span.Start.File == "" {
+				e.Span = mc.span
+			}
+			status, err = -1, e
+		default:
+			panic(e)
+		}
+	}()
+
+	mc.initGlobals(prog)
+	main := mc.byName["main"]
+	if main == nil || main.Body == nil {
+		return -1, fmt.Errorf("no main function")
+	}
+	var args []value
+	if params := main.Type.Decls; len(params) >= 2 {
+		args = append(args, value{t: intType, i: uint64(len(opts.Args))}, mc.argv())
+	}
+	v := mc.call(main, args)
+	if v.t.Kind == cc.Void {
+		return 0, nil
+	}
+	return int(int32(v.i)), nil
+}
+
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) { return 0, io.EOF }
+
+// argv allocates the argument vector of main.
+func (mc *machine) argv() value {
+	ptr := mc.model.Ptr
+	obj := mc.mem.alloc(int64(len(mc.opts.Args)+1)*ptr, "argv")
+	for i, arg := range mc.opts.Args {
+		s := mc.mem.alloc(int64(len(arg)+1), "argv")
+		copy(s.data, arg)
+		mc.mem.storeUint(obj.addr+uint64(int64(i)*ptr), ptr, s.addr)
+	}
+	return ptrValue(charPtr, obj.addr)
+}
+
+// step counts the execution of x against the step limit.
+func (mc *machine) step(x cc.Syntax) {
+	mc.span = x.GetSpan()
+	mc.steps++
+	if mc.opts.MaxSteps > 0 && mc.steps > mc.opts.MaxSteps {
+		panic(&Error{Msg: "step limit exceeded"})
+	}
+}
+
+// initGlobals allocates and initializes the static variables of prog.
+func (mc *machine) initGlobals(prog *cc.Prog) {
+	var vars []*cc.Decl
+	for _, d := range prog.Decls {
+		if d.Name == "" || d.Macro != nil || d.Storage&cc.Typedef != 0 {
+			continue
+		}
+		if old := mc.byName[d.Name]; old != nil && old.Body != nil {
+			continue
+		}
+		mc.byName[d.Name] = d
+		if d.Type.Is(cc.Func) {
+			continue
+		}
+		if _, ok := mc.globals[d]; ok {
+			continue
+		}
+		mc.globals[d] = mc.mem.alloc(mc.sizeof(d.Type), d.Name).addr
+		vars = append(vars, d)
+	}
+	for _, d := range vars {
+		if d.Init != nil {
+			mc.span = d.Span
+			mc.initialize(mc.globals[d], d.Type, d.Init)
+		}
+	}
+}
+
+// sizeof returns the size of t, evaluating the widths of
+// variable-length arrays.
+func (mc *machine) sizeof(t *cc.Type) int64 {
+	if size := t.Size(mc.model); size >= 0 {
+		return size
+	}
+	t = t.Def()
+	if t.Kind == cc.Array && t.Width != nil {
+		n := mc.convert(mc.eval(t.Width), kindTypes[cc.Long])
+		return int64(n.i) * mc.sizeof(t.Base)
+	}
+	if t.Kind == cc.Void || t.Kind == cc.Func {
+		// As GCC does, for arithmetic on void* and function pointers.
+		return 1
+	}
+	panic(&Error{Msg: fmt.Sprintf("size of %v is unknown", t)})
+}
+
+// funcAddr returns the address of the function d.
+func (mc *machine) funcAddr(d *cc.Decl) uint64 {
+	if d1 := mc.byName[d.Name]; d1 != nil && d1.Type.Is(cc.Func) && d.CurFn == nil {
+		d = d1
+	}
+	if d.Body == nil {
+		if _, ok := libc[d.Name]; ok {
+			return mc.libcAddr(d.Name)
+		}
+	}
+	addr, ok := mc.funcs[d]
+	if !ok {
+		obj := mc.mem.alloc(1, d.Name)
+		obj.fn = d
+		addr = obj.addr
+		mc.funcs[d] = addr
+	}
+	return addr
+}
+
+func (mc *machine) libcAddr(name string) uint64 {
+	addr, ok := mc.libcs[name]
+	if !ok {
+		obj := mc.mem.alloc(1, name)
+		obj.libc = name
+		addr = obj.addr
+		mc.libcs[name] = addr
+	}
+	return addr
+}
+
+// call calls the function fn with the arguments args,
+// which are converted to the types of its parameters.
+func (mc *machine) call(fn *cc.Decl, args []value) value {
+	if fn.Body == nil {
+		if d := mc.byName[fn.Name]; d != nil && d.Body != nil {
+			fn = d
+		}
+	}
+	if fn.Body == nil {
+		f, ok := libc[fn.Name]
+		if !ok {
+			panic(&Error{Msg: "call of undefined function " + fn.Name})
+		}
+		return mc.callLibc(f, fn.Type.Def().Base, args)
+	}
+	if mc.depth >= maxDepth {
+		panic(&Error{Msg: "call stack too deep"})
+	}
+	mc.depth++
+	defer func() { mc.depth-- }()
+
+	outer := mc.frame
+	mark := mc.mem.mark()
+	f := &frame{
+		fn:     fn,
+		vars:   make(map[*cc.Decl]uint64),
+		temps:  make(map[*cc.Expr]uint64),
+		labels: mc.fnLabels(fn),
+	}
+	params := fn.Type.Def().Decls
+	if len(params) == 1 && params[0].Type != nil && params[0].Type.Is(cc.Void) {
+		params = nil
+	}
+	for i, p := range params {
+		if p.Name == "..." {
+			f.varargs = args[i:]
+			break
+		}
+		if i >= len(args) {
+			panic(&Error{Msg: "not enough arguments in call of " + fn.Name})
+		}
+		addr := mc.mem.push(mc.sizeof(p.Type), p.Name).addr
+		mc.store(addr, mc.convert(args[i], p.Type))
+		f.vars[p] = addr
+	}
+	mc.frame = f
+	defer func() {
+		mc.frame = outer
+		mc.mem.release(mark)
+	}()
+
+	mc.exec(fn.Body, nil)
+	t := fn.Type.Def().Base
+	if t.Is(cc.Void) {
+		return value{t: voidType}
+	}
+	if f.ret.t == nil {
+		// Falling off the end of a function returns an indeterminate value,
+		// except for main, which returns 0.
+		return mc.convert(value{t: intType}, t)
+	}
+	return mc.convert(f.ret, t)
+}
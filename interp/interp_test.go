@@ -0,0 +1,381 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package interp_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/cingo/cc"
+	. "github.com/hajimehoshi/cingo/interp"
+)
+
+var runTests = []struct {
+	name   string
+	model  *cc.Model
+	src    string
+	out    string
+	status int
+	err    string
+}{
+	{
+		name:   "return",
+		src:    `int main(void) { return 3; }`,
+		out:    "",
+		status: 3,
+	},
+	{
+		name: "widths",
+		src: `
+#include <stdio.h>
+int main(void) {
+	unsigned char c = 255;
+	signed char s = 127;
+	unsigned int u = 0;
+	short h = 32767;
+	c++;
+	s++;
+	u--;
+	h++;
+	printf("%d %d %u %d\n", c, s, u, h);
+	printf("%d %d\n", -7 / 2, -7 % 2);
+	printf("%d\n", (unsigned int)-1 > 0);
+	printf("%d\n", -1 < (unsigned char)1);
+	return 0;
+}`,
+		out: "0 -128 4294967295 -32768\n-3 -1\n1\n1\n",
+	},
+	{
+		name: "pointers",
+		src: `
+#include <stdio.h>
+int sum(int *p, int n) {
+	int s = 0;
+	while (n-- > 0)
+		s += *p++;
+	return s;
+}
+int main(void) {
+	int a[5] = {1, 2, 3, 4, 5};
+	int *p = &a[4], *q = a;
+	char *s = "hello";
+	printf("%d %ld %d\n", sum(a, 5), (long)(p - q), *(s + 1));
+	p[-1] = 10;
+	printf("%d %d\n", a[3], 2[a]);
+	return 0;
+}`,
+		out: "15 4 101\n10 3\n",
+	},
+	{
+		name: "structs",
+		src: `
+#include <stdio.h>
+struct point { int x, y; };
+struct rect { struct point min, max; char name[8]; };
+struct point add(struct point p, struct point q) {
+	p.x += q.x;
+	p.y += q.y;
+	return p;
+}
+int main(void) {
+	struct rect r = {{1, 2}, {3, 4}, "box"};
+	struct rect *rp = &r;
+	struct point p = add(r.min, rp->max);
+	struct rect r2 = r;
+	r2.min.x = 100;
+	printf("%d %d %s %d %d\n", p.x, p.y, rp->name, r.min.x, r2.min.x);
+	printf("%d\n", (int)sizeof r);
+	return 0;
+}`,
+		out: "4 6 box 1 100\n24\n",
+	},
+	{
+		name: "control",
+		src: `
+#include <stdio.h>
+int f(int n) {
+	switch (n) {
+	case 0:
+		return 10;
+	case 1:
+	case 2:
+		n *= 2;
+	default:
+		n++;
+		break;
+	case 5:
+		goto out;
+	}
+	return n;
+out:
+	return -1;
+}
+int main(void) {
+	int i, n = 0;
+	for (i = 0; i < 6; i++) {
+		if (i == 3)
+			continue;
+		printf("%d ", f(i));
+	}
+	do {
+		n++;
+	} while (n < 10);
+again:
+	if (n > 0) {
+		n -= 4;
+		goto again;
+	}
+	printf("%d\n", n);
+	return 0;
+}`,
+		out: "10 3 5 5 -1 -2\n",
+	},
+	{
+		name: "printf",
+		src: `
+#include <stdio.h>
+int main(void) {
+	printf("[%5d|%-5d|%05d|%x|%#o|%c|%.2s|%%]\n", 42, 42, 42, 255, 8, 'A', "abc");
+	printf("%g %f %.3e %g\n", 0.1, 1.5, 12345.678, 1e20);
+	printf("%ld %lu %lld\n", -1L, 1UL << 40, 1LL << 62);
+	return 0;
+}`,
+		out: "[   42|42   |00042|ff|010|A|ab|%]\n0.1 1.500000 1.235e+04 1e+20\n-1 1099511627776 4611686018427387904\n",
+	},
+	{
+		name: "malloc",
+		src: `
+#include <stdio.h>
+#include <stdlib.h>
+void *malloc(size_t);
+void free(void*);
+char *strcpy(char*, char*);
+int strlen(char*);
+int main(void) {
+	int *p = calloc(4, sizeof(int));
+	char *s = malloc(16);
+	p[3] = 7;
+	strcpy(s, "interp");
+	printf("%d %d %s %d\n", p[0], p[3], s, strlen(s));
+	free(p);
+	free(s);
+	return 0;
+}`,
+		out: "0 7 interp 6\n",
+	},
+	{
+		name: "out of memory",
+		src: `
+#include <stdio.h>
+#include <stdlib.h>
+void *malloc(size_t);
+void *realloc(void*, size_t);
+int main(void) {
+	char *p = malloc(16);
+	p[0] = 'x';
+	printf("%d\n", malloc(1UL << 40) == 0);
+	printf("%d\n", calloc(1UL << 62, 8) == 0);
+	printf("%d %c\n", realloc(p, 1UL << 40) == 0, p[0]);
+	return 0;
+}`,
+		out: "1\n1\n1 x\n",
+	},
+	{
+		name: "stack overflow",
+		src: `
+int main(void) { char buf[1L << 40]; buf[0] = 1; return buf[0]; }`,
+		err: "stack overflow",
+	},
+	{
+		name: "function pointers",
+		src: `
+#include <stdio.h>
+int twice(int x) { return 2 * x; }
+int square(int x) { return x * x; }
+int (*ops[])(int) = {twice, square};
+int apply(int (*f)(int), int x) { return f(x); }
+int main(void) {
+	int i;
+	for (i = 0; i < 2; i++)
+		printf("%d %d\n", ops[i](5), apply(ops[i], 3));
+	return (*ops[0])(1);
+}`,
+		out:    "10 6\n25 9\n",
+		status: 2,
+	},
+	{
+		name: "varargs",
+		src: `
+#include <stdio.h>
+#include <u.h>
+void va_start(va_list, void*);
+void va_end(va_list);
+int sum(int n, ...) {
+	va_list ap;
+	int s = 0;
+	va_start(ap, &n);
+	while (n-- > 0)
+		s += va_arg(ap, int);
+	va_end(ap);
+	return s;
+}
+int main(void) {
+	printf("%d\n", sum(3, 1, 2, 3));
+	return 0;
+}`,
+		out: "6\n",
+	},
+	{
+		name: "bit-fields",
+		src: `
+#include <stdio.h>
+struct flags { unsigned int a : 3; int b : 4; unsigned int c : 1; };
+int main(void) {
+	struct flags f = {5, -3, 1};
+	f.a++;
+	f.c++;
+	printf("%d %d %d\n", f.a, f.b, f.c);
+	f.a = 9;
+	printf("%d\n", f.a);
+	return 0;
+}`,
+		out: "6 -3 0\n1\n",
+	},
+	{
+		name:  "ILP32",
+		model: cc.ILP32,
+		src: `
+#include <stdio.h>
+int main(void) {
+	long l = 2147483647L;
+	unsigned long u = l;
+	u++;
+	printf("%d %d %lu\n", (int)sizeof(long), (int)sizeof(char*), u + u);
+	return 0;
+}`,
+		out: "4 4 0\n",
+	},
+	{
+		name: "globals and statics",
+		src: `
+#include <stdio.h>
+int counter;
+char msg[] = "hi";
+int next(void) {
+	static int n = 100;
+	return n++ + counter++;
+}
+int main(void) {
+	next();
+	next();
+	printf("%d %s %d\n", next(), msg, (int)sizeof msg);
+	return 0;
+}`,
+		out: "104 hi 3\n",
+	},
+	{
+		name: "temporaries",
+		src: `
+#include <stdio.h>
+struct pair { int a, b; };
+struct pair mk(int a) { struct pair p = {a, a + 1}; return p; }
+int g = 3;
+int *gp = &g;
+int main(void) {
+	int i, s = 0;
+	struct pair *p;
+	for (i = 0; i < 100000; i++)
+		s += mk(i).b & 1;
+	p = &(struct pair){*gp, 4};
+	printf("%d %d %d\n", s, p->a, p->b);
+	return 0;
+}`,
+		out: "50000 3 4\n",
+	},
+	{
+		name: "exit",
+		src: `
+#include <stdio.h>
+void exit(int);
+int main(void) {
+	printf("bye\n");
+	exit(4);
+	return 0;
+}`,
+		out:    "bye\n",
+		status: 4,
+	},
+	{
+		name: "out of bounds",
+		src: `
+int main(void) {
+	int a[2];
+	int *p = a;
+	return p[2];
+}`,
+		err: "x.c:5: invalid memory access",
+	},
+	{
+		name: "division by zero",
+		src: `
+int zero;
+int main(void) { return 1 / zero; }`,
+		err: "integer division by zero",
+	},
+	{
+		name: "use after free",
+		src: `
+#include <stdlib.h>
+void *malloc(size_t);
+void free(void*);
+int main(void) {
+	int *p = malloc(sizeof(int));
+	free(p);
+	return *p;
+}`,
+		err: "use of freed memory",
+	},
+}
+
+func TestRun(t *testing.T) {
+	for _, tt := range runTests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &cc.Options{Model: tt.model}
+			prog, err := opts.Read("x.c", strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			var out bytes.Buffer
+			status, err := Run(prog, &Options{Model: tt.model, Stdout: &out, MaxSteps: 1e6})
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if out.String() != tt.out {
+				t.Errorf("output:\n%s\nwant:\n%s", out.String(), tt.out)
+			}
+			if status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestStepLimit(t *testing.T) {
+	prog, err := (&cc.Options{}).Read("x.c", strings.NewReader(`int main(void) { for (;;); }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Run(prog, &Options{MaxSteps: 1000})
+	if err == nil || !strings.Contains(err.Error(), "step limit exceeded") {
+		t.Fatalf("error = %v, want step limit exceeded", err)
+	}
+}
@@ -0,0 +1,566 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package interp
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// A libcFunc implements a function of the C library.
+// Its arguments have been promoted but not converted to
+// the types of the parameters.
+type libcFunc func(mc *machine, args []value) value
+
+// libc holds the library functions a program may call.
+var libc map[string]libcFunc
+
+func init() {
+	libc = map[string]libcFunc{
+		"printf":   libcPrintf,
+		"sprintf":  libcSprintf,
+		"snprintf": libcSnprintf,
+		"puts":     libcPuts,
+		"putchar":  libcPutchar,
+		"getchar":  libcGetchar,
+
+		"malloc":  libcMalloc,
+		"calloc":  libcCalloc,
+		"realloc": libcRealloc,
+		"free":    libcFree,
+
+		"memset":  libcMemset,
+		"memcpy":  libcMemmove,
+		"memmove": libcMemmove,
+		"memcmp":  libcMemcmp,
+		"strlen":  libcStrlen,
+		"strcmp":  libcStrcmp,
+		"strncmp": libcStrncmp,
+		"strcpy":  libcStrcpy,
+		"strncpy": libcStrncpy,
+		"strcat":  libcStrcat,
+		"strchr":  libcStrchr,
+		"strrchr": libcStrrchr,
+		"strstr":  libcStrstr,
+		"strdup":  libcStrdup,
+
+		"atoi":  libcAtoi,
+		"abs":   libcAbs,
+		"exit":  libcExit,
+		"abort": libcAbort,
+
+		"isdigit": ctype(func(c byte) bool { return '0' <= c && c <= '9' }),
+		"isalpha": ctype(isAlpha),
+		"isalnum": ctype(func(c byte) bool { return isAlpha(c) || '0' <= c && c <= '9' }),
+		"isspace": ctype(func(c byte) bool { return c == ' ' || '\t' <= c && c <= '\r' }),
+
+		// The variable arguments of a function are kept in its frame,
+		// so va_start and va_end have nothing to do.
+		"va_start": func(mc *machine, args []value) value { return value{t: voidType} },
+		"va_end":   func(mc *machine, args []value) value { return value{t: voidType} },
+	}
+}
+
+// callLibc calls f and converts its result to t.
+func (mc *machine) callLibc(f libcFunc, t *cc.Type, args []value) value {
+	v := f(mc, args)
+	if t == nil || t.Is(cc.Void) || v.t == nil {
+		return value{t: voidType}
+	}
+	return mc.convert(v, t)
+}
+
+// arg returns the i'th argument in args.
+func arg(args []value, i int) value {
+	if i >= len(args) {
+		panic(&Error{Msg: "not enough arguments in call"})
+	}
+	return args[i]
+}
+
+// size returns the i'th argument in args as a size.
+func (mc *machine) size(args []value, i int) int64 {
+	n := int64(mc.convert(arg(args, i), kindTypes[cc.Longlong]).i)
+	if n < 0 {
+		panic(&Error{Msg: "negative size"})
+	}
+	return n
+}
+
+func (mc *machine) voidPtr(addr uint64) value {
+	return value{t: voidPtr, i: addr}
+}
+
+func (mc *machine) charPtr(addr uint64) value {
+	return value{t: charPtr, i: addr}
+}
+
+func (mc *machine) int(n int64) value {
+	return mc.intValue(cc.Int, uint64(n))
+}
+
+func libcPrintf(mc *machine, args []value) value {
+	b := mc.format(mc.mem.cstring(arg(args, 0).i), args[1:])
+	mc.stdout.Write(b)
+	return mc.int(int64(len(b)))
+}
+
+func libcSprintf(mc *machine, args []value) value {
+	b := mc.format(mc.mem.cstring(arg(args, 1).i), args[2:])
+	dst := mc.mem.bytes(arg(args, 0).i, int64(len(b))+1)
+	copy(dst, b)
+	dst[len(b)] = 0
+	return mc.int(int64(len(b)))
+}
+
+func libcSnprintf(mc *machine, args []value) value {
+	n := mc.size(args, 1)
+	b := mc.format(mc.mem.cstring(arg(args, 2).i), args[3:])
+	if n > 0 {
+		w := b
+		if int64(len(w)) > n-1 {
+			w = w[:n-1]
+		}
+		dst := mc.mem.bytes(args[0].i, int64(len(w))+1)
+		copy(dst, w)
+		dst[len(w)] = 0
+	}
+	return mc.int(int64(len(b)))
+}
+
+func libcPuts(mc *machine, args []value) value {
+	mc.stdout.Write(mc.mem.cstring(arg(args, 0).i))
+	mc.stdout.WriteByte('\n')
+	return mc.int(0)
+}
+
+func libcPutchar(mc *machine, args []value) value {
+	c := byte(arg(args, 0).i)
+	mc.stdout.WriteByte(c)
+	return mc.int(int64(c))
+}
+
+func libcGetchar(mc *machine, args []value) value {
+	c, err := mc.stdin.ReadByte()
+	if err != nil {
+		return mc.int(-1)
+	}
+	return mc.int(int64(c))
+}
+
+func libcMalloc(mc *machine, args []value) value {
+	obj := mc.mem.tryAlloc(mc.size(args, 0), "malloc")
+	if obj == nil {
+		return mc.voidPtr(0)
+	}
+	obj.heap = true
+	return mc.voidPtr(obj.addr)
+}
+
+func libcCalloc(mc *machine, args []value) value {
+	n, size := mc.size(args, 0), mc.size(args, 1)
+	if size != 0 && n > math.MaxInt64/size {
+		return mc.voidPtr(0)
+	}
+	obj := mc.mem.tryAlloc(n*size, "calloc")
+	if obj == nil {
+		return mc.voidPtr(0)
+	}
+	obj.heap = true
+	return mc.voidPtr(obj.addr)
+}
+
+func libcRealloc(mc *machine, args []value) value {
+	p := arg(args, 0).i
+	obj := mc.mem.tryAlloc(mc.size(args, 1), "realloc")
+	if obj == nil {
+		// The old object is left alone.
+		return mc.voidPtr(0)
+	}
+	obj.heap = true
+	if p != 0 {
+		old := mc.heapObject(p)
+		copy(obj.data, old.data)
+		old.freed = true
+	}
+	return mc.voidPtr(obj.addr)
+}
+
+func libcFree(mc *machine, args []value) value {
+	if p := arg(args, 0).i; p != 0 {
+		mc.heapObject(p).freed = true
+	}
+	return value{t: voidType}
+}
+
+// heapObject returns the object allocated by malloc at addr.
+func (mc *machine) heapObject(addr uint64) *object {
+	obj, off := mc.mem.object(addr, 0)
+	if off != 0 || !obj.heap {
+		panic(&Error{Msg: "free of pointer not allocated by malloc"})
+	}
+	return obj
+}
+
+func libcMemset(mc *machine, args []value) value {
+	p := arg(args, 0).i
+	c := byte(arg(args, 1).i)
+	b := mc.mem.bytes(p, mc.size(args, 2))
+	for i := range b {
+		b[i] = c
+	}
+	return mc.voidPtr(p)
+}
+
+func libcMemmove(mc *machine, args []value) value {
+	n := mc.size(args, 2)
+	dst, src := arg(args, 0).i, arg(args, 1).i
+	if n > 0 {
+		copy(mc.mem.bytes(dst, n), mc.mem.bytes(src, n))
+	}
+	return mc.voidPtr(dst)
+}
+
+func libcMemcmp(mc *machine, args []value) value {
+	n := mc.size(args, 2)
+	if n == 0 {
+		return mc.int(0)
+	}
+	return mc.int(int64(bytes.Compare(mc.mem.bytes(arg(args, 0).i, n), mc.mem.bytes(args[1].i, n))))
+}
+
+func libcStrlen(mc *machine, args []value) value {
+	return mc.intValue(mc.model.SizeKind(), uint64(len(mc.mem.cstring(arg(args, 0).i))))
+}
+
+func libcStrcmp(mc *machine, args []value) value {
+	return mc.int(int64(bytes.Compare(mc.mem.cstring(arg(args, 0).i), mc.mem.cstring(arg(args, 1).i))))
+}
+
+func libcStrncmp(mc *machine, args []value) value {
+	s, t := mc.mem.cstring(arg(args, 0).i), mc.mem.cstring(arg(args, 1).i)
+	n := mc.size(args, 2)
+	if int64(len(s)) > n {
+		s = s[:n]
+	}
+	if int64(len(t)) > n {
+		t = t[:n]
+	}
+	return mc.int(int64(bytes.Compare(s, t)))
+}
+
+func libcStrcpy(mc *machine, args []value) value {
+	dst := arg(args, 0).i
+	s := mc.mem.cstring(arg(args, 1).i)
+	b := mc.mem.bytes(dst, int64(len(s))+1)
+	copy(b, s)
+	b[len(s)] = 0
+	return mc.charPtr(dst)
+}
+
+func libcStrncpy(mc *machine, args []value) value {
+	dst := arg(args, 0).i
+	s := mc.mem.cstring(arg(args, 1).i)
+	b := mc.mem.bytes(dst, mc.size(args, 2))
+	n := copy(b, s)
+	for i := n; i < len(b); i++ {
+		b[i] = 0
+	}
+	return mc.charPtr(dst)
+}
+
+func libcStrcat(mc *machine, args []value) value {
+	dst := arg(args, 0).i
+	end := dst + uint64(len(mc.mem.cstring(dst)))
+	libcStrcpy(mc, []value{mc.charPtr(end), arg(args, 1)})
+	return mc.charPtr(dst)
+}
+
+func libcStrchr(mc *machine, args []value) value {
+	p := arg(args, 0).i
+	s := mc.mem.cstring(p)
+	c := byte(arg(args, 1).i)
+	if c == 0 {
+		return mc.charPtr(p + uint64(len(s)))
+	}
+	if i := bytes.IndexByte(s, c); i >= 0 {
+		return mc.charPtr(p + uint64(i))
+	}
+	return mc.charPtr(0)
+}
+
+func libcStrrchr(mc *machine, args []value) value {
+	p := arg(args, 0).i
+	s := mc.mem.cstring(p)
+	c := byte(arg(args, 1).i)
+	if c == 0 {
+		return mc.charPtr(p + uint64(len(s)))
+	}
+	if i := bytes.LastIndexByte(s, c); i >= 0 {
+		return mc.charPtr(p + uint64(i))
+	}
+	return mc.charPtr(0)
+}
+
+func libcStrstr(mc *machine, args []value) value {
+	p := arg(args, 0).i
+	if i := bytes.Index(mc.mem.cstring(p), mc.mem.cstring(arg(args, 1).i)); i >= 0 {
+		return mc.charPtr(p + uint64(i))
+	}
+	return mc.charPtr(0)
+}
+
+func libcStrdup(mc *machine, args []value) value {
+	s := mc.mem.cstring(arg(args, 0).i)
+	obj := mc.mem.tryAlloc(int64(len(s))+1, "strdup")
+	if obj == nil {
+		return mc.charPtr(0)
+	}
+	obj.heap = true
+	copy(obj.data, s)
+	return mc.charPtr(obj.addr)
+}
+
+func libcAtoi(mc *machine, args []value) value {
+	s := mc.mem.cstring(arg(args, 0).i)
+	i := 0
+	for i < len(s) && (s[i] == ' ' || '\t' <= s[i] && s[i] <= '\r') {
+		i++
+	}
+	neg := false
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		neg = s[i] == '-'
+		i++
+	}
+	var n int64
+	for ; i < len(s) && '0' <= s[i] && s[i] <= '9'; i++ {
+		n = n*10 + int64(s[i]-'0')
+	}
+	if neg {
+		n = -n
+	}
+	return mc.int(n)
+}
+
+func libcAbs(mc *machine, args []value) value {
+	n := int64(mc.convert(arg(args, 0), intType).i)
+	if n < 0 {
+		n = -n
+	}
+	return mc.int(n)
+}
+
+func libcExit(mc *machine, args []value) value {
+	panic(exit(int32(arg(args, 0).i)))
+}
+
+func libcAbort(mc *machine, args []value) value {
+	panic(&Error{Msg: "abort called"})
+}
+
+func isAlpha(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func ctype(f func(c byte) bool) libcFunc {
+	return func(mc *machine, args []value) value {
+		c := arg(args, 0).i
+		if c < 256 && f(byte(c)) {
+			return mc.int(1)
+		}
+		return mc.int(0)
+	}
+}
+
+// format formats args as printf does with the format f.
+func (mc *machine) format(f []byte, args []value) []byte {
+	var buf bytes.Buffer
+	next := func() value {
+		if len(args) == 0 {
+			panic(&Error{Msg: "not enough arguments for format"})
+		}
+		v := args[0]
+		args = args[1:]
+		return v
+	}
+	for i := 0; i < len(f); i++ {
+		if f[i] != '%' {
+			buf.WriteByte(f[i])
+			continue
+		}
+		i++
+		var flags []byte
+		for ; i < len(f) && bytes.IndexByte([]byte("-+ #0"), f[i]) >= 0; i++ {
+			flags = append(flags, f[i])
+		}
+		width, prec := -1, -1
+		if i < len(f) && f[i] == '*' {
+			width = int(int32(next().i))
+			if width < 0 {
+				flags = append(flags, '-')
+				width = -width
+			}
+			i++
+		} else {
+			for ; i < len(f) && '0' <= f[i] && f[i] <= '9'; i++ {
+				width = max0(width)*10 + int(f[i]-'0')
+			}
+		}
+		if i < len(f) && f[i] == '.' {
+			i++
+			prec = 0
+			if i < len(f) && f[i] == '*' {
+				prec = int(int32(next().i))
+				i++
+			} else {
+				for ; i < len(f) && '0' <= f[i] && f[i] <= '9'; i++ {
+					prec = prec*10 + int(f[i]-'0')
+				}
+			}
+		}
+		k := cc.Int
+		for ; i < len(f) && bytes.IndexByte([]byte("hlLqjzt"), f[i]) >= 0; i++ {
+			switch f[i] {
+			case 'h':
+				if k == cc.Short {
+					k = cc.Char
+				} else {
+					k = cc.Short
+				}
+			case 'l':
+				if k == cc.Long {
+					k = cc.Longlong
+				} else {
+					k = cc.Long
+				}
+			case 'q', 'j', 'L':
+				k = cc.Longlong
+			case 'z', 't':
+				k = mc.model.SizeKind()
+			}
+		}
+		if i >= len(f) {
+			buf.WriteByte('%')
+			break
+		}
+		verb := f[i]
+		spec := "%" + string(flags)
+		if width >= 0 {
+			spec += strconv.Itoa(width)
+		}
+		if prec >= 0 {
+			spec += "." + strconv.Itoa(prec)
+		}
+
+		switch verb {
+		default:
+			panic(&Error{Msg: fmt.Sprintf("unsupported format %%%c", verb)})
+
+		case '%':
+			buf.WriteByte('%')
+
+		case 'd', 'i':
+			v := mc.convert(next(), kindTypes[signedKind(k)])
+			fmt.Fprintf(&buf, spec+"d", int64(v.i))
+
+		case 'u', 'x', 'X', 'o':
+			v := mc.convert(next(), kindTypes[unsignedKind(k)])
+			if verb == 'u' {
+				verb = 'd'
+			}
+			fmt.Fprintf(&buf, spec+string(verb), v.i)
+
+		case 'c':
+			fmt.Fprintf(&buf, "%"+string(flags)+widthSpec(width)+"s", []byte{byte(next().i)})
+
+		case 's':
+			s := mc.mem.cstring(next().i)
+			if prec >= 0 && prec < len(s) {
+				s = s[:prec]
+			}
+			fmt.Fprintf(&buf, "%"+string(flags)+widthSpec(width)+"s", s)
+
+		case 'p':
+			fmt.Fprintf(&buf, "%"+string(flags)+widthSpec(width)+"s", fmt.Sprintf("%#x", next().i))
+
+		case 'f', 'F', 'e', 'E', 'g', 'G':
+			v := mc.convert(next(), doubleType).f
+			if math.IsInf(v, 0) || math.IsNaN(v) {
+				s := "inf"
+				switch {
+				case math.IsNaN(v):
+					s = "nan"
+				case v < 0:
+					s = "-inf"
+				case bytes.IndexByte(flags, '+') >= 0:
+					s = "+inf"
+				}
+				if verb == 'F' || verb == 'E' || verb == 'G' {
+					s = string(bytes.ToUpper([]byte(s)))
+				}
+				fmt.Fprintf(&buf, "%"+string(bytes.Trim(flags, "0"))+widthSpec(width)+"s", s)
+				break
+			}
+			if prec < 0 {
+				spec += ".6"
+			}
+			if verb == 'F' {
+				verb = 'f'
+			}
+			fmt.Fprintf(&buf, spec+string(verb), v)
+		}
+	}
+	return buf.Bytes()
+}
+
+func max0(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+func widthSpec(width int) string {
+	if width < 0 {
+		return ""
+	}
+	return strconv.Itoa(width)
+}
+
+func signedKind(k cc.TypeKind) cc.TypeKind {
+	switch k {
+	case cc.Uchar:
+		return cc.Char
+	case cc.Ushort:
+		return cc.Short
+	case cc.Uint:
+		return cc.Int
+	case cc.Ulong:
+		return cc.Long
+	case cc.Ulonglong:
+		return cc.Longlong
+	}
+	return k
+}
+
+func unsignedKind(k cc.TypeKind) cc.TypeKind {
+	switch k {
+	case cc.Char:
+		return cc.Uchar
+	case cc.Short:
+		return cc.Ushort
+	case cc.Int:
+		return cc.Uint
+	case cc.Long:
+		return cc.Ulong
+	case cc.Longlong:
+		return cc.Ulonglong
+	}
+	return k
+}
@@ -0,0 +1,160 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package interp
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+const (
+	heapBase   = 0x10000
+	stackBase  = 0x40000000
+	stackLimit = 0x80000000
+)
+
+// An object is a region of memory.
+type object struct {
+	addr  uint64
+	data  []byte
+	name  string   // variable name or kind of object, for errors
+	fn    *cc.Decl // function the object stands for
+	libc  string   // library function the object stands for
+	freed bool
+	heap  bool // allocated by malloc
+}
+
+// A memory is a set of objects at distinct addresses,
+// so that pointers can be stored, converted to integers and back,
+// and checked on each access.
+// Static and heap objects live below stackBase, in the order allocated;
+// the objects of running functions live above it, as on a stack,
+// up to stackLimit.
+// A gap follows each object, so that no pointer past the end of
+// an object points into the next one.
+type memory struct {
+	heap      []*object
+	stack     []*object
+	heapNext  uint64
+	stackNext uint64
+}
+
+func newMemory() *memory {
+	return &memory{heapNext: heapBase, stackNext: stackBase}
+}
+
+func (m *memory) alloc(size int64, name string) *object {
+	obj := m.tryAlloc(size, name)
+	if obj == nil {
+		panic(&Error{Msg: "out of memory"})
+	}
+	return obj
+}
+
+// tryAlloc is like alloc but returns nil, as malloc does,
+// if the heap has no room for size bytes.
+func (m *memory) tryAlloc(size int64, name string) *object {
+	if size < 0 || uint64(size) >= stackBase-m.heapNext {
+		return nil
+	}
+	obj := &object{addr: m.heapNext, data: make([]byte, size), name: name}
+	m.heapNext = roundUp(m.heapNext+uint64(size)+1, 16)
+	m.heap = append(m.heap, obj)
+	return obj
+}
+
+func (m *memory) push(size int64, name string) *object {
+	if size < 0 || uint64(size) >= stackLimit-m.stackNext {
+		panic(&Error{Msg: "stack overflow"})
+	}
+	obj := &object{addr: m.stackNext, data: make([]byte, size), name: name}
+	m.stackNext = roundUp(m.stackNext+uint64(size)+1, 16)
+	m.stack = append(m.stack, obj)
+	return obj
+}
+
+// mark returns the state of the stack, to be restored by release
+// when the objects pushed since are no longer live.
+func (m *memory) mark() int {
+	return len(m.stack)
+}
+
+func (m *memory) release(mark int) {
+	if mark < len(m.stack) {
+		m.stackNext = m.stack[mark].addr
+		m.stack = m.stack[:mark]
+	}
+}
+
+// object returns the object holding the size bytes at addr
+// and the offset of addr in it, or panics if there is none.
+func (m *memory) object(addr uint64, size int64) (*object, int64) {
+	if addr == 0 {
+		panic(&Error{Msg: "nil pointer dereference"})
+	}
+	list := m.heap
+	if addr >= stackBase {
+		list = m.stack
+	}
+	i := sort.Search(len(list), func(i int) bool { return list[i].addr > addr }) - 1
+	if i < 0 || addr+uint64(size) > list[i].addr+uint64(len(list[i].data)) {
+		panic(&Error{Msg: "invalid memory access"})
+	}
+	obj := list[i]
+	if obj.freed {
+		panic(&Error{Msg: "use of freed memory"})
+	}
+	return obj, int64(addr - obj.addr)
+}
+
+// bytes returns the size bytes at addr.
+func (m *memory) bytes(addr uint64, size int64) []byte {
+	obj, off := m.object(addr, size)
+	return obj.data[off : off+size]
+}
+
+func (m *memory) loadUint(addr uint64, size int64) uint64 {
+	b := m.bytes(addr, size)
+	switch size {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(binary.LittleEndian.Uint16(b))
+	case 4:
+		return uint64(binary.LittleEndian.Uint32(b))
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+func (m *memory) storeUint(addr uint64, size int64, v uint64) {
+	b := m.bytes(addr, size)
+	switch size {
+	case 1:
+		b[0] = byte(v)
+	case 2:
+		binary.LittleEndian.PutUint16(b, uint16(v))
+	case 4:
+		binary.LittleEndian.PutUint32(b, uint32(v))
+	default:
+		binary.LittleEndian.PutUint64(b, v)
+	}
+}
+
+// cstring returns the NUL-terminated string at addr.
+func (m *memory) cstring(addr uint64) []byte {
+	obj, off := m.object(addr, 1)
+	for i := off; i < int64(len(obj.data)); i++ {
+		if obj.data[i] == 0 {
+			return obj.data[off:i]
+		}
+	}
+	panic(&Error{Msg: "unterminated string"})
+}
+
+func roundUp(n, align uint64) uint64 {
+	return (n + align - 1) / align * align
+}
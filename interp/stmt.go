@@ -0,0 +1,379 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package interp
+
+import (
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// A ctl says how control leaves a statement.
+type ctl int
+
+const (
+	ctlNext ctl = iota
+	ctlBreak
+	ctlContinue
+	ctlReturn
+	ctlGoto
+)
+
+// fnLabels records the labels in the body of a function.
+type fnLabels struct {
+	names map[string]*cc.Label            // named labels
+	in    map[*cc.Label]map[*cc.Stmt]bool // statements containing each label
+	cases map[*cc.Stmt][]*cc.Label        // case and default labels of each switch
+}
+
+func (mc *machine) fnLabels(fn *cc.Decl) *fnLabels {
+	if l := mc.labels[fn]; l != nil {
+		return l
+	}
+	l := &fnLabels{
+		names: make(map[string]*cc.Label),
+		in:    make(map[*cc.Label]map[*cc.Stmt]bool),
+		cases: make(map[*cc.Stmt][]*cc.Label),
+	}
+	var stack []*cc.Stmt
+	var switches []*cc.Stmt
+	var walk func(s *cc.Stmt)
+	walk = func(s *cc.Stmt) {
+		if s == nil {
+			return
+		}
+		stack = append(stack, s)
+		for _, lab := range s.Labels {
+			in := make(map[*cc.Stmt]bool)
+			for _, s := range stack {
+				in[s] = true
+			}
+			l.in[lab] = in
+			switch lab.Op {
+			case cc.LabelName:
+				l.names[lab.Name] = lab
+			case cc.Case, cc.Default:
+				if len(switches) > 0 {
+					sw := switches[len(switches)-1]
+					l.cases[sw] = append(l.cases[sw], lab)
+				}
+			}
+		}
+		if s.Op == cc.Switch {
+			switches = append(switches, s)
+		}
+		walk(s.Body)
+		walk(s.Else)
+		for _, s := range s.Block {
+			walk(s)
+		}
+		if s.Op == cc.Switch {
+			switches = switches[:len(switches)-1]
+		}
+		stack = stack[:len(stack)-1]
+	}
+	walk(fn.Body)
+	mc.labels[fn] = l
+	return l
+}
+
+// contains reports whether the statement s contains the label target.
+func (mc *machine) contains(s *cc.Stmt, target *cc.Label) bool {
+	return s != nil && mc.frame.labels.in[target][s]
+}
+
+// exec executes s. If seek is not nil, execution starts at
+// the statement labeled seek, which s contains.
+func (mc *machine) exec(s *cc.Stmt, seek *cc.Label) ctl {
+	if s == nil {
+		return ctlNext
+	}
+	for _, lab := range s.Labels {
+		if lab == seek {
+			seek = nil
+		}
+	}
+	mc.step(s)
+
+	switch s.Op {
+	default:
+		panic(&Error{Msg: "unsupported statement " + s.Op.String()})
+
+	case cc.Empty:
+
+	case cc.StmtExpr:
+		if seek == nil {
+			mc.eval(s.Expr)
+		}
+
+	case cc.StmtDecl:
+		if seek == nil {
+			mc.declare(s.Decl)
+		}
+
+	case cc.Block:
+		start := 0
+		if seek != nil {
+			start = mc.indexOf(s.Block, seek)
+		}
+		for i := start; i < len(s.Block); i++ {
+			c := mc.exec(s.Block[i], seek)
+			seek = nil
+			if c == ctlGoto {
+				if j := mc.indexOf(s.Block, mc.frame.target); j >= 0 {
+					i, seek = j-1, mc.frame.target
+					continue
+				}
+			}
+			if c != ctlNext {
+				return c
+			}
+		}
+
+	case cc.If:
+		switch {
+		case seek != nil && mc.contains(s.Body, seek):
+			return mc.exec(s.Body, seek)
+		case seek != nil:
+			return mc.exec(s.Else, seek)
+		case truth(mc.eval(s.Expr)):
+			return mc.exec(s.Body, nil)
+		default:
+			return mc.exec(s.Else, nil)
+		}
+
+	case cc.While, cc.For, cc.Do:
+		if seek == nil && s.Op == cc.For && s.Pre != nil {
+			mc.eval(s.Pre)
+		}
+		first := true
+		for {
+			if seek == nil && !(s.Op == cc.Do && first) && s.Expr != nil {
+				mc.step(s.Expr)
+				if !truth(mc.eval(s.Expr)) {
+					break
+				}
+			}
+			first = false
+			c := mc.exec(s.Body, seek)
+			seek = nil
+			if c == ctlBreak {
+				break
+			}
+			if c == ctlReturn || c == ctlGoto {
+				return c
+			}
+			if s.Op == cc.For && s.Post != nil {
+				mc.eval(s.Post)
+			}
+		}
+
+	case cc.Switch:
+		target := seek
+		if target == nil {
+			target = mc.switchTarget(s)
+			if target == nil {
+				return ctlNext
+			}
+		}
+		c := mc.exec(s.Body, target)
+		if c == ctlBreak {
+			return ctlNext
+		}
+		return c
+
+	case cc.Break:
+		return ctlBreak
+
+	case cc.Continue:
+		return ctlContinue
+
+	case cc.Return:
+		if s.Expr != nil {
+			mc.frame.ret = mc.eval(s.Expr)
+		}
+		return ctlReturn
+
+	case cc.Goto:
+		target := mc.frame.labels.names[s.Text]
+		if target == nil {
+			panic(&Error{Msg: "goto undefined label " + s.Text})
+		}
+		mc.frame.target = target
+		return ctlGoto
+	}
+	return ctlNext
+}
+
+// indexOf returns the index of the statement in list containing target,
+// or -1 if there is none.
+func (mc *machine) indexOf(list []*cc.Stmt, target *cc.Label) int {
+	for i, s := range list {
+		if mc.contains(s, target) {
+			return i
+		}
+	}
+	return -1
+}
+
+// switchTarget returns the label of the switch statement s
+// where execution starts, or nil if the body is skipped.
+func (mc *machine) switchTarget(s *cc.Stmt) *cc.Label {
+	v := mc.promote(mc.eval(s.Expr))
+	var def *cc.Label
+	for _, lab := range mc.frame.labels.cases[s] {
+		if lab.Op == cc.Default {
+			def = lab
+			continue
+		}
+		c := mc.convert(mc.eval(lab.Expr), v.t)
+		if c.i == v.i {
+			return lab
+		}
+	}
+	return def
+}
+
+// declare allocates the variable declared by d in a block
+// and initializes it.
+func (mc *machine) declare(d *cc.Decl) {
+	if d.Name == "" || d.Storage&(cc.Typedef|cc.Extern) != 0 || d.Type.Is(cc.Func) {
+		return
+	}
+	if d.Storage&cc.Static != 0 {
+		if _, ok := mc.globals[d]; !ok {
+			mc.globals[d] = mc.mem.alloc(mc.sizeof(d.Type), d.Name).addr
+			if d.Init != nil {
+				mc.initialize(mc.globals[d], d.Type, d.Init)
+			}
+		}
+		return
+	}
+	addr, ok := mc.frame.vars[d]
+	if !ok {
+		addr = mc.mem.push(mc.sizeof(d.Type), d.Name).addr
+		mc.frame.vars[d] = addr
+	}
+	if d.Init != nil {
+		b := mc.mem.bytes(addr, mc.sizeof(d.Type))
+		for i := range b {
+			b[i] = 0
+		}
+		mc.initialize(addr, d.Type, d.Init)
+	}
+}
+
+// initialize initializes the object of type t at addr with init.
+// The object is zero to begin with.
+func (mc *machine) initialize(addr uint64, t *cc.Type, init *cc.Init) {
+	if init.Braced == nil {
+		mc.initExpr(addr, t, init.Expr)
+		return
+	}
+	rest := mc.initBraced(addr, t, init.Braced, false)
+	if len(rest) > 0 {
+		panic(&Error{Msg: "too many initializers"})
+	}
+}
+
+func (mc *machine) initExpr(addr uint64, t *cc.Type, x *cc.Expr) {
+	if d := t.Def(); d.Kind == cc.Array && x.Op == cc.String {
+		s := mc.stringValue(x)
+		b := mc.mem.bytes(addr, mc.sizeof(d))
+		copy(b, s)
+		return
+	}
+	mc.store(addr, mc.convert(mc.eval(x), t))
+}
+
+// initBraced initializes the array, struct or union of type t at addr
+// with the elements of list. If elided is set, the braces around
+// the elements have been left out, and initBraced stops at the first
+// designated element or when t is complete.
+// It returns the elements left over.
+func (mc *machine) initBraced(addr uint64, t *cc.Type, list []*cc.Init, elided bool) []*cc.Init {
+	t = t.Def()
+	if t.Kind != cc.Array && t.Kind != cc.Struct && t.Kind != cc.Union {
+		// Braces around a scalar initializer.
+		if len(list) > 0 {
+			mc.initialize(addr, t, list[0])
+			list = list[1:]
+		}
+		return list
+	}
+
+	n := int64(-1)
+	if t.Kind == cc.Array {
+		if t.Width != nil {
+			n = mc.sizeof(t) / mc.sizeof(t.Base)
+		}
+	} else {
+		n = int64(len(t.Decls))
+	}
+	for i := int64(0); len(list) > 0; i++ {
+		elem := list[0]
+		if len(elem.Prefix) > 0 {
+			if elided {
+				break
+			}
+			pre := elem.Prefix[0]
+			if t.Kind == cc.Array {
+				i = int64(mc.convert(mc.eval(pre.Index), kindTypes[cc.Long]).i)
+			} else {
+				for j, d := range t.Decls {
+					if d == pre.XDecl || d.Name == pre.Dot {
+						i = int64(j)
+					}
+				}
+			}
+		}
+		if n >= 0 && i >= n || t.Kind == cc.Union && i > 0 && len(elem.Prefix) == 0 {
+			if elided {
+				break
+			}
+			panic(&Error{Msg: "too many initializers"})
+		}
+
+		var et *cc.Type
+		var eaddr uint64
+		var field *cc.Decl
+		if t.Kind == cc.Array {
+			et = t.Base
+			eaddr = addr + uint64(i*mc.sizeof(et))
+		} else {
+			field = t.Decls[i]
+			et = field.Type
+			eaddr = addr + uint64(t.Offset(mc.model, field.Name))
+		}
+
+		switch {
+		case elem.Braced != nil:
+			mc.initialize(eaddr, et, elem)
+			list = list[1:]
+		case isAggregate(et) && !mc.initsWhole(et, elem.Expr):
+			list = mc.initBraced(eaddr, et, list, true)
+		case field != nil && field.Bits != nil:
+			mc.storeLvalue(mc.fieldLvalue(addr, t, field), mc.eval(elem.Expr))
+			list = list[1:]
+		default:
+			mc.initExpr(eaddr, et, elem.Expr)
+			list = list[1:]
+		}
+	}
+	return list
+}
+
+func isAggregate(t *cc.Type) bool {
+	t = t.Def()
+	return t.Kind == cc.Array || t.Kind == cc.Struct || t.Kind == cc.Union
+}
+
+// initsWhole reports whether x initializes a whole object of type t
+// rather than its first element.
+func (mc *machine) initsWhole(t *cc.Type, x *cc.Expr) bool {
+	t = t.Def()
+	if t.Kind == cc.Array {
+		return x.Op == cc.String
+	}
+	return x.XType != nil && x.XType.Def().Kind == t.Kind
+}
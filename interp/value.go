@@ -0,0 +1,279 @@
+// Copyright 2018 Hajime Hoshi.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package interp
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/cingo/cc"
+)
+
+// A value is the value of a C expression.
+type value struct {
+	t *cc.Type // type, without typedefs
+	i uint64   // integers, extended to 64 bits, and pointers
+	f float64  // floating-point numbers
+	b []byte   // structs and unions
+}
+
+var (
+	intType    = &cc.Type{Kind: cc.Int}
+	doubleType = &cc.Type{Kind: cc.Double}
+	voidType   = &cc.Type{Kind: cc.Void}
+	charPtr    = &cc.Type{Kind: cc.Ptr, Base: &cc.Type{Kind: cc.Char}}
+	voidPtr    = &cc.Type{Kind: cc.Ptr, Base: voidType}
+)
+
+// kindTypes holds a type of each scalar kind.
+var kindTypes = map[cc.TypeKind]*cc.Type{}
+
+func init() {
+	for k := cc.Char; k <= cc.Double; k++ {
+		kindTypes[k] = &cc.Type{Kind: k}
+	}
+	kindTypes[cc.Int] = intType
+	kindTypes[cc.Double] = doubleType
+}
+
+func isInt(k cc.TypeKind) bool {
+	return cc.Char <= k && k <= cc.Ulonglong || k == cc.Enum
+}
+
+func isFloat(k cc.TypeKind) bool {
+	return k == cc.Float || k == cc.Double
+}
+
+func isPtr(k cc.TypeKind) bool {
+	return k == cc.Ptr || k == cc.Array || k == cc.Func
+}
+
+func (mc *machine) intValue(k cc.TypeKind, n uint64) value {
+	return value{t: kindTypes[k], i: mc.extend(k, n)}
+}
+
+func (mc *machine) boolValue(b bool) value {
+	if b {
+		return value{t: intType, i: 1}
+	}
+	return value{t: intType}
+}
+
+func ptrValue(t *cc.Type, addr uint64) value {
+	return value{t: &cc.Type{Kind: cc.Ptr, Base: t}, i: addr}
+}
+
+// extend truncates n to the size of the integer kind k
+// and extends it back to 64 bits as the signedness of k says.
+func (mc *machine) extend(k cc.TypeKind, n uint64) uint64 {
+	if k == cc.Ptr {
+		if mc.model.Ptr < 8 {
+			return n & (1<<uint(8*mc.model.Ptr) - 1)
+		}
+		return n
+	}
+	return uint64(mc.model.Truncate(k, int64(n)))
+}
+
+func (mc *machine) signed(k cc.TypeKind) bool {
+	return mc.model.IsSigned(k)
+}
+
+// truth reports whether v compares unequal to 0.
+func truth(v value) bool {
+	if isFloat(v.t.Kind) {
+		return v.f != 0
+	}
+	return v.i != 0
+}
+
+// convert converts v to the type t as C does.
+func (mc *machine) convert(v value, t *cc.Type) value {
+	t = t.Def()
+	from := v.t.Kind
+	switch k := t.Kind; {
+	case k == cc.Void:
+		return value{t: t}
+
+	case isFloat(k):
+		f := v.f
+		if !isFloat(from) {
+			if mc.signed(from) {
+				f = float64(int64(v.i))
+			} else {
+				f = float64(v.i)
+			}
+		}
+		if k == cc.Float {
+			f = float64(float32(f))
+		}
+		return value{t: t, f: f}
+
+	case isInt(k) || k == cc.Ptr:
+		n := v.i
+		if isFloat(from) {
+			if math.IsNaN(v.f) || math.IsInf(v.f, 0) {
+				panic(&Error{Msg: "floating-point value out of range in conversion"})
+			}
+			if v.f >= 1<<63 {
+				n = uint64(v.f)
+			} else {
+				n = uint64(int64(v.f))
+			}
+		}
+		if k == cc.Enum {
+			return value{t: t, i: mc.extend(cc.Int, n)}
+		}
+		return value{t: t, i: mc.extend(k, n)}
+	}
+	v.t = t
+	return v
+}
+
+// promote applies the integer promotions to v.
+func (mc *machine) promote(v value) value {
+	k := v.t.Kind
+	if !isInt(k) {
+		return v
+	}
+	if p := mc.model.Promote(k); p != k {
+		return mc.convert(v, kindTypes[p])
+	}
+	return v
+}
+
+// promoteArg applies the default argument promotions to v.
+func (mc *machine) promoteArg(v value) value {
+	if v.t.Kind == cc.Float {
+		return mc.convert(v, doubleType)
+	}
+	return mc.promote(v)
+}
+
+// arith applies the binary operator op, one of the arithmetic,
+// bitwise and comparison operators, to operands that are not pointers.
+func (mc *machine) arith(op cc.ExprOp, l, r value) value {
+	if op == cc.Lsh || op == cc.Rsh {
+		return mc.shift(op, mc.promote(l), mc.promote(r))
+	}
+	lk, rk := l.t.Kind, r.t.Kind
+	if lk == cc.Enum {
+		lk = cc.Int
+	}
+	if rk == cc.Enum {
+		rk = cc.Int
+	}
+	k := mc.model.Common(lk, rk)
+	t := kindTypes[k]
+	l, r = mc.convert(l, t), mc.convert(r, t)
+
+	if isFloat(k) {
+		x, y := l.f, r.f
+		var z float64
+		switch op {
+		case cc.Add:
+			z = x + y
+		case cc.Sub:
+			z = x - y
+		case cc.Mul:
+			z = x * y
+		case cc.Div:
+			z = x / y
+		case cc.EqEq:
+			return mc.boolValue(x == y)
+		case cc.NotEq:
+			return mc.boolValue(x != y)
+		case cc.Lt:
+			return mc.boolValue(x < y)
+		case cc.LtEq:
+			return mc.boolValue(x <= y)
+		case cc.Gt:
+			return mc.boolValue(x > y)
+		case cc.GtEq:
+			return mc.boolValue(x >= y)
+		default:
+			panic(&Error{Msg: "invalid operator " + op.String() + " for floating-point operands"})
+		}
+		return mc.convert(value{t: doubleType, f: z}, t)
+	}
+
+	x, y := l.i, r.i
+	signed := mc.signed(k)
+	var z uint64
+	switch op {
+	case cc.Add:
+		z = x + y
+	case cc.Sub:
+		z = x - y
+	case cc.Mul:
+		z = x * y
+	case cc.Div, cc.Mod:
+		if y == 0 {
+			panic(&Error{Msg: "integer division by zero"})
+		}
+		switch {
+		case !signed && op == cc.Div:
+			z = x / y
+		case !signed:
+			z = x % y
+		case int64(y) == -1:
+			// Avoid the overflow of the most negative value divided by -1.
+			if op == cc.Div {
+				z = -x
+			}
+		case op == cc.Div:
+			z = uint64(int64(x) / int64(y))
+		default:
+			z = uint64(int64(x) % int64(y))
+		}
+	case cc.And:
+		z = x & y
+	case cc.Or:
+		z = x | y
+	case cc.Xor:
+		z = x ^ y
+	case cc.EqEq:
+		return mc.boolValue(x == y)
+	case cc.NotEq:
+		return mc.boolValue(x != y)
+	case cc.Lt, cc.LtEq, cc.Gt, cc.GtEq:
+		var c int
+		switch {
+		case signed && int64(x) < int64(y), !signed && x < y:
+			c = -1
+		case x != y:
+			c = 1
+		}
+		switch op {
+		case cc.Lt:
+			return mc.boolValue(c < 0)
+		case cc.LtEq:
+			return mc.boolValue(c <= 0)
+		case cc.Gt:
+			return mc.boolValue(c > 0)
+		}
+		return mc.boolValue(c >= 0)
+	default:
+		panic(&Error{Msg: "invalid operator " + op.String()})
+	}
+	return mc.intValue(k, z)
+}
+
+func (mc *machine) shift(op cc.ExprOp, l, r value) value {
+	k := l.t.Kind
+	if !isInt(k) || !isInt(r.t.Kind) {
+		panic(&Error{Msg: "invalid shift operands"})
+	}
+	n := r.i
+	if mc.signed(r.t.Kind) && int64(n) < 0 || n >= uint64(8*mc.model.KindSize(k)) {
+		panic(&Error{Msg: "shift count out of range"})
+	}
+	if op == cc.Lsh {
+		return mc.intValue(k, l.i<<n)
+	}
+	if mc.signed(k) {
+		return mc.intValue(k, uint64(int64(l.i)>>n))
+	}
+	return mc.intValue(k, l.i>>n)
+}
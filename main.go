@@ -20,17 +20,59 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/hajimehoshi/cingo/c2go"
 	"github.com/hajimehoshi/cingo/cc"
 )
 
 var (
-	cfgFile = flag.String("c", "", "config file")
-	inc     = flag.String("I", "", "include directory")
+	cfgFile   = flag.String("c", "", "config file")
+	inc       = flag.String("I", "", "include directory")
+	dst       = flag.String("dst", "/tmp/c2go", "root directory of destination module")
+	verbose   = flag.Bool("v", false, "also report C constructs left untranslated")
+	jsonFlag  = flag.Bool("json", false, "print diagnostics as JSON, one object per line, to standard output")
+	werror    = flag.Bool("Werror", false, "exit with an error status if there are warnings")
+	modelFlag = flag.String("model", "", "C data `model`: ILP32, LP64 or LLP64, with optional settings as in LP64,char=unsigned")
+	suggest   = flag.Bool("suggest", false, "print a draft config file to standard output instead of writing Go files")
+	lines     = flag.Bool("lines", false, "write //line directives giving C positions, and a source map file.go.map next to each Go file")
 )
 
+// A macroFlag implements the repeatable -D and -U flags.
+// Definitions are applied in command-line order.
+type macroFlag struct {
+	undef bool
+}
+
+// A macroDef is a single -D or -U flag.
+type macroDef struct {
+	name, value string
+	undef       bool
+}
+
+var macroDefs []macroDef
+
+func (f macroFlag) String() string { return "" }
+
+func (f macroFlag) Set(s string) error {
+	name, value := s, "1"
+	if i := strings.Index(s, "="); i >= 0 && !f.undef {
+		name, value = s[:i], s[i+1:]
+	}
+	macroDefs = append(macroDefs, macroDef{name, value, f.undef})
+	return nil
+}
+
+func init() {
+	flag.Var(macroFlag{}, "D", "define macro `name[=value]` (repeatable)")
+	flag.Var(macroFlag{undef: true}, "U", "undefine macro `name` (repeatable)")
+}
+
 func main() {
 	log.SetFlags(0)
 	flag.Parse()
@@ -40,45 +82,99 @@ func main() {
 		os.Exit(2)
 	}
 
-	if *inc != "" {
-		cc.AddInclude(*inc)
-	}
-
 	args := flag.Args()
 	if len(args) == 0 {
 		flag.Usage()
 	}
 
-	var r []io.Reader
-	files := args
-	for _, file := range files {
-		f, err := os.Open(file)
+	var diags []*c2go.Diagnostic
+	cfg := new(c2go.Config)
+	if *cfgFile != "" {
+		data, err := ioutil.ReadFile(*cfgFile)
 		if err != nil {
 			log.Fatal(err)
 		}
-		r = append(r, f)
-		defer f.Close()
+		cfg, diags = c2go.ParseConfig(*cfgFile, data)
+	}
+	if *inc != "" {
+		cfg.AddInclude(*inc)
 	}
-	prog, err := cc.ReadMany(files, r)
-	if err != nil {
-		log.Fatal(err)
+	for _, m := range macroDefs {
+		if m.undef {
+			cfg.Undefine(m.name)
+		} else {
+			cfg.Define(m.name, m.value)
+		}
 	}
-	cfg := new(Config)
-	if *cfgFile != "" {
-		cfg.read(*cfgFile)
-	}
-	rewriteTypes(cfg, prog)
-	rewriteSyntax(cfg, prog)
-	rewriteLen(cfg, prog)
-	fixGoTypes(cfg, prog)
-	simplifyBool(cfg, prog)
-	renameDecls(cfg, prog)
-	exportDecls(cfg, prog)
-	writeGoFiles(cfg, prog)
-
-	for _, d := range cfg.diffs {
-		if d.used == 0 {
-			fmt.Fprintf(os.Stderr, "%s: unused diff\n", d.line)
+	if *modelFlag != "" {
+		m, err := cc.ParseModel(*modelFlag)
+		if err != nil {
+			log.Fatal(err)
 		}
+		cfg.SetModel(m)
+	}
+	if *lines {
+		cfg.SetLines(true)
+	}
+	if cfg.Module() == "" {
+		cfg.SetModule(filepath.Base(*dst))
+	}
+
+	var inputs []c2go.Input
+	for _, file := range args {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		inputs = append(inputs, c2go.Input{Name: file, Data: data})
+	}
+
+	if *suggest {
+		out, list := c2go.Suggest(cfg, inputs)
+		os.Stdout.Write(out)
+		flushDiagnostics(append(diags, list...))
+		return
+	}
+
+	files, list := c2go.Translate(cfg, inputs)
+	diags = append(diags, list...)
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		file := filepath.Join(*dst, filepath.FromSlash(name))
+		if name == "go.mod" {
+			if _, err := os.Stat(file); err == nil {
+				// Keep the existing module.
+				continue
+			}
+		}
+		os.MkdirAll(filepath.Dir(file), 0777)
+		if err := ioutil.WriteFile(file, files[name], 0666); err != nil {
+			log.Print(err)
+		}
+	}
+
+	flushDiagnostics(diags)
+}
+
+// flushDiagnostics prints the diagnostics in list as selected by the flags
+// and exits if any of them counts as a failure.
+func flushDiagnostics(list []*c2go.Diagnostic) {
+	min, fail := c2go.Warning, c2go.Error
+	if *verbose {
+		min = c2go.Info
+	}
+	if *werror {
+		fail = c2go.Warning
+	}
+	w := io.Writer(os.Stderr)
+	if *jsonFlag {
+		w = os.Stdout
+	}
+	if c2go.WriteDiagnostics(w, list, min, fail, *jsonFlag) {
+		os.Exit(1)
 	}
 }
@@ -4,136 +4,420 @@
 
 // +build ignore
 
+// Test translates the single-exec cases of a local c-testsuite checkout
+// (https://github.com/c-testsuite/c-testsuite) to Go, builds and runs them,
+// and compares their output with the expected one.
+// Each case is also run by the C interpreter in package interp,
+// and a translation whose output or exit status differs from the
+// interpreter's fails.
+//
+// Usage:
+//
+//	go run test.go -testsuite path/to/c-testsuite [-update]
+//
+// It prints a table of the cases that pass, fail or are skipped because
+// they cannot be parsed, with the reason for each, and exits with an
+// error status if a case does worse than recorded in the status file,
+// or is not recorded in it. The status file also records the revision
+// of the c-testsuite checkout it was made from, which must match.
+// With -update it rewrites the status file instead, creating it
+// if it does not exist yet.
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"flag"
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
 
+	"github.com/hajimehoshi/cingo/c2go"
 	"github.com/hajimehoshi/cingo/cc"
+	"github.com/hajimehoshi/cingo/interp"
 )
 
 var (
-	testsuite = flag.String("testsuite", "", "path to the c-testsuite")
+	testsuite = flag.String("testsuite", "", "path to a c-testsuite checkout")
+	status    = flag.String("status", "testsuite.status", "status file recording the result of each case")
+	update    = flag.Bool("update", false, "rewrite the status file with the results")
+	timeout   = flag.Duration("timeout", 10*time.Second, "time limit for building and for running each case")
+	runFlag   = flag.String("run", "", "run only the cases whose names match `regexp`")
 )
 
-var skips = map[string]struct{}{
-	"00044": {}, // Defining a struct in a block
-	"00050": {}, // Initializing a struct in a struct
-	"00053": {}, // Defining a struct in a block
-	"00061": {}, // #define
-	"00062": {}, // #ifdef
-	"00063": {}, // #define
-	"00064": {}, // #define
-	"00065": {}, // #define
-	"00066": {}, // #define
-	"00067": {}, // #if
-	"00068": {}, // #if
-	"00069": {}, // #if
-	"00070": {}, // #ifndef
+// maxSteps limits the statements and calls the interpreter executes for a case.
+const maxSteps = 1e8
+
+// A Result is the outcome of a case.
+type Result string
+
+const (
+	Pass Result = "pass"
+	Fail Result = "fail"
+	Skip Result = "skip" // the C program cannot be parsed
+)
+
+// rank orders results from worst to best.
+var rank = map[Result]int{
+	Skip: 0,
+	Fail: 1,
+	Pass: 2,
 }
 
 type Case struct {
+	Name     string
 	In       []byte
 	Expected []byte
+
+	Result Result
+	Reason string
 }
 
-func run() error {
-	if *testsuite == "" {
-		dir, err := ioutil.TempDir("", "")
+func (c *Case) fail(format string, args ...interface{}) {
+	c.Result = Fail
+	c.Reason = fmt.Sprintf(format, args...)
+}
+
+// readCases reads the cases in dir,
+// each a file name.c with its output in name.c.expected.
+func readCases(dir string) ([]*Case, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.c"))
+	if err != nil {
+		return nil, err
+	}
+	var re *regexp.Regexp
+	if *runFlag != "" {
+		re, err = regexp.Compile(*runFlag)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if err := os.Chdir(dir); err != nil {
-			return err
+	}
+	var cases []*Case
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".c")
+		if re != nil && !re.MatchString(name) {
+			continue
 		}
-		fmt.Println(dir)
-
-		cmd := exec.Command("git", "clone", "https://github.com/c-testsuite/c-testsuite", "--depth", "1")
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return err
+		in, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
 		}
-
-		if err := os.Chdir("c-testsuite"); err != nil {
-			return err
+		expected, err := ioutil.ReadFile(file + ".expected")
+		if err != nil {
+			return nil, err
 		}
-	} else {
-		if err := os.Chdir(*testsuite); err != nil {
-			return err
+		cases = append(cases, &Case{Name: name, In: in, Expected: expected})
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].Name < cases[j].Name })
+	return cases, nil
+}
+
+// run translates c to Go in a new directory in tmp, builds it and runs it.
+func (c *Case) run(tmp string) {
+	// Translate rewrites the syntax tree, so the interpreter
+	// runs a separately parsed copy.
+	want, wantStatus, ierr := c.interpret()
+
+	cfg := new(c2go.Config)
+	out, diags := c2go.Translate(cfg, []c2go.Input{{Name: c.Name + ".c", Data: c.In}})
+	if out == nil {
+		c.Result = Skip
+		c.Reason = "cannot parse"
+		for _, d := range diags {
+			if d.Severity == c2go.Error {
+				c.Reason = d.Message
+				break
+			}
 		}
+		return
+	}
 
+	dir := filepath.Join(tmp, c.Name)
+	for name, data := range out {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(file), 0777)
+		if err := ioutil.WriteFile(file, data, 0666); err != nil {
+			c.fail("%v", err)
+			return
+		}
+	}
+	if err := writeMain(dir, out); err != nil {
+		c.fail("%v", err)
+		return
 	}
 
-	if err := os.Chdir(filepath.Join("tests", "single-exec")); err != nil {
-		return err
+	bin := filepath.Join(dir, "prog")
+	if _, _, err := command(dir, "go", "build", "-o", bin, "."); err != nil {
+		c.fail("build: %v", err)
+		return
+	}
+	stdout, status, err := command(dir, bin)
+	if status < 0 {
+		c.fail("run: %v", err)
+		return
+	}
+	if ierr == nil {
+		switch {
+		case !bytes.Equal(stdout, want):
+			c.fail("diverges from interpreter: output differs")
+			return
+		case status != wantStatus&0xff:
+			c.fail("diverges from interpreter: exit status %d, want %d", status, wantStatus)
+			return
+		}
+	}
+	if err != nil {
+		c.fail("run: %v", err)
+		return
 	}
+	if !bytes.Equal(stdout, c.Expected) {
+		c.fail("output differs")
+		return
+	}
+	c.Result = Pass
+	if ierr != nil {
+		c.Reason = "interpreter: " + ierr.Error()
+	}
+}
 
-	cases := map[string]*Case{}
+// interpret runs c with the interpreter
+// and returns its output and exit status.
+func (c *Case) interpret() (stdout []byte, status int, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("panic: %v", e)
+		}
+	}()
+	prog, err := new(cc.Options).Read(c.Name+".c", bytes.NewReader(c.In))
+	if err != nil {
+		return nil, 0, err
+	}
+	var buf bytes.Buffer
+	status, err = interp.Run(prog, &interp.Options{
+		Args:     []string{"prog"},
+		Stdout:   &buf,
+		MaxSteps: maxSteps,
+	})
+	return buf.Bytes(), status, err
+}
 
-	filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+// writeMain renames the translated C main function
+// and adds a Go main function calling it and exiting with its result.
+func writeMain(dir string, out map[string][]byte) error {
+	for name := range out {
+		if !strings.HasSuffix(name, ".go") || strings.Contains(name, "/") {
+			continue
+		}
+		file := filepath.Join(dir, name)
+		f, err := parser.ParseFile(token.NewFileSet(), file, out[name], 0)
 		if err != nil {
 			return err
 		}
-		toks := strings.SplitN(info.Name(), ".", 2)
-		name := toks[0]
-		ext := toks[1]
-		switch ext {
-		case "c":
-			if _, ok := cases[name]; !ok {
-				cases[name] = &Case{}
-			}
-			content, err := ioutil.ReadFile(path)
-			if err != nil {
-				return err
+		for _, d := range f.Decls {
+			fn, ok := d.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Name.Name != "main" {
+				continue
 			}
-			cases[name].In = content
-		case "c.expected":
-			if _, ok := cases[name]; !ok {
-				cases[name] = &Case{}
+			if fn.Type.Params.NumFields() != 0 {
+				return fmt.Errorf("main takes arguments")
 			}
-			content, err := ioutil.ReadFile(path)
-			if err != nil {
+			data := bytes.Replace(out[name], []byte("\nfunc main()"), []byte("\nfunc cmain()"), 1)
+			if err := ioutil.WriteFile(file, data, 0666); err != nil {
 				return err
 			}
-			cases[name].Expected = content
+			call := "cmain()\n\tos.Exit(0)"
+			if fn.Type.Results.NumFields() != 0 {
+				call = "os.Exit(int(cmain()))"
+			}
+			src := fmt.Sprintf("package main\n\nimport \"os\"\n\nfunc main() {\n\t%s\n}\n", call)
+			return ioutil.WriteFile(filepath.Join(dir, "cmain.go"), []byte(src), 0666)
 		}
-		return nil
-	})
+	}
+	return fmt.Errorf("no main function")
+}
+
+// command runs the named program in dir, with the time limit set by -timeout,
+// and returns its standard output and exit status.
+// The status is -1 if the program could not be run to completion.
+func command(dir, name string, args ...string) ([]byte, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, -1, fmt.Errorf("timed out after %v", *timeout)
+	}
+	status := 0
+	if err != nil {
+		status = -1
+		if e, ok := err.(*exec.ExitError); ok {
+			status = e.ExitCode()
+		}
+		if msg := firstLine(stderr.String()); msg != "" {
+			err = fmt.Errorf("%v: %s", err, msg)
+		}
+	}
+	return stdout.Bytes(), status, err
+}
 
-	names := []string{}
-	for name := range cases {
-		names = append(names, name)
+// firstLine returns the first line of s that is not a comment
+// as printed by the go command.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
 	}
-	sort.Strings(names)
+	return ""
+}
 
-	for _, name := range names {
-		if _, ok := skips[name]; ok {
+// revisionPrefix starts the line of the status file
+// giving the revision of the c-testsuite checkout.
+const revisionPrefix = "# c-testsuite revision "
+
+// readStatus reads the status file, in which each line holds
+// the name of a case, its result and the reason for it,
+// and returns the results and the c-testsuite revision.
+func readStatus(file string) (map[string]Result, string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	results := make(map[string]Result)
+	rev := ""
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, revisionPrefix) {
+			rev = strings.TrimSpace(strings.TrimPrefix(line, revisionPrefix))
 			continue
 		}
-		c := cases[name]
-		prog, err := cc.Read(name+".c", bytes.NewReader(c.In))
-		if err != nil {
-			fmt.Println(err)
-			break
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
 		}
-		_ = prog
+		results[fields[0]] = Result(fields[1])
 	}
+	return results, rev, s.Err()
+}
 
+func writeStatus(file, rev string, cases []*Case) error {
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by go run test.go -update; do not edit.\n")
+	if rev != "" {
+		buf.WriteString(revisionPrefix + rev + "\n")
+	}
+	for _, c := range cases {
+		fmt.Fprintf(&buf, "%s\t%s", c.Name, c.Result)
+		if c.Reason != "" {
+			fmt.Fprintf(&buf, "\t%s", c.Reason)
+		}
+		buf.WriteString("\n")
+	}
+	return ioutil.WriteFile(file, buf.Bytes(), 0666)
+}
+
+func run() error {
+	if *testsuite == "" {
+		return fmt.Errorf("-testsuite is required")
+	}
+	cases, err := readCases(filepath.Join(*testsuite, "tests", "single-exec"))
+	if err != nil {
+		return err
+	}
+	rev, err := revision(*testsuite)
+	if err != nil {
+		return err
+	}
+	old, oldRev, err := readStatus(*status)
+	switch {
+	case *update && os.IsNotExist(err):
+		// Create the status file.
+	case os.IsNotExist(err):
+		return fmt.Errorf("%v; run with -update to create it", err)
+	case err != nil:
+		return err
+	case !*update && oldRev != rev:
+		return fmt.Errorf("%s is for c-testsuite revision %s, not %s", *status, oldRev, rev)
+	}
+
+	tmp, err := ioutil.TempDir("", "c2go-testsuite")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	var wg sync.WaitGroup
+	sem := make(chan bool, runtime.NumCPU())
+	for _, c := range cases {
+		wg.Add(1)
+		sem <- true
+		go func(c *Case) {
+			defer wg.Done()
+			c.run(tmp)
+			<-sem
+		}(c)
+	}
+	wg.Wait()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, ' ', 0)
+	count := make(map[Result]int)
+	var worse []string
+	for _, c := range cases {
+		count[c.Result]++
+		note := ""
+		if r, ok := old[c.Name]; !ok {
+			note = " (new)"
+			worse = append(worse, c.Name)
+		} else if rank[c.Result] < rank[r] {
+			note = " (was " + string(r) + ")"
+			worse = append(worse, c.Name)
+		}
+		fmt.Fprintf(w, "%s\t%s%s\t%s\n", c.Name, c.Result, note, c.Reason)
+	}
+	w.Flush()
+	fmt.Printf("%d pass, %d fail, %d skip\n", count[Pass], count[Fail], count[Skip])
+
+	if *update {
+		return writeStatus(*status, rev, cases)
+	}
+	if len(worse) > 0 {
+		return fmt.Errorf("worse than or missing from %s: %s", *status, strings.Join(worse, " "))
+	}
 	return nil
 }
 
+// revision returns the commit checked out in the c-testsuite checkout dir.
+func revision(dir string) (string, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("finding c-testsuite revision: %v", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 func main() {
 	flag.Parse()
 	if err := run(); err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }